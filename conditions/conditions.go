@@ -0,0 +1,245 @@
+// Package conditions decodes the trade conditions and quote indicators attached to
+// market data.
+//
+// Conditions are looked up in a Table, which maps the numeric IDs used by the REST and
+// WebSocket APIs to names and aggregation rules. restload.Refresh loads the
+// conditions from the REST API into the default table used by the helpers on the
+// WebSocket models. No table is embedded in the package, since a partial one would
+// report real conditions as unknown; until the table is loaded, every condition is
+// unknown.
+package conditions
+
+import (
+	"sort"
+	"sync"
+)
+
+// AssetClass is the asset class a condition applies to.
+type AssetClass string
+
+const (
+	Stocks  AssetClass = "stocks"
+	Options AssetClass = "options"
+	Crypto  AssetClass = "crypto"
+	Forex   AssetClass = "fx"
+)
+
+// DataType is the kind of data a condition is attached to. IDs are unique per asset
+// class and data type.
+type DataType string
+
+const (
+	Trade DataType = "trade"
+	BBO   DataType = "bbo"
+	NBBO  DataType = "nbbo"
+)
+
+// Rules describe whether trades with a condition update an aggregate.
+type Rules struct {
+	UpdatesHighLow   bool `json:"updates_high_low"`
+	UpdatesOpenClose bool `json:"updates_open_close"`
+	UpdatesVolume    bool `json:"updates_volume"`
+}
+
+// Condition is a trade condition or quote indicator.
+type Condition struct {
+	ID           int32      `json:"id"`
+	Type         string     `json:"type"`
+	Name         string     `json:"name"`
+	Abbreviation string     `json:"abbreviation,omitempty"`
+	Description  string     `json:"description,omitempty"`
+	AssetClass   AssetClass `json:"asset_class"`
+	DataTypes    []DataType `json:"data_types"`
+
+	// Exchange is set if the condition only comes from one exchange.
+	Exchange int32 `json:"exchange,omitempty"`
+
+	// Legacy is true if the condition is no longer used by the SIPs.
+	Legacy bool `json:"legacy,omitempty"`
+
+	// Consolidated are the rules for aggregates across all exchanges, and
+	// MarketCenter the rules for aggregates of a single exchange. Conditions without
+	// update rules update everything.
+	Consolidated Rules `json:"consolidated"`
+	MarketCenter Rules `json:"market_center"`
+}
+
+// UpdatesHighLow reports whether trades with this condition update the consolidated
+// high and low.
+func (c Condition) UpdatesHighLow() bool {
+	return c.Consolidated.UpdatesHighLow
+}
+
+// UpdatesOpenClose reports whether trades with this condition update the consolidated
+// open and close.
+func (c Condition) UpdatesOpenClose() bool {
+	return c.Consolidated.UpdatesOpenClose
+}
+
+// UpdatesVolume reports whether trades with this condition update the consolidated
+// volume.
+func (c Condition) UpdatesVolume() bool {
+	return c.Consolidated.UpdatesVolume
+}
+
+// EligibleForLast reports whether trades with this condition can set the last sale
+// price, which is the same as updating the consolidated close.
+func (c Condition) EligibleForLast() bool {
+	return c.Consolidated.UpdatesOpenClose
+}
+
+// Table is a set of conditions. It's safe for concurrent use.
+type Table struct {
+	list       []Condition
+	conditions map[key]Condition
+}
+
+type key struct {
+	assetClass AssetClass
+	dataType   DataType
+	id         int32
+}
+
+// NewTable creates a table from a list of conditions.
+func NewTable(conditions []Condition) *Table {
+	t := &Table{
+		list:       append([]Condition(nil), conditions...),
+		conditions: make(map[key]Condition, len(conditions)),
+	}
+	sort.SliceStable(t.list, func(i, j int) bool {
+		a, b := t.list[i], t.list[j]
+		if a.AssetClass != b.AssetClass {
+			return a.AssetClass < b.AssetClass
+		}
+		if dataType(a) != dataType(b) {
+			return dataType(a) < dataType(b)
+		}
+		return a.ID < b.ID
+	})
+	for _, c := range conditions {
+		for _, dt := range c.DataTypes {
+			t.conditions[key{c.AssetClass, dt, c.ID}] = c
+		}
+	}
+	return t
+}
+
+// Lookup returns a condition by asset class, data type and ID.
+func (t *Table) Lookup(assetClass AssetClass, dataType DataType, id int32) (Condition, bool) {
+	c, ok := t.conditions[key{assetClass, dataType, id}]
+	return c, ok
+}
+
+// All returns every condition, ordered by asset class, data type and ID.
+func (t *Table) All() []Condition {
+	return append([]Condition(nil), t.list...)
+}
+
+// Conditions returns every condition for an asset class and data type, ordered by ID.
+func (t *Table) Conditions(assetClass AssetClass, dataType DataType) []Condition {
+	var out []Condition
+	for k, c := range t.conditions {
+		if k.assetClass == assetClass && k.dataType == dataType {
+			out = append(out, c)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Names returns the names of a list of condition IDs. Unknown IDs are skipped; Unknown
+// returns them.
+func (t *Table) Names(assetClass AssetClass, dataType DataType, ids []int32) []string {
+	var out []string
+	for _, id := range ids {
+		if c, ok := t.Lookup(assetClass, dataType, id); ok {
+			out = append(out, c.Name)
+		}
+	}
+	return out
+}
+
+// UpdatesHighLow reports whether a trade with these conditions updates the
+// consolidated high and low. Every condition has to be in the table and allow it, so
+// it's false for a trade with an unknown ID; Unknown tells those trades apart.
+func (t *Table) UpdatesHighLow(assetClass AssetClass, ids []int32) bool {
+	return t.all(assetClass, ids, Condition.UpdatesHighLow)
+}
+
+// UpdatesOpenClose reports whether a trade with these conditions updates the
+// consolidated open and close.
+func (t *Table) UpdatesOpenClose(assetClass AssetClass, ids []int32) bool {
+	return t.all(assetClass, ids, Condition.UpdatesOpenClose)
+}
+
+// UpdatesVolume reports whether a trade with these conditions updates the
+// consolidated volume.
+func (t *Table) UpdatesVolume(assetClass AssetClass, ids []int32) bool {
+	return t.all(assetClass, ids, Condition.UpdatesVolume)
+}
+
+// EligibleForLast reports whether a trade with these conditions can set the last
+// sale price.
+func (t *Table) EligibleForLast(assetClass AssetClass, ids []int32) bool {
+	return t.all(assetClass, ids, Condition.EligibleForLast)
+}
+
+// Unknown returns the IDs in a list that aren't in the table, e.g. conditions newer
+// than the table or every condition if the table hasn't been loaded.
+func (t *Table) Unknown(assetClass AssetClass, dataType DataType, ids []int32) []int32 {
+	var out []int32
+	for _, id := range ids {
+		if _, ok := t.Lookup(assetClass, dataType, id); !ok {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// Excluded returns the IDs of the trade conditions for an asset class that don't
+// update the high and low, e.g. for bars.Config.ExcludeConditions.
+func (t *Table) Excluded(assetClass AssetClass) []int32 {
+	var out []int32
+	for _, c := range t.Conditions(assetClass, Trade) {
+		if !c.UpdatesHighLow() {
+			out = append(out, c.ID)
+		}
+	}
+	return out
+}
+
+func dataType(c Condition) DataType {
+	if len(c.DataTypes) == 0 {
+		return ""
+	}
+	return c.DataTypes[0]
+}
+
+func (t *Table) all(assetClass AssetClass, ids []int32, rule func(Condition) bool) bool {
+	for _, id := range ids {
+		if c, ok := t.Lookup(assetClass, Trade, id); !ok || !rule(c) {
+			return false
+		}
+	}
+	return true
+}
+
+var (
+	defaultMtx   sync.RWMutex
+	defaultTable = NewTable(nil)
+)
+
+// Default returns the table used by the condition helpers on the WebSocket models.
+// It's empty until SetDefault or restload.Refresh replaces it.
+func Default() *Table {
+	defaultMtx.RLock()
+	defer defaultMtx.RUnlock()
+	return defaultTable
+}
+
+// SetDefault replaces the default table.
+func SetDefault(t *Table) {
+	defaultMtx.Lock()
+	defer defaultMtx.Unlock()
+	defaultTable = t
+}
@@ -0,0 +1,115 @@
+package conditions
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testTable loads a table from testdata/conditions.json, which has a subset of the
+// stock, options and crypto conditions returned by the REST API.
+func testTable(t *testing.T) *Table {
+	data, err := os.ReadFile("testdata/conditions.json")
+	assert.Nil(t, err)
+	var conditions []Condition
+	assert.Nil(t, json.Unmarshal(data, &conditions))
+	return NewTable(conditions)
+}
+
+func TestTable(t *testing.T) {
+	table := testTable(t)
+
+	c, ok := table.Lookup(Stocks, Trade, 2)
+	assert.True(t, ok)
+	assert.Equal(t, "Average Price Trade", c.Name)
+	assert.False(t, c.UpdatesHighLow())
+	assert.False(t, c.EligibleForLast())
+	assert.True(t, c.UpdatesVolume())
+
+	c, ok = table.Lookup(Crypto, Trade, 2)
+	assert.True(t, ok)
+	assert.Equal(t, "Buy Side", c.Name)
+
+	_, ok = table.Lookup(Stocks, NBBO, 999)
+	assert.False(t, ok)
+
+	all := table.All()
+	assert.Equal(t, Crypto, all[0].AssetClass)
+	for i := 1; i < len(all); i++ {
+		if all[i].AssetClass == all[i-1].AssetClass && all[i].DataTypes[0] == all[i-1].DataTypes[0] {
+			assert.Less(t, all[i-1].ID, all[i].ID)
+		}
+	}
+	assert.Len(t, table.Conditions(Crypto, Trade), 3)
+
+	// quote conditions apply to both BBO and NBBO quotes
+	c, ok = table.Lookup(Stocks, BBO, 43)
+	assert.True(t, ok)
+	assert.Equal(t, "LULD Trading Pause", c.Name)
+	c, ok = table.Lookup(Stocks, NBBO, 43)
+	assert.True(t, ok)
+	assert.Equal(t, "LULD Trading Pause", c.Name)
+
+	c, ok = table.Lookup(Options, Trade, 201)
+	assert.True(t, ok)
+	assert.Equal(t, "Canceled", c.Name)
+	assert.False(t, c.UpdatesVolume())
+}
+
+func TestTableRules(t *testing.T) {
+	table := testTable(t)
+
+	// regular, intermarket sweep
+	assert.True(t, table.UpdatesHighLow(Stocks, []int32{0, 14}))
+	assert.True(t, table.EligibleForLast(Stocks, []int32{0, 14}))
+
+	// any condition can exclude a trade
+	assert.False(t, table.UpdatesHighLow(Stocks, []int32{14, 37}))
+	assert.True(t, table.UpdatesVolume(Stocks, []int32{14, 37}))
+
+	// sold out of sequence updates the high and low but not the last price
+	assert.True(t, table.UpdatesHighLow(Stocks, []int32{32}))
+	assert.False(t, table.UpdatesOpenClose(Stocks, []int32{32}))
+
+	// official open and close prints don't update consolidated volume
+	assert.False(t, table.UpdatesVolume(Stocks, []int32{15}))
+
+	// no conditions update everything, unknown conditions nothing
+	assert.True(t, table.UpdatesHighLow(Stocks, nil))
+	assert.False(t, table.UpdatesHighLow(Stocks, []int32{9999}))
+	assert.False(t, table.UpdatesVolume(Stocks, []int32{14, 9999}))
+	assert.Equal(t, []int32{9999}, table.Unknown(Stocks, Trade, []int32{14, 9999, 37}))
+	assert.Empty(t, table.Unknown(Stocks, Trade, []int32{14, 37}))
+
+	assert.Equal(t, []string{"Intermarket Sweep", "Odd Lot Trade"}, table.Names(Stocks, Trade, []int32{14, 9999, 37}))
+	assert.Contains(t, table.Excluded(Stocks), int32(37))
+	assert.NotContains(t, table.Excluded(Stocks), int32(32))
+	assert.Empty(t, table.Excluded(Crypto))
+
+	// multi leg options trades only count towards volume
+	assert.False(t, table.UpdatesHighLow(Options, []int32{232}))
+	assert.True(t, table.UpdatesVolume(Options, []int32{232}))
+	assert.Contains(t, table.Excluded(Options), int32(232))
+	assert.NotContains(t, table.Excluded(Options), int32(219))
+}
+
+func TestSetDefault(t *testing.T) {
+	// nothing is known until the table is loaded
+	assert.Empty(t, Default().All())
+	assert.Equal(t, []int32{14}, Default().Unknown(Stocks, Trade, []int32{14}))
+	assert.False(t, Default().UpdatesHighLow(Stocks, []int32{14}))
+	assert.True(t, Default().UpdatesHighLow(Stocks, nil))
+
+	defer SetDefault(Default())
+
+	table := NewTable([]Condition{{ID: 7, Name: "Custom", AssetClass: Stocks, DataTypes: []DataType{Trade, NBBO}}})
+	SetDefault(table)
+	assert.Same(t, table, Default())
+
+	c, ok := Default().Lookup(Stocks, NBBO, 7)
+	assert.True(t, ok)
+	assert.Equal(t, "Custom", c.Name)
+	assert.Len(t, Default().All(), 1)
+}
@@ -0,0 +1,55 @@
+package conditions_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/massive-com/client-go/v3/conditions"
+	"github.com/massive-com/client-go/v3/websocket/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// The model helpers use the default table.
+func TestModelHelpers(t *testing.T) {
+	data, err := os.ReadFile("testdata/conditions.json")
+	assert.Nil(t, err)
+	var list []conditions.Condition
+	assert.Nil(t, json.Unmarshal(data, &list))
+	defer conditions.SetDefault(conditions.Default())
+	conditions.SetDefault(conditions.NewTable(list))
+
+	trade := models.EquityTrade{Symbol: "AAPL", Conditions: []int32{12, 37}}
+	assert.Equal(t, []string{"Form T/Extended Hours", "Odd Lot Trade"}, trade.ConditionNames())
+	assert.False(t, trade.UpdatesHighLow())
+	assert.False(t, trade.EligibleForLast())
+	assert.True(t, trade.UpdatesVolume())
+
+	trade = models.EquityTrade{Symbol: "AAPL", Conditions: []int32{14}}
+	assert.True(t, trade.UpdatesHighLow())
+	assert.True(t, trade.UpdatesOpenClose())
+	assert.Empty(t, trade.UnknownConditions())
+
+	// conditions missing from the table are listed and don't update anything
+	trade = models.EquityTrade{Symbol: "AAPL", Conditions: []int32{14, 9999}}
+	assert.Equal(t, []int32{9999}, trade.UnknownConditions())
+	assert.False(t, trade.UpdatesHighLow())
+	assert.False(t, trade.UpdatesVolume())
+
+	crypto := models.CryptoTrade{Pair: "BTC-USD", Conditions: []int32{1}}
+	assert.Equal(t, []string{"Sell Side"}, crypto.ConditionNames())
+	assert.True(t, crypto.UpdatesHighLow())
+	assert.True(t, crypto.UpdatesOpenClose())
+	assert.True(t, crypto.UpdatesVolume())
+	assert.True(t, crypto.EligibleForLast())
+
+	assert.Equal(t, "Regular, Two-Sided Open", models.EquityQuote{Condition: 1}.ConditionName())
+	assert.Equal(t, []string{"Sub-Penny Trading"}, models.EquityQuote{Indicators: []int32{33}}.IndicatorNames())
+	assert.Empty(t, models.EquityQuote{Condition: 999}.ConditionName())
+
+	// options trades share the stock trade model, so their conditions are looked up
+	// directly
+	c, ok := conditions.Default().Lookup(conditions.Options, conditions.Trade, 219)
+	assert.True(t, ok)
+	assert.Equal(t, "Intermarket Sweep Order", c.Name)
+}
@@ -0,0 +1,87 @@
+// Package restload loads conditions from the REST API. It's separate from the
+// conditions package so that decoding conditions, e.g. with the helpers on the
+// WebSocket models, doesn't depend on the REST client.
+package restload
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/massive-com/client-go/v3/conditions"
+	"github.com/massive-com/client-go/v3/internal/restiter"
+	"github.com/massive-com/client-go/v3/rest"
+	"github.com/massive-com/client-go/v3/rest/gen"
+)
+
+// maxLimit is the largest page size accepted by the conditions endpoint.
+const maxLimit = 1000
+
+// result is a condition as returned by the REST conditions endpoint.
+type result struct {
+	ID           int32                 `json:"id"`
+	Type         string                `json:"type"`
+	Name         string                `json:"name"`
+	Abbreviation string                `json:"abbreviation"`
+	Description  string                `json:"description"`
+	AssetClass   conditions.AssetClass `json:"asset_class"`
+	DataTypes    []conditions.DataType `json:"data_types"`
+	Exchange     int32                 `json:"exchange"`
+	Legacy       bool                  `json:"legacy"`
+	UpdateRules  *struct {
+		Consolidated conditions.Rules `json:"consolidated"`
+		MarketCenter conditions.Rules `json:"market_center"`
+	} `json:"update_rules"`
+}
+
+// Load fetches every condition from the REST API. The client should have pagination
+// enabled.
+func Load(ctx context.Context, client *rest.Client) (*conditions.Table, error) {
+	resp, err := client.ListConditionsWithResponse(ctx, &gen.ListConditionsParams{
+		Limit: rest.Int(maxLimit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conditions: %w", err)
+	}
+
+	results, err := restiter.Results[result](client, resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list conditions: %w", err)
+	}
+	list := make([]conditions.Condition, len(results))
+	for i, r := range results {
+		list[i] = r.condition()
+	}
+	return conditions.NewTable(list), nil
+}
+
+// Refresh loads every condition from the REST API and makes them the default table.
+func Refresh(ctx context.Context, client *rest.Client) error {
+	t, err := Load(ctx, client)
+	if err != nil {
+		return err
+	}
+	conditions.SetDefault(t)
+	return nil
+}
+
+func (r result) condition() conditions.Condition {
+	all := conditions.Rules{UpdatesHighLow: true, UpdatesOpenClose: true, UpdatesVolume: true}
+	c := conditions.Condition{
+		ID:           r.ID,
+		Type:         r.Type,
+		Name:         r.Name,
+		Abbreviation: r.Abbreviation,
+		Description:  r.Description,
+		AssetClass:   r.AssetClass,
+		DataTypes:    r.DataTypes,
+		Exchange:     r.Exchange,
+		Legacy:       r.Legacy,
+		Consolidated: all,
+		MarketCenter: all,
+	}
+	if r.UpdateRules != nil {
+		c.Consolidated = r.UpdateRules.Consolidated
+		c.MarketCenter = r.UpdateRules.MarketCenter
+	}
+	return c
+}
@@ -0,0 +1,30 @@
+package restload
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/massive-com/client-go/v3/conditions"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResult(t *testing.T) {
+	var r result
+	assert.Nil(t, json.Unmarshal([]byte(`{
+		"asset_class": "stocks", "data_types": ["trade"], "id": 2, "name": "Average Price Trade",
+		"sip_mapping": {"CTA": "B", "UTP": "W"}, "type": "condition",
+		"update_rules": {
+			"consolidated": {"updates_high_low": false, "updates_open_close": false, "updates_volume": true},
+			"market_center": {"updates_high_low": false, "updates_open_close": false, "updates_volume": true}
+		}
+	}`), &r))
+	assert.Equal(t, conditions.Condition{
+		ID: 2, Type: "condition", Name: "Average Price Trade", AssetClass: conditions.Stocks, DataTypes: []conditions.DataType{conditions.Trade},
+		Consolidated: conditions.Rules{UpdatesVolume: true}, MarketCenter: conditions.Rules{UpdatesVolume: true},
+	}, r.condition())
+
+	// conditions without update rules update everything
+	r = result{ID: 1, AssetClass: conditions.Stocks, DataTypes: []conditions.DataType{conditions.NBBO}}
+	assert.True(t, r.condition().UpdatesHighLow())
+	assert.True(t, r.condition().MarketCenter.UpdatesVolume)
+}
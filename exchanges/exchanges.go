@@ -0,0 +1,156 @@
+// Package exchanges resolves the numeric exchange IDs attached to market data.
+//
+// Exchanges are looked up in a Directory, which maps the IDs used by the REST and
+// WebSocket APIs to names, MICs and types. restload.Refresh loads the exchanges of
+// every asset class from the REST API into the default directory used by Resolve and
+// the helpers on the WebSocket models. No directory is embedded in the package, since
+// a partial one would miss valid IDs; until it's loaded, no exchange is found.
+package exchanges
+
+import (
+	"sort"
+	"sync"
+)
+
+// AssetClass is the asset class an exchange trades. IDs are unique per asset class.
+type AssetClass string
+
+const (
+	Stocks  AssetClass = "stocks"
+	Options AssetClass = "options"
+	Crypto  AssetClass = "crypto"
+	Forex   AssetClass = "fx"
+	Futures AssetClass = "futures"
+)
+
+// Exchange is an exchange, trading venue or reporting facility.
+type Exchange struct {
+	ID         int32      `json:"id"`
+	AssetClass AssetClass `json:"asset_class"`
+	Name       string     `json:"name"`
+	Acronym    string     `json:"acronym,omitempty"`
+
+	// MIC is the ISO 10383 Market Identifier Code, and OperatingMIC the MIC of the
+	// entity that operates the exchange. Some venues don't have one.
+	MIC          string `json:"mic,omitempty"`
+	OperatingMIC string `json:"operating_mic,omitempty"`
+
+	// ParticipantID is the ID used by the SIPs for the exchange.
+	ParticipantID string `json:"participant_id,omitempty"`
+
+	// Type is the type of venue, e.g. exchange, TRF, SIP or ORF.
+	Type   string `json:"type"`
+	Locale string `json:"locale,omitempty"`
+	URL    string `json:"url,omitempty"`
+}
+
+// Directory is a set of exchanges. It's safe for concurrent use.
+type Directory struct {
+	list      []Exchange
+	exchanges map[key]Exchange
+	mics      map[string]Exchange
+}
+
+type key struct {
+	assetClass AssetClass
+	id         int32
+}
+
+// NewDirectory creates a directory from a list of exchanges. If an exchange is listed
+// more than once, the last entry wins.
+func NewDirectory(exchanges []Exchange) *Directory {
+	d := &Directory{
+		exchanges: make(map[key]Exchange, len(exchanges)),
+		mics:      make(map[string]Exchange, len(exchanges)),
+	}
+	for _, e := range exchanges {
+		d.exchanges[key{e.AssetClass, e.ID}] = e
+	}
+	for _, e := range d.exchanges {
+		d.list = append(d.list, e)
+	}
+	sort.Slice(d.list, func(i, j int) bool {
+		if d.list[i].AssetClass != d.list[j].AssetClass {
+			return d.list[i].AssetClass < d.list[j].AssetClass
+		}
+		return d.list[i].ID < d.list[j].ID
+	})
+	for _, e := range d.list {
+		if _, ok := d.mics[e.MIC]; e.MIC != "" && !ok {
+			d.mics[e.MIC] = e
+		}
+	}
+	return d
+}
+
+// Lookup returns an exchange by asset class and ID.
+func (d *Directory) Lookup(assetClass AssetClass, id int32) (Exchange, bool) {
+	e, ok := d.exchanges[key{assetClass, id}]
+	return e, ok
+}
+
+// ByMIC returns an exchange by its MIC. If the MIC is used in more than one asset
+// class, the first by asset class name is returned.
+func (d *Directory) ByMIC(mic string) (Exchange, bool) {
+	e, ok := d.mics[mic]
+	return e, ok
+}
+
+// Name returns the name of an exchange, or an empty string if it's unknown.
+func (d *Directory) Name(assetClass AssetClass, id int32) string {
+	e, _ := d.Lookup(assetClass, id)
+	return e.Name
+}
+
+// MIC returns the MIC of an exchange, or an empty string if it's unknown or doesn't
+// have one.
+func (d *Directory) MIC(assetClass AssetClass, id int32) string {
+	e, _ := d.Lookup(assetClass, id)
+	return e.MIC
+}
+
+// Resolve looks an exchange ID up in the default directory. It takes the ID types of
+// both the WebSocket models and the REST results, which use int or int32 depending
+// on the endpoint, e.g. Resolve(Stocks, result.Exchange) for a stock trade result.
+func Resolve[ID ~int | ~int32 | ~int64](assetClass AssetClass, id ID) (Exchange, bool) {
+	if int64(id) != int64(int32(id)) {
+		return Exchange{}, false
+	}
+	return Default().Lookup(assetClass, int32(id))
+}
+
+// All returns every exchange, ordered by asset class and ID.
+func (d *Directory) All() []Exchange {
+	return append([]Exchange(nil), d.list...)
+}
+
+// Exchanges returns every exchange for an asset class, ordered by ID.
+func (d *Directory) Exchanges(assetClass AssetClass) []Exchange {
+	var out []Exchange
+	for _, e := range d.list {
+		if e.AssetClass == assetClass {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+var (
+	defaultMtx       sync.RWMutex
+	defaultDirectory = NewDirectory(nil)
+)
+
+// Default returns the directory used by Resolve and the exchange helpers on the
+// WebSocket models. It's empty until SetDefault or restload.Refresh replaces it.
+func Default() *Directory {
+	defaultMtx.RLock()
+	defer defaultMtx.RUnlock()
+	return defaultDirectory
+}
+
+// SetDefault replaces the default directory.
+func SetDefault(d *Directory) {
+	defaultMtx.Lock()
+	defer defaultMtx.Unlock()
+	defaultDirectory = d
+}
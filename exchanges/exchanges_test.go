@@ -0,0 +1,69 @@
+package exchanges
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testDirectory loads a directory from testdata/exchanges.json, which has the stock,
+// options, crypto and forex exchanges returned by the REST API.
+func testDirectory(t *testing.T) *Directory {
+	data, err := os.ReadFile("testdata/exchanges.json")
+	assert.Nil(t, err)
+	var exchanges []Exchange
+	assert.Nil(t, json.Unmarshal(data, &exchanges))
+	return NewDirectory(exchanges)
+}
+
+func TestDirectory(t *testing.T) {
+	d := testDirectory(t)
+
+	e, ok := d.Lookup(Stocks, 10)
+	assert.True(t, ok)
+	assert.Equal(t, "New York Stock Exchange", e.Name)
+	assert.Equal(t, "XNYS", e.MIC)
+	assert.Equal(t, "exchange", e.Type)
+	assert.Equal(t, "XNAS", d.MIC(Stocks, 12))
+	assert.Equal(t, "TRF", d.Exchanges(Stocks)[3].Type)
+
+	assert.Equal(t, "Coinbase", d.Name(Crypto, 1))
+	assert.Empty(t, d.MIC(Crypto, 1))
+
+	// IDs are per asset class
+	_, ok = d.Lookup(Options, 10)
+	assert.False(t, ok)
+	assert.Equal(t, "Cboe Options Exchange", d.Name(Options, 302))
+	assert.Equal(t, "SIP", d.Exchanges(Options)[10].Type)
+	assert.Equal(t, "Currency Banks 1", d.Name(Forex, 48))
+	assert.Empty(t, d.Name(Stocks, 9999))
+
+	e, ok = d.ByMIC("IEXG")
+	assert.True(t, ok)
+	assert.Equal(t, int32(15), e.ID)
+	_, ok = d.ByMIC("")
+	assert.False(t, ok)
+
+	all := d.All()
+	for i := 1; i < len(all); i++ {
+		if all[i].AssetClass == all[i-1].AssetClass {
+			assert.Less(t, all[i-1].ID, all[i].ID)
+		}
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	// nothing is found until the directory is loaded
+	assert.Empty(t, Default().All())
+	_, ok := Resolve(Stocks, 10)
+	assert.False(t, ok)
+
+	defer SetDefault(Default())
+
+	d := NewDirectory([]Exchange{{ID: 7, AssetClass: Futures, Name: "CME", MIC: "XCME"}})
+	SetDefault(d)
+	assert.Same(t, d, Default())
+	assert.Equal(t, "CME", Default().Name(Futures, 7))
+}
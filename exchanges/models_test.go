@@ -0,0 +1,82 @@
+package exchanges_test
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"testing"
+
+	"github.com/massive-com/client-go/v3/exchanges"
+	"github.com/massive-com/client-go/v3/rest/gen"
+	"github.com/massive-com/client-go/v3/websocket/models"
+	"github.com/stretchr/testify/assert"
+)
+
+// useTestDirectory makes the exchanges in testdata the default directory for the
+// duration of a test.
+func useTestDirectory(t *testing.T) {
+	data, err := os.ReadFile("testdata/exchanges.json")
+	assert.Nil(t, err)
+	var list []exchanges.Exchange
+	assert.Nil(t, json.Unmarshal(data, &list))
+	prev := exchanges.Default()
+	exchanges.SetDefault(exchanges.NewDirectory(list))
+	t.Cleanup(func() { exchanges.SetDefault(prev) })
+}
+
+// The model helpers use the default directory.
+func TestModelHelpers(t *testing.T) {
+	useTestDirectory(t)
+
+	e, ok := models.EquityTrade{Exchange: 4}.ExchangeInfo()
+	assert.True(t, ok)
+	assert.Equal(t, "XADF", e.MIC)
+
+	q := models.EquityQuote{BidExchangeID: 11, AskExchangeID: 19}
+	bid, _ := q.BidExchange()
+	ask, _ := q.AskExchange()
+	assert.Equal(t, "ARCX", bid.MIC)
+	assert.Equal(t, "BATS", ask.MIC)
+
+	e, ok = models.CryptoQuote{ExchangeID: 23}.ExchangeInfo()
+	assert.True(t, ok)
+	assert.Equal(t, "Kraken", e.Name)
+
+	_, ok = models.Level2Book{ExchangeID: 9999}.ExchangeInfo()
+	assert.False(t, ok)
+
+	e, ok = models.ForexQuote{ExchangeID: 48}.ExchangeInfo()
+	assert.True(t, ok)
+	assert.Equal(t, "Currency Banks 1", e.Name)
+
+	// options trades share the stock trade model, so their exchanges are looked up
+	// directly
+	trade := models.EquityTrade{Symbol: "O:SPY251219C00650000", Exchange: 302}
+	e, ok = exchanges.Default().Lookup(exchanges.Options, trade.Exchange)
+	assert.True(t, ok)
+	assert.Equal(t, "XCBO", e.MIC)
+	_, ok = trade.ExchangeInfo()
+	assert.False(t, ok)
+}
+
+func TestResolve(t *testing.T) {
+	useTestDirectory(t)
+
+	// REST results use int IDs
+	var resp gen.GetStocksTradesResponse
+	err := json.Unmarshal([]byte(`{"results":[{"exchange":11,"price":194.12,"size":100}]}`), &resp.JSON200)
+	assert.NoError(t, err)
+	e, ok := exchanges.Resolve(exchanges.Stocks, (*resp.JSON200.Results)[0].Exchange)
+	assert.True(t, ok)
+	assert.Equal(t, "ARCX", e.MIC)
+	assert.Equal(t, "exchange", e.Type)
+
+	e, ok = exchanges.Resolve(exchanges.Crypto, models.CryptoTrade{Exchange: 1}.Exchange)
+	assert.True(t, ok)
+	assert.Equal(t, "Coinbase", e.Name)
+
+	_, ok = exchanges.Resolve(exchanges.Stocks, 9999)
+	assert.False(t, ok)
+	_, ok = exchanges.Resolve(exchanges.Stocks, int64(math.MaxInt32)+11)
+	assert.False(t, ok)
+}
@@ -0,0 +1,148 @@
+// Package restload loads exchanges from the REST API. It's separate from the exchanges
+// package so that resolving exchange IDs, e.g. with the helpers on the WebSocket
+// models, doesn't depend on the REST client.
+package restload
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/massive-com/client-go/v3/exchanges"
+	"github.com/massive-com/client-go/v3/internal/restiter"
+	"github.com/massive-com/client-go/v3/rest"
+	"github.com/massive-com/client-go/v3/rest/gen"
+)
+
+// maxLimit is the largest page size accepted by the per asset class exchanges endpoints.
+const maxLimit = 999
+
+// result is an exchange as returned by any of the REST exchanges endpoints. The
+// reference endpoint returns numeric IDs and the per asset class endpoints return
+// them as strings.
+type result struct {
+	ID            json.RawMessage      `json:"id"`
+	AssetClass    exchanges.AssetClass `json:"asset_class"`
+	Name          string               `json:"name"`
+	Acronym       string               `json:"acronym"`
+	MIC           string               `json:"mic"`
+	OperatingMIC  string               `json:"operating_mic"`
+	ParticipantID string               `json:"participant_id"`
+	Type          string               `json:"type"`
+	Locale        string               `json:"locale"`
+	URL           string               `json:"url"`
+}
+
+// Load fetches every exchange from the REST API. The reference exchanges are loaded
+// first and then completed by the per asset class endpoints. The client should have
+// pagination enabled.
+func Load(ctx context.Context, client *rest.Client) (*exchanges.Directory, error) {
+	var list []exchanges.Exchange
+	add := func(name string, assetClass exchanges.AssetClass, resp any, err error) error {
+		if err != nil {
+			return fmt.Errorf("failed to list %v exchanges: %w", name, err)
+		}
+		results, err := restiter.Results[result](client, resp)
+		if err != nil {
+			return fmt.Errorf("failed to list %v exchanges: %w", name, err)
+		}
+		for _, r := range results {
+			e, ok := r.exchange(assetClass)
+			if ok {
+				list = append(list, e)
+			}
+		}
+		return nil
+	}
+
+	resp, err := client.ListExchangesWithResponse(ctx, &gen.ListExchangesParams{})
+	if err := add("reference", "", resp, err); err != nil {
+		return nil, err
+	}
+	stocks, err := client.GetStocksV1ExchangesWithResponse(ctx, &gen.GetStocksV1ExchangesParams{Limit: rest.Int(maxLimit)})
+	if err := add("stocks", exchanges.Stocks, stocks, err); err != nil {
+		return nil, err
+	}
+	options, err := client.GetOptionsV1ExchangesWithResponse(ctx, &gen.GetOptionsV1ExchangesParams{Limit: rest.Int(maxLimit)})
+	if err := add("options", exchanges.Options, options, err); err != nil {
+		return nil, err
+	}
+	crypto, err := client.GetCryptoV1ExchangesWithResponse(ctx, &gen.GetCryptoV1ExchangesParams{Limit: rest.Int(maxLimit)})
+	if err := add("crypto", exchanges.Crypto, crypto, err); err != nil {
+		return nil, err
+	}
+	futures, err := client.GetFuturesV1ExchangesWithResponse(ctx, &gen.GetFuturesV1ExchangesParams{Limit: rest.Int(maxLimit)})
+	if err := add("futures", exchanges.Futures, futures, err); err != nil {
+		return nil, err
+	}
+
+	return exchanges.NewDirectory(merge(list)), nil
+}
+
+// Refresh loads every exchange from the REST API and makes them the default directory.
+func Refresh(ctx context.Context, client *rest.Client) error {
+	d, err := Load(ctx, client)
+	if err != nil {
+		return err
+	}
+	exchanges.SetDefault(d)
+	return nil
+}
+
+// exchange converts a result, using the asset class of the endpoint if the result
+// doesn't have one. Results without a numeric ID are skipped.
+func (r result) exchange(assetClass exchanges.AssetClass) (exchanges.Exchange, bool) {
+	id, err := strconv.ParseInt(strings.Trim(string(r.ID), `"`), 10, 32)
+	if err != nil {
+		return exchanges.Exchange{}, false
+	}
+	if r.AssetClass != "" {
+		assetClass = r.AssetClass
+	}
+	return exchanges.Exchange{
+		ID:            int32(id),
+		AssetClass:    assetClass,
+		Name:          r.Name,
+		Acronym:       r.Acronym,
+		MIC:           r.MIC,
+		OperatingMIC:  r.OperatingMIC,
+		ParticipantID: r.ParticipantID,
+		Type:          r.Type,
+		Locale:        r.Locale,
+		URL:           r.URL,
+	}, true
+}
+
+// merge combines exchanges listed by more than one endpoint. Later entries win, but
+// fields they leave empty are kept from earlier ones.
+func merge(list []exchanges.Exchange) []exchanges.Exchange {
+	type key struct {
+		assetClass exchanges.AssetClass
+		id         int32
+	}
+
+	index := make(map[key]int, len(list))
+	var out []exchanges.Exchange
+	for _, e := range list {
+		k := key{e.AssetClass, e.ID}
+		i, ok := index[k]
+		if !ok {
+			index[k] = len(out)
+			out = append(out, e)
+			continue
+		}
+		prev := &out[i]
+		for _, f := range []struct{ dst, src *string }{
+			{&prev.Name, &e.Name}, {&prev.Acronym, &e.Acronym}, {&prev.MIC, &e.MIC},
+			{&prev.OperatingMIC, &e.OperatingMIC}, {&prev.ParticipantID, &e.ParticipantID},
+			{&prev.Type, &e.Type}, {&prev.Locale, &e.Locale}, {&prev.URL, &e.URL},
+		} {
+			if *f.src != "" {
+				*f.dst = *f.src
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,44 @@
+package restload
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/massive-com/client-go/v3/exchanges"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResult(t *testing.T) {
+	decode := func(s string) result {
+		var r result
+		assert.Nil(t, json.Unmarshal([]byte(s), &r))
+		return r
+	}
+
+	// the reference endpoint has numeric IDs and an asset class
+	e, ok := decode(`{"id": 10, "asset_class": "stocks", "name": "New York Stock Exchange", "mic": "XNYS", "type": "exchange", "locale": "us"}`).exchange("")
+	assert.True(t, ok)
+	assert.Equal(t, exchanges.Exchange{ID: 10, AssetClass: exchanges.Stocks, Name: "New York Stock Exchange", MIC: "XNYS", Type: "exchange", Locale: "us"}, e)
+
+	// the per asset class endpoints have string IDs
+	e, ok = decode(`{"id": "4", "name": "CME Globex", "acronym": "CME", "mic": "XCME", "type": "exchange"}`).exchange(exchanges.Futures)
+	assert.True(t, ok)
+	assert.Equal(t, exchanges.Exchange{ID: 4, AssetClass: exchanges.Futures, Name: "CME Globex", Acronym: "CME", MIC: "XCME", Type: "exchange"}, e)
+
+	_, ok = decode(`{"id": "n/a", "name": "Unknown"}`).exchange(exchanges.Stocks)
+	assert.False(t, ok)
+}
+
+func TestMerge(t *testing.T) {
+	merged := merge([]exchanges.Exchange{
+		{ID: 1, AssetClass: exchanges.Stocks, Name: "NYSE American", MIC: "XASE", URL: "https://www.nyse.com"},
+		{ID: 2, AssetClass: exchanges.Stocks, Name: "Nasdaq BX"},
+		{ID: 1, AssetClass: exchanges.Stocks, Name: "NYSE American, LLC", ParticipantID: "A"},
+		{ID: 1, AssetClass: exchanges.Options, Name: "NYSE American Options"},
+	})
+	assert.Equal(t, []exchanges.Exchange{
+		{ID: 1, AssetClass: exchanges.Stocks, Name: "NYSE American, LLC", MIC: "XASE", ParticipantID: "A", URL: "https://www.nyse.com"},
+		{ID: 2, AssetClass: exchanges.Stocks, Name: "Nasdaq BX"},
+		{ID: 1, AssetClass: exchanges.Options, Name: "NYSE American Options"},
+	}, merged)
+}
@@ -0,0 +1,142 @@
+package futures
+
+import (
+	"sort"
+	"time"
+)
+
+// Adjustment is how prices before a roll are adjusted in a continuous series to remove
+// the gap between the old and the new contract.
+type Adjustment int
+
+const (
+	// NoAdjustment leaves prices as traded, so the series jumps at every roll.
+	NoAdjustment Adjustment = iota
+
+	// Difference adds the price difference between the new and old contract at each
+	// roll to every earlier bar. Price changes are preserved.
+	Difference
+
+	// Ratio multiplies every earlier bar by the price ratio between the new and old
+	// contract at each roll. Percentage returns are preserved.
+	Ratio
+)
+
+// Bar is an aggregate of a contract.
+type Bar struct {
+	Ticker string
+
+	// Start is the start of the aggregate window and Session the trading date of the
+	// session it belongs to, at midnight UTC.
+	Start   time.Time
+	Session time.Time
+
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume int64
+
+	Transactions int64
+}
+
+func (b Bar) adjust(adjustment Adjustment, offset, factor float64) Bar {
+	switch adjustment {
+	case Difference:
+		b.Open, b.High, b.Low, b.Close = b.Open+offset, b.High+offset, b.Low+offset, b.Close+offset
+	case Ratio:
+		b.Open, b.High, b.Low, b.Close = b.Open*factor, b.High*factor, b.Low*factor, b.Close*factor
+	}
+	return b
+}
+
+// Continuous stitches the bars of consecutive contracts into one series, ordered by
+// start. bars holds the bars of each contract by ticker. The bars of a contract are
+// used from the session of the roll to it up to, but excluding, the session of the roll
+// away from it.
+//
+// Prices are back-adjusted, so the latest contract is left as traded. The gap at a roll
+// is measured between the closes of both contracts in the last session before the roll
+// that they both have bars for; if there's none, that roll isn't adjusted.
+func Continuous(rolls []Roll, bars map[string][]Bar, adjustment Adjustment) []Bar {
+	if len(rolls) == 0 {
+		return nil
+	}
+
+	// offsets and factors apply to the segment before each roll and accumulate
+	// backwards from the latest contract
+	offsets := make([]float64, len(rolls)+1)
+	factors := make([]float64, len(rolls)+1)
+	factors[len(rolls)] = 1
+	for i := len(rolls) - 1; i >= 0; i-- {
+		offsets[i], factors[i] = offsets[i+1], factors[i+1]
+		from, to, ok := rollCloses(rolls[i], bars)
+		if !ok {
+			continue
+		}
+		offsets[i] += to - from
+		if from != 0 {
+			factors[i] *= to / from
+		}
+	}
+
+	var out []Bar
+	for i := 0; i <= len(rolls); i++ {
+		var ticker string
+		var start, end time.Time
+		if i == 0 {
+			ticker = rolls[0].From.Ticker
+		} else {
+			ticker = rolls[i-1].To.Ticker
+			start = rolls[i-1].Date
+		}
+		if i < len(rolls) {
+			end = rolls[i].Date
+		}
+
+		for _, b := range bars[ticker] {
+			if b.Session.Before(start) || (!end.IsZero() && !b.Session.Before(end)) {
+				continue
+			}
+			out = append(out, b.adjust(adjustment, offsets[i], factors[i]))
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Start.Before(out[j].Start) })
+	return out
+}
+
+// rollCloses returns the closes of the old and new contract of a roll in the last
+// session before the roll that both have bars for.
+func rollCloses(r Roll, bars map[string][]Bar) (float64, float64, bool) {
+	from := sessionCloses(bars[r.From.Ticker], r.Date)
+	to := sessionCloses(bars[r.To.Ticker], r.Date)
+
+	var last time.Time
+	for s := range from {
+		if _, ok := to[s]; ok && s.After(last) {
+			last = s
+		}
+	}
+	if last.IsZero() {
+		return 0, 0, false
+	}
+	return from[last], to[last], true
+}
+
+// sessionCloses returns the close of each session before a date, i.e. the close of the
+// latest bar of the session.
+func sessionCloses(bars []Bar, before time.Time) map[time.Time]float64 {
+	closes := make(map[time.Time]float64)
+	starts := make(map[time.Time]time.Time)
+	for _, b := range bars {
+		if !b.Session.Before(before) {
+			continue
+		}
+		if s, ok := starts[b.Session]; !ok || !b.Start.Before(s) {
+			starts[b.Session] = b.Start
+			closes[b.Session] = b.Close
+		}
+	}
+	return closes
+}
@@ -0,0 +1,153 @@
+// Package futures parses futures contract codes and builds continuous contracts.
+//
+// Contracts are identified by codes made of a product root, a month code and the
+// year, e.g. ESZ5 for the December 2025 E-mini S&P 500 contract. ParseAt and
+// Contract.Code convert between codes and contracts. The roll calendar helpers find
+// the front month and the roll dates of a product from its listed contracts and
+// trading sessions, and Continuous stitches the bars of consecutive contracts into a
+// single back-adjusted series.
+package futures
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// monthCodes are the month codes in month order.
+const monthCodes = "FGHJKMNQUVXZ"
+
+// MonthCode returns the code of a month, e.g. 'Z' for December, or 0 if the month is
+// invalid.
+func MonthCode(m time.Month) byte {
+	if m < time.January || m > time.December {
+		return 0
+	}
+	return monthCodes[m-1]
+}
+
+// ParseMonthCode returns the month of a month code.
+func ParseMonthCode(c byte) (time.Month, error) {
+	i := strings.IndexByte(monthCodes, c)
+	if i < 0 {
+		return 0, fmt.Errorf("invalid month code %q", c)
+	}
+	return time.Month(i + 1), nil
+}
+
+// Contract is a futures contract of a product.
+type Contract struct {
+	// Root is the product code, e.g. ES or 6E.
+	Root  string
+	Month time.Month
+	Year  int
+}
+
+// ParseAt parses a contract code like ESZ5, ESZ25 or ESZ2025. Codes only have the last
+// digit or two of the year, so they're resolved relative to ref, e.g. the date the
+// contract was listed or the data was recorded: one digit years are the year in the
+// decade starting the year before ref, and two digit years are in the 2000s. For
+// example, with a ref in 2025, ESZ4 is December 2024 and ESZ3 is December 2033.
+func ParseAt(code string, ref time.Time) (Contract, error) {
+	digits := len(code)
+	for digits > 0 && code[digits-1] >= '0' && code[digits-1] <= '9' {
+		digits--
+	}
+	n := len(code) - digits
+	if n != 1 && n != 2 && n != 4 {
+		return Contract{}, fmt.Errorf("invalid year in futures contract %q", code)
+	}
+	if digits < 2 {
+		return Contract{}, fmt.Errorf("invalid futures contract %q", code)
+	}
+
+	year, _ := strconv.Atoi(code[digits:])
+	switch n {
+	case 1:
+		first := ref.Year() - 1
+		year = first - first%10 + year
+		if year < first {
+			year += 10
+		}
+	case 2:
+		year += 2000
+	}
+
+	month, err := ParseMonthCode(code[digits-1])
+	if err != nil {
+		return Contract{}, fmt.Errorf("invalid month in futures contract %q", code)
+	}
+
+	c := Contract{Root: code[:digits-1], Month: month, Year: year}
+	if err := c.Validate(); err != nil {
+		return Contract{}, fmt.Errorf("invalid futures contract %q: %w", code, err)
+	}
+	return c, nil
+}
+
+// Parse is ParseAt with the current time of the machine's clock, so a one digit year
+// can resolve to a different contract depending on when it runs. Use ParseAt with the
+// time of the data for codes that aren't current.
+func Parse(code string) (Contract, error) {
+	return ParseAt(code, time.Now())
+}
+
+// MustParse is like Parse but panics if the code is invalid.
+func MustParse(code string) Contract {
+	c, err := Parse(code)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Validate reports whether the contract can be represented as a code.
+func (c Contract) Validate() error {
+	if c.Root == "" {
+		return fmt.Errorf("root is required")
+	}
+	for _, r := range c.Root {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return fmt.Errorf("root %q must be uppercase letters and digits", c.Root)
+		}
+	}
+	if MonthCode(c.Month) == 0 {
+		return fmt.Errorf("invalid month %d", c.Month)
+	}
+	if c.Year < 2000 || c.Year > 2099 {
+		return fmt.Errorf("year %d is out of range", c.Year)
+	}
+	return nil
+}
+
+// Code returns the contract code with a one digit year, e.g. ESZ5, which is the form
+// used by the REST and WebSocket APIs. The contract should be valid.
+func (c Contract) Code() string {
+	return c.Root + string(MonthCode(c.Month)) + strconv.Itoa(c.Year%10)
+}
+
+func (c Contract) String() string {
+	return c.Code()
+}
+
+// MonthStart returns the first day of the contract month at midnight UTC. It isn't
+// the expiration of the contract, which depends on the product; see Listing for the
+// last trade and settlement dates.
+func (c Contract) MonthStart() time.Time {
+	return time.Date(c.Year, c.Month, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// Before reports whether the contract month is before the one of o.
+func (c Contract) Before(o Contract) bool {
+	return c.Year < o.Year || (c.Year == o.Year && c.Month < o.Month)
+}
+
+// Codes returns the codes of contracts, e.g. to subscribe to them.
+func Codes(contracts []Contract) []string {
+	out := make([]string, len(contracts))
+	for i, c := range contracts {
+		out[i] = c.Code()
+	}
+	return out
+}
@@ -0,0 +1,214 @@
+package futures
+
+import (
+	"testing"
+	"time"
+
+	massivews "github.com/massive-com/client-go/v3/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func TestParse(t *testing.T) {
+	ref := date(2025, 8, 1)
+	for code, want := range map[string]Contract{
+		"ESZ5":    {Root: "ES", Month: time.December, Year: 2025},
+		"ESH4":    {Root: "ES", Month: time.March, Year: 2024},
+		"ESH3":    {Root: "ES", Month: time.March, Year: 2033},
+		"6EM26":   {Root: "6E", Month: time.June, Year: 2026},
+		"ZNU2027": {Root: "ZN", Month: time.September, Year: 2027},
+		"MESF6":   {Root: "MES", Month: time.January, Year: 2026},
+	} {
+		c, err := ParseAt(code, ref)
+		assert.Nil(t, err, code)
+		assert.Equal(t, want, c, code)
+	}
+
+	for _, code := range []string{"", "ES", "ESZ", "Z5", "ESA5", "ESZ123", "esZ5", "ES-Z5"} {
+		_, err := ParseAt(code, ref)
+		assert.NotNil(t, err, code)
+	}
+
+	c := MustParse("ESZ25")
+	assert.Equal(t, "ESZ5", c.Code())
+	assert.Equal(t, "ESZ5", c.String())
+	assert.Equal(t, date(2025, 12, 1), c.MonthStart())
+	assert.True(t, MustParse("ESU25").Before(c))
+	assert.False(t, c.Before(c))
+	assert.Equal(t, []string{"ESZ5", "NQH6"}, Codes([]Contract{c, MustParse("NQH26")}))
+	assert.Panics(t, func() { MustParse("ES") })
+}
+
+func TestMonthCodes(t *testing.T) {
+	for m := time.January; m <= time.December; m++ {
+		got, err := ParseMonthCode(MonthCode(m))
+		assert.Nil(t, err)
+		assert.Equal(t, m, got)
+	}
+	assert.Equal(t, byte('Z'), MonthCode(time.December))
+	assert.Equal(t, byte(0), MonthCode(13))
+	_, err := ParseMonthCode('A')
+	assert.NotNil(t, err)
+}
+
+var (
+	esh5 = Listing{Contract: MustParse("ESH25"), Ticker: "ESH5", FirstTradeDate: date(2024, 3, 15), LastTradeDate: date(2025, 3, 21)}
+	esm5 = Listing{Contract: MustParse("ESM25"), Ticker: "ESM5", FirstTradeDate: date(2024, 6, 21), LastTradeDate: date(2025, 6, 20)}
+	esu5 = Listing{Contract: MustParse("ESU25"), Ticker: "ESU5", FirstTradeDate: date(2024, 9, 20), LastTradeDate: date(2025, 9, 19)}
+)
+
+// weekdays returns the weekdays between two dates, inclusive.
+func weekdays(from, to time.Time) []time.Time {
+	var out []time.Time
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		if d.Weekday() != time.Saturday && d.Weekday() != time.Sunday {
+			out = append(out, d)
+		}
+	}
+	return out
+}
+
+func TestCalendar(t *testing.T) {
+	sessions := weekdays(date(2025, 3, 10), date(2025, 3, 21))
+	// duplicates and times of day are ignored
+	cal := NewCalendar(append(sessions, date(2025, 3, 12).Add(17*time.Hour)))
+	assert.Equal(t, sessions, cal.Sessions())
+	assert.True(t, cal.Trading(date(2025, 3, 14)))
+	assert.False(t, cal.Trading(date(2025, 3, 15)))
+
+	d, ok := cal.SessionsBefore(date(2025, 3, 21), 0)
+	assert.True(t, ok)
+	assert.Equal(t, date(2025, 3, 21), d)
+	d, ok = cal.SessionsBefore(date(2025, 3, 21), 5)
+	assert.True(t, ok)
+	assert.Equal(t, date(2025, 3, 14), d)
+	d, ok = cal.SessionsBefore(date(2025, 3, 16), 1) // a Sunday
+	assert.True(t, ok)
+	assert.Equal(t, date(2025, 3, 13), d)
+
+	_, ok = cal.SessionsBefore(date(2025, 3, 11), 5)
+	assert.False(t, ok)
+	_, ok = cal.SessionsBefore(date(2025, 3, 24), 0)
+	assert.False(t, ok)
+}
+
+func TestFrontMonthAndRolls(t *testing.T) {
+	listings := []Listing{esu5, esh5, esm5}
+
+	front, ok := FrontMonth(listings, date(2025, 3, 21))
+	assert.True(t, ok)
+	assert.Equal(t, "ESH5", front.Ticker)
+	front, ok = FrontMonth(listings, date(2025, 3, 24))
+	assert.True(t, ok)
+	assert.Equal(t, "ESM5", front.Ticker)
+	_, ok = FrontMonth(listings, date(2026, 1, 1))
+	assert.False(t, ok)
+
+	// March is covered by the calendar, June falls back to weekdays
+	cal := NewCalendar(weekdays(date(2025, 3, 1), date(2025, 3, 31)))
+	rolls := Rolls(listings, cal, 2)
+	if assert.Len(t, rolls, 2) {
+		assert.Equal(t, "ESH5", rolls[0].From.Ticker)
+		assert.Equal(t, "ESM5", rolls[0].To.Ticker)
+		assert.Equal(t, date(2025, 3, 19), rolls[0].Date)
+		assert.Equal(t, "ESU5", rolls[1].To.Ticker)
+		assert.Equal(t, date(2025, 6, 18), rolls[1].Date)
+	}
+
+	// holidays in the calendar are skipped
+	cal = NewCalendar(append(weekdays(date(2025, 3, 1), date(2025, 3, 19)), date(2025, 3, 21)))
+	assert.Equal(t, date(2025, 3, 18), Rolls(listings, cal, 2)[0].Date)
+	assert.Equal(t, date(2025, 3, 21), Rolls(listings, nil, 0)[0].Date)
+}
+
+// daily returns a bar per weekday between two dates with closes increasing by 1 from
+// first.
+func daily(ticker string, from, to time.Time, first float64) []Bar {
+	var out []Bar
+	for i, d := range weekdays(from, to) {
+		c := first + float64(i)
+		out = append(out, Bar{Ticker: ticker, Start: d.Add(-7 * time.Hour), Session: d, Open: c, High: c + 1, Low: c - 1, Close: c, Volume: 10})
+	}
+	return out
+}
+
+func TestContinuous(t *testing.T) {
+	bars := map[string][]Bar{
+		"ESH5": daily("ESH5", date(2025, 3, 17), date(2025, 3, 21), 100),
+		"ESM5": daily("ESM5", date(2025, 3, 17), date(2025, 3, 21), 110),
+	}
+	rolls := Rolls([]Listing{esh5, esm5}, NewCalendar(weekdays(date(2025, 3, 1), date(2025, 3, 31))), 2)
+
+	closes := func(series []Bar) []float64 {
+		var out []float64
+		for _, b := range series {
+			out = append(out, b.Close)
+		}
+		return out
+	}
+	tickers := func(series []Bar) []string {
+		var out []string
+		for _, b := range series {
+			out = append(out, b.Ticker)
+		}
+		return out
+	}
+
+	series := Continuous(rolls, bars, NoAdjustment)
+	assert.Equal(t, []string{"ESH5", "ESH5", "ESM5", "ESM5", "ESM5"}, tickers(series))
+	assert.Equal(t, []float64{100, 101, 112, 113, 114}, closes(series))
+
+	// the gap is measured on March 18, the last session before the roll
+	series = Continuous(rolls, bars, Difference)
+	assert.Equal(t, []float64{110, 111, 112, 113, 114}, closes(series))
+	assert.Equal(t, float64(111), series[0].High)
+	assert.Equal(t, int64(10), series[0].Volume)
+
+	series = Continuous(rolls, bars, Ratio)
+	assert.InDelta(t, 100*111.0/101, series[0].Close, 1e-9)
+	assert.InDelta(t, 111, series[1].Close, 1e-9)
+	assert.Equal(t, float64(112), series[2].Close)
+
+	// without overlapping bars the roll isn't adjusted
+	bars["ESM5"] = daily("ESM5", date(2025, 3, 19), date(2025, 3, 21), 112)
+	assert.Equal(t, []float64{100, 101, 112, 113, 114}, closes(Continuous(rolls, bars, Difference)))
+
+	assert.Nil(t, Continuous(nil, bars, Difference))
+}
+
+func TestResults(t *testing.T) {
+	l, ok := listingResult{Ticker: "ESZ5", FirstTradeDate: "2024-12-20", LastTradeDate: "2025-12-19", SettlementDate: "2025-12-19"}.listing()
+	assert.True(t, ok)
+	assert.Equal(t, Listing{
+		Contract:       Contract{Root: "ES", Month: time.December, Year: 2025},
+		Ticker:         "ESZ5",
+		FirstTradeDate: date(2024, 12, 20),
+		LastTradeDate:  date(2025, 12, 19),
+		SettlementDate: date(2025, 12, 19),
+	}, l)
+
+	_, ok = listingResult{Ticker: "ESZ5-ESH6", Type: "combo", FirstTradeDate: "2024-12-20", LastTradeDate: "2025-12-19"}.listing()
+	assert.False(t, ok)
+	_, ok = listingResult{Ticker: "ESZ5"}.listing()
+	assert.False(t, ok)
+}
+
+func TestProductMarket(t *testing.T) {
+	for _, tc := range []struct {
+		venue string
+		want  massivews.Market
+	}{
+		{"XCME", massivews.FuturesCME},
+		{"XCBT", massivews.FuturesCBOT},
+		{"XNYM", massivews.FuturesNYMEX},
+		{"XCEC", massivews.FuturesCOMEX},
+		// other venues and products without one use the combined market
+		{"XEUR", massivews.Futures},
+		{"", massivews.Futures},
+	} {
+		assert.Equal(t, tc.want, massivews.Market(Product{TradingVenue: tc.venue}.Market()), tc.venue)
+	}
+}
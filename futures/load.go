@@ -0,0 +1,348 @@
+package futures
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/massive-com/client-go/v3/internal/restiter"
+	"github.com/massive-com/client-go/v3/rest"
+	"github.com/massive-com/client-go/v3/rest/gen"
+)
+
+const (
+	// dateLayout is the layout of dates in REST params and results.
+	dateLayout = "2006-01-02"
+
+	// maxContractsLimit and maxAggsLimit are the largest page sizes of the contracts
+	// and aggregates endpoints.
+	maxContractsLimit = 1000
+	maxAggsLimit      = 50000
+)
+
+// Product is a futures product.
+type Product struct {
+	Code string `json:"product_code"`
+	Name string `json:"name"`
+
+	// TradingVenue is the MIC of the exchange the product trades on.
+	TradingVenue string `json:"trading_venue"`
+
+	AssetClass    string `json:"asset_class"`
+	AssetSubClass string `json:"asset_sub_class"`
+	Sector        string `json:"sector"`
+	SubSector     string `json:"sub_sector"`
+}
+
+// Market returns the name of the WebSocket market of the product's trading venue, or
+// of the all-venue futures market if the venue doesn't have its own. Convert it with
+// massivews.Market(p.Market()); it's a string so that this package doesn't depend on
+// the WebSocket client.
+func (p Product) Market() string {
+	switch p.TradingVenue {
+	case "XCME":
+		return "futures/cme"
+	case "XCBT":
+		return "futures/cbot"
+	case "XNYM":
+		return "futures/nymex"
+	case "XCEC":
+		return "futures/comex"
+	default:
+		return "futures"
+	}
+}
+
+// LoadProduct fetches a product by code.
+func LoadProduct(ctx context.Context, client *rest.Client, code string) (Product, error) {
+	resp, err := client.GetFuturesV1ProductsWithResponse(ctx, &gen.GetFuturesV1ProductsParams{ProductCode: rest.Ptr(code)})
+	if err != nil {
+		return Product{}, fmt.Errorf("failed to get futures product %v: %w", code, err)
+	}
+	list, err := restiter.Results[Product](client, resp)
+	if err != nil {
+		return Product{}, fmt.Errorf("failed to get futures product %v: %w", code, err)
+	}
+	if len(list) == 0 {
+		return Product{}, fmt.Errorf("futures product %v not found", code)
+	}
+	return list[0], nil
+}
+
+// listingResult is a contract as returned by the futures contracts endpoint.
+type listingResult struct {
+	Ticker         string `json:"ticker"`
+	Type           string `json:"type"`
+	FirstTradeDate string `json:"first_trade_date"`
+	LastTradeDate  string `json:"last_trade_date"`
+	SettlementDate string `json:"settlement_date"`
+}
+
+// ListContracts lists the single contracts of a product matching params, e.g. by
+// date or trade dates. Combos and contracts without trade dates are skipped. The
+// client should have pagination enabled to get every page.
+func ListContracts(ctx context.Context, client *rest.Client, product string, params *gen.GetFuturesV1ContractsParams) ([]Listing, error) {
+	p := gen.GetFuturesV1ContractsParams{}
+	if params != nil {
+		p = *params
+	}
+	p.ProductCode = rest.Ptr(product)
+	if p.Limit == nil {
+		p.Limit = rest.Ptr(maxContractsLimit)
+	}
+
+	resp, err := client.GetFuturesV1ContractsWithResponse(ctx, &p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %v futures contracts: %w", product, err)
+	}
+	list, err := restiter.Results[listingResult](client, resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %v futures contracts: %w", product, err)
+	}
+
+	var out []Listing
+	for _, r := range list {
+		if l, ok := r.listing(); ok {
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
+
+func (r listingResult) listing() (Listing, bool) {
+	if r.Type == "combo" {
+		return Listing{}, false
+	}
+	first, err := time.Parse(dateLayout, r.FirstTradeDate)
+	if err != nil {
+		return Listing{}, false
+	}
+	last, err := time.Parse(dateLayout, r.LastTradeDate)
+	if err != nil {
+		return Listing{}, false
+	}
+	// contracts are listed at most a few years ahead, so the one digit year is
+	// resolved relative to the first trade date
+	c, err := ParseAt(r.Ticker, first)
+	if err != nil {
+		return Listing{}, false
+	}
+	settlement, _ := time.Parse(dateLayout, r.SettlementDate)
+	return Listing{
+		Contract:       c,
+		Ticker:         r.Ticker,
+		FirstTradeDate: first,
+		LastTradeDate:  last,
+		SettlementDate: settlement,
+	}, true
+}
+
+// scheduleResult is an event as returned by the futures schedules endpoint.
+type scheduleResult struct {
+	SessionEndDate string `json:"session_end_date"`
+}
+
+// LoadCalendar fetches the trading sessions of a product between two dates,
+// inclusive. The client should have pagination enabled to get every page.
+func LoadCalendar(ctx context.Context, client *rest.Client, product string, from, to time.Time) (*Calendar, error) {
+	resp, err := client.GetFuturesV1SchedulesWithResponse(ctx, &gen.GetFuturesV1SchedulesParams{
+		ProductCode:       rest.Ptr(product),
+		SessionEndDateGte: rest.Ptr(from.Format(dateLayout)),
+		SessionEndDateLte: rest.Ptr(to.Format(dateLayout)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %v futures schedules: %w", product, err)
+	}
+	list, err := restiter.Results[scheduleResult](client, resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %v futures schedules: %w", product, err)
+	}
+
+	var sessions []time.Time
+	for _, r := range list {
+		if d, err := time.Parse(dateLayout, r.SessionEndDate); err == nil {
+			sessions = append(sessions, d)
+		}
+	}
+	return NewCalendar(sessions), nil
+}
+
+// aggResult is an aggregate as returned by the futures aggregates endpoint.
+type aggResult struct {
+	Ticker         string  `json:"ticker"`
+	SessionEndDate string  `json:"session_end_date"`
+	WindowStart    int64   `json:"window_start"`
+	Open           float64 `json:"open"`
+	High           float64 `json:"high"`
+	Low            float64 `json:"low"`
+	Close          float64 `json:"close"`
+	Volume         int64   `json:"volume"`
+	Transactions   int64   `json:"transactions"`
+}
+
+// ListBars lists the aggregates of a contract matching params, ordered by start. The
+// client should have pagination enabled to get every page.
+func ListBars(ctx context.Context, client *rest.Client, ticker string, params *gen.AggregatesV1Params) ([]Bar, error) {
+	p := gen.AggregatesV1Params{}
+	if params != nil {
+		p = *params
+	}
+	if p.Limit == nil {
+		p.Limit = rest.Ptr(maxAggsLimit)
+	}
+	p.Sort = rest.Ptr(gen.WindowStartAsc)
+
+	resp, err := client.AggregatesV1WithResponse(ctx, ticker, &p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %v futures aggregates: %w", ticker, err)
+	}
+	list, err := restiter.Results[aggResult](client, resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %v futures aggregates: %w", ticker, err)
+	}
+
+	out := make([]Bar, 0, len(list))
+	for _, r := range list {
+		session, _ := time.Parse(dateLayout, r.SessionEndDate)
+		out = append(out, Bar{
+			Ticker:       ticker,
+			Start:        time.Unix(0, r.WindowStart),
+			Session:      session,
+			Open:         r.Open,
+			High:         r.High,
+			Low:          r.Low,
+			Close:        r.Close,
+			Volume:       r.Volume,
+			Transactions: r.Transactions,
+		})
+	}
+	return out, nil
+}
+
+// ContinuousConfig is a set of options for LoadContinuous.
+type ContinuousConfig struct {
+	// Product is the product code, e.g. ES.
+	Product string
+
+	// From and To are the first and last session dates of the series.
+	From time.Time
+	To   time.Time
+
+	// Resolution is the size of each bar, e.g. 1min or 1session. Omitting this uses
+	// 1session.
+	Resolution string
+
+	// RollDaysBefore is the number of sessions before the last trade date of a
+	// contract to roll to the next one.
+	RollDaysBefore int
+
+	// Adjustment is how prices before a roll are adjusted.
+	Adjustment Adjustment
+}
+
+// LoadContinuous builds a continuous series of a product between two dates from the
+// REST API. Contracts are rolled using the product's trading sessions, falling back to
+// weekdays where the schedule doesn't cover a roll.
+func LoadContinuous(ctx context.Context, client *rest.Client, config ContinuousConfig) ([]Bar, error) {
+	if config.Product == "" {
+		return nil, fmt.Errorf("product is required")
+	}
+	if config.To.Before(config.From) {
+		return nil, fmt.Errorf("to must not be before from")
+	}
+	if config.Resolution == "" {
+		config.Resolution = "1session"
+	}
+	from, to := day(config.From), day(config.To)
+
+	// every contract still trading in the range, plus the ones after it which the last
+	// roll may go to
+	listings, err := ListContracts(ctx, client, config.Product, &gen.GetFuturesV1ContractsParams{
+		Type:              rest.Ptr(gen.GetFuturesV1ContractsParamsTypeSingle),
+		LastTradeDateGte:  rest.Ptr(from.Format(dateLayout)),
+		FirstTradeDateLte: rest.Ptr(to.Format(dateLayout)),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(listings) == 0 {
+		return nil, fmt.Errorf("no %v futures contracts trade between %v and %v", config.Product,
+			from.Format(dateLayout), to.Format(dateLayout))
+	}
+
+	// sessions before the range are needed to count back from the last trade dates of
+	// the first contracts, and bars before it to measure the gap at the first roll
+	lookback := from.AddDate(0, 0, -(2*config.RollDaysBefore + 7))
+	calendar, err := LoadCalendar(ctx, client, config.Product, lookback, latestLastTrade(listings))
+	if err != nil {
+		return nil, err
+	}
+	all := Rolls(listings, calendar, config.RollDaysBefore)
+
+	// the rolls inside the range
+	first := sort.Search(len(all), func(i int) bool { return all[i].Date.After(from) })
+	last := sort.Search(len(all), func(i int) bool { return all[i].Date.After(to) })
+	rolls := all[first:last]
+
+	bars := make(map[string][]Bar)
+	load := func(ticker string) error {
+		if _, ok := bars[ticker]; ok {
+			return nil
+		}
+		// sessions start the evening before their trading date
+		b, err := ListBars(ctx, client, ticker, &gen.AggregatesV1Params{
+			Resolution:     rest.Ptr(config.Resolution),
+			WindowStartGte: rest.Ptr(lookback.Format(dateLayout)),
+			WindowStartLt:  rest.Ptr(to.AddDate(0, 0, 1).Format(dateLayout)),
+		})
+		bars[ticker] = b
+		return err
+	}
+
+	var series []Bar
+	if len(rolls) == 0 {
+		// the whole range is covered by one contract
+		var ticker string
+		switch {
+		case first > 0:
+			ticker = all[first-1].To.Ticker
+		case len(all) > 0:
+			ticker = all[0].From.Ticker
+		default:
+			ticker = listings[0].Ticker
+		}
+		if err := load(ticker); err != nil {
+			return nil, err
+		}
+		series = bars[ticker]
+	} else {
+		for _, r := range rolls {
+			if err := load(r.From.Ticker); err != nil {
+				return nil, err
+			}
+			if err := load(r.To.Ticker); err != nil {
+				return nil, err
+			}
+		}
+		series = Continuous(rolls, bars, config.Adjustment)
+	}
+
+	out := series[:0:0]
+	for _, b := range series {
+		if !b.Session.Before(from) && !b.Session.After(to) {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
+func latestLastTrade(listings []Listing) time.Time {
+	var latest time.Time
+	for _, l := range listings {
+		if l.LastTradeDate.After(latest) {
+			latest = l.LastTradeDate
+		}
+	}
+	return latest
+}
@@ -0,0 +1,138 @@
+package futures
+
+import (
+	"sort"
+	"time"
+)
+
+// Listing is a listed contract with its trading dates. Dates are at midnight UTC.
+type Listing struct {
+	Contract Contract
+	Ticker   string
+
+	// FirstTradeDate and LastTradeDate are the first and last days the contract
+	// trades. SettlementDate is the day it settles; it's zero if unknown.
+	FirstTradeDate time.Time
+	LastTradeDate  time.Time
+	SettlementDate time.Time
+}
+
+// Trading reports whether the listing trades on a date.
+func (l Listing) Trading(date time.Time) bool {
+	d := day(date)
+	return !d.Before(l.FirstTradeDate) && !d.After(l.LastTradeDate)
+}
+
+// Calendar is the set of trading sessions of a product, identified by their session
+// end (trading) dates.
+type Calendar struct {
+	sessions []time.Time
+}
+
+// NewCalendar creates a calendar from session dates. Duplicates are ignored and the
+// time of day is dropped.
+func NewCalendar(sessions []time.Time) *Calendar {
+	seen := make(map[time.Time]bool, len(sessions))
+	c := &Calendar{}
+	for _, s := range sessions {
+		d := day(s)
+		if !seen[d] {
+			seen[d] = true
+			c.sessions = append(c.sessions, d)
+		}
+	}
+	sort.Slice(c.sessions, func(i, j int) bool { return c.sessions[i].Before(c.sessions[j]) })
+	return c
+}
+
+// Sessions returns the session dates in order.
+func (c *Calendar) Sessions() []time.Time {
+	return append([]time.Time(nil), c.sessions...)
+}
+
+// Trading reports whether there's a session on a date.
+func (c *Calendar) Trading(date time.Time) bool {
+	d := day(date)
+	i := sort.Search(len(c.sessions), func(i int) bool { return !c.sessions[i].Before(d) })
+	return i < len(c.sessions) && c.sessions[i].Equal(d)
+}
+
+// SessionsBefore returns the session n sessions before a date; for n = 0 it's the
+// last session on or before the date. It returns false if the calendar ends before
+// the date or doesn't go back far enough.
+func (c *Calendar) SessionsBefore(date time.Time, n int) (time.Time, bool) {
+	d := day(date)
+	if len(c.sessions) == 0 || c.sessions[len(c.sessions)-1].Before(d) {
+		return time.Time{}, false
+	}
+	// index of the first session after date, so i-1 is the last one on or before it
+	i := sort.Search(len(c.sessions), func(i int) bool { return c.sessions[i].After(d) })
+	j := i - 1 - n
+	if n < 0 || j < 0 {
+		return time.Time{}, false
+	}
+	return c.sessions[j], true
+}
+
+// FrontMonth returns the listing trading on a date with the earliest last trade date.
+func FrontMonth(listings []Listing, date time.Time) (Listing, bool) {
+	var front Listing
+	var ok bool
+	for _, l := range listings {
+		if l.Trading(date) && (!ok || l.LastTradeDate.Before(front.LastTradeDate)) {
+			front, ok = l, true
+		}
+	}
+	return front, ok
+}
+
+// Roll is a switch from one contract to the next in a continuous series.
+type Roll struct {
+	From Listing
+	To   Listing
+
+	// Date is the first session that uses To.
+	Date time.Time
+}
+
+// Rolls returns the roll dates between consecutive listings, ordered by last trade
+// date. Each contract is rolled daysBefore sessions before its last trade date, so 0
+// rolls on the last trade date itself. Without a calendar, or where it doesn't cover
+// a roll, weekdays are used as sessions.
+func Rolls(listings []Listing, calendar *Calendar, daysBefore int) []Roll {
+	sorted := append([]Listing(nil), listings...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].LastTradeDate.Before(sorted[j].LastTradeDate) })
+
+	var out []Roll
+	for i := 1; i < len(sorted); i++ {
+		from, to := sorted[i-1], sorted[i]
+		date, ok := time.Time{}, false
+		if calendar != nil {
+			date, ok = calendar.SessionsBefore(from.LastTradeDate, daysBefore)
+		}
+		if !ok {
+			date = weekdaysBefore(from.LastTradeDate, daysBefore)
+		}
+		out = append(out, Roll{From: from, To: to, Date: date})
+	}
+	return out
+}
+
+// weekdaysBefore returns the weekday n weekdays before a date, or the date itself
+// for n = 0.
+func weekdaysBefore(date time.Time, n int) time.Time {
+	d := day(date)
+	for n > 0 {
+		d = d.AddDate(0, 0, -1)
+		if d.Weekday() != time.Saturday && d.Weekday() != time.Sunday {
+			n--
+		}
+	}
+	return d
+}
+
+// day returns the date of t at midnight UTC.
+func day(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
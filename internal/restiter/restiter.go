@@ -0,0 +1,33 @@
+// Package restiter decodes the results of generated REST responses into the types of
+// the packages built on the REST client.
+package restiter
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/massive-com/client-go/v3/rest"
+)
+
+// Results checks a response and decodes every result across all pages. Pages after
+// the first are only fetched if the client has pagination enabled.
+func Results[T any](c *rest.Client, resp any) ([]T, error) {
+	if err := rest.CheckResponse(resp); err != nil {
+		return nil, err
+	}
+
+	var out []T
+	iter := rest.NewIteratorFromResponse(c, resp)
+	for iter.Next() {
+		data, err := json.Marshal(iter.Item())
+		if err != nil {
+			return nil, err
+		}
+		var v T
+		if err := json.Unmarshal(data, &v); err != nil {
+			return nil, fmt.Errorf("failed to decode result: %w", err)
+		}
+		out = append(out, v)
+	}
+	return out, iter.Err()
+}
@@ -0,0 +1,44 @@
+package restiter
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/massive-com/client-go/v3/rest"
+	"github.com/stretchr/testify/assert"
+)
+
+// response has the fields of a generated response.
+type response struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *struct {
+		Results *[]map[string]any
+	}
+}
+
+type result struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestResults(t *testing.T) {
+	client := rest.New("test")
+
+	resp := response{HTTPResponse: &http.Response{StatusCode: http.StatusOK}}
+	resp.JSON200 = &struct{ Results *[]map[string]any }{
+		Results: &[]map[string]any{{"id": 1, "name": "a"}, {"id": 2}},
+	}
+	got, err := Results[result](client, resp)
+	assert.Nil(t, err)
+	assert.Equal(t, []result{{1, "a"}, {2, ""}}, got)
+
+	(*resp.JSON200.Results)[1]["id"] = "two"
+	_, err = Results[result](client, resp)
+	assert.NotNil(t, err)
+
+	_, err = Results[result](client, response{HTTPResponse: &http.Response{StatusCode: http.StatusNotFound}, Body: []byte("not found")})
+	assert.NotNil(t, err)
+	_, err = Results[result](client, nil)
+	assert.NotNil(t, err)
+}
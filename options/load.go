@@ -0,0 +1,84 @@
+package options
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/massive-com/client-go/v3/internal/restiter"
+	"github.com/massive-com/client-go/v3/rest"
+	"github.com/massive-com/client-go/v3/rest/gen"
+)
+
+// contractResult is a contract as returned by the options contracts endpoint.
+type contractResult struct {
+	Ticker string `json:"ticker"`
+}
+
+// chainResult is a contract as returned by the options chain endpoint.
+type chainResult struct {
+	Details struct {
+		Ticker string `json:"ticker"`
+	} `json:"details"`
+}
+
+// ListContracts lists the contracts matching params, e.g. by underlying, type,
+// expiration or strike. The client should have pagination enabled to get every page.
+func ListContracts(ctx context.Context, client *rest.Client, params *gen.ListOptionsContractsParams) ([]Contract, error) {
+	if params == nil {
+		params = &gen.ListOptionsContractsParams{}
+	}
+	resp, err := client.ListOptionsContractsWithResponse(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list options contracts: %w", err)
+	}
+	return FromContracts(client, resp)
+}
+
+// FromContracts converts a response of ListOptionsContractsWithResponse, following
+// its next pages if the client has pagination enabled.
+func FromContracts(client *rest.Client, resp *gen.ListOptionsContractsResponse) ([]Contract, error) {
+	list, err := restiter.Results[contractResult](client, resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list options contracts: %w", err)
+	}
+	out := make([]Contract, 0, len(list))
+	for _, r := range list {
+		c, err := Parse(r.Ticker)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// Chain lists the contracts in the chain of an underlying asset matching params. The
+// client should have pagination enabled to get every page.
+func Chain(ctx context.Context, client *rest.Client, underlying string, params *gen.GetOptionsChainParams) ([]Contract, error) {
+	if params == nil {
+		params = &gen.GetOptionsChainParams{}
+	}
+	resp, err := client.GetOptionsChainWithResponse(ctx, underlying, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %v options chain: %w", underlying, err)
+	}
+	return FromChain(client, resp)
+}
+
+// FromChain converts a response of GetOptionsChainWithResponse, following its next
+// pages if the client has pagination enabled.
+func FromChain(client *rest.Client, resp *gen.GetOptionsChainResponse) ([]Contract, error) {
+	list, err := restiter.Results[chainResult](client, resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get options chain: %w", err)
+	}
+	out := make([]Contract, 0, len(list))
+	for _, r := range list {
+		c, err := Parse(r.Details.Ticker)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
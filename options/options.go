@@ -0,0 +1,190 @@
+// Package options parses and builds options contract symbols.
+//
+// Contracts are identified by OCC symbols, e.g. O:A230616C00070000 for the call on A
+// expiring on June 16, 2023 with a strike of 70. The REST and WebSocket APIs both use
+// this form, prefixed with O:. Parse and Contract.Ticker convert between symbols and
+// contracts, and ListContracts and Chain turn REST results into contracts that can be
+// subscribed to with Tickers.
+package options
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Prefix is the prefix of options tickers in the REST and WebSocket APIs.
+const Prefix = "O:"
+
+const (
+	// maxRoot is the longest OCC root symbol.
+	maxRoot = 6
+
+	// strikeScale is the number of strike units per dollar in a symbol.
+	strikeScale = 1000
+
+	// maxStrike is the largest strike that fits in the eight digits of a symbol.
+	maxStrike = 99999.999
+)
+
+// Type is the type of a contract.
+type Type byte
+
+const (
+	Call Type = 'C'
+	Put  Type = 'P'
+)
+
+func (t Type) String() string {
+	switch t {
+	case Call:
+		return "call"
+	case Put:
+		return "put"
+	default:
+		return fmt.Sprintf("Type(%d)", byte(t))
+	}
+}
+
+// ParseType parses a contract type from "call" or "put", as returned by the REST API,
+// or from "C" or "P". Case is ignored.
+func ParseType(s string) (Type, error) {
+	switch strings.ToUpper(s) {
+	case "C", "CALL":
+		return Call, nil
+	case "P", "PUT":
+		return Put, nil
+	default:
+		return 0, fmt.Errorf("invalid contract type %q", s)
+	}
+}
+
+// Contract is an options contract.
+type Contract struct {
+	// Underlying is the OCC root symbol, which is usually the underlying ticker. Roots
+	// of adjusted contracts have a trailing digit, e.g. AAPL1.
+	Underlying string
+
+	// Expiration is the expiration date at midnight UTC.
+	Expiration time.Time
+
+	Type Type
+
+	// Strike is the strike price, with at most three decimals.
+	Strike float64
+}
+
+// Parse parses an OCC symbol, with or without the O: prefix.
+func Parse(symbol string) (Contract, error) {
+	s := strings.TrimPrefix(symbol, Prefix)
+
+	// the root is followed by 15 characters: YYMMDD, C or P and the strike
+	n := len(s) - 15
+	if n < 1 || n > maxRoot {
+		return Contract{}, fmt.Errorf("invalid options symbol %q", symbol)
+	}
+
+	exp, err := time.Parse("060102", s[n:n+6])
+	if err != nil {
+		return Contract{}, fmt.Errorf("invalid expiration in options symbol %q", symbol)
+	}
+	typ, err := ParseType(s[n+6 : n+7])
+	if err != nil {
+		return Contract{}, fmt.Errorf("invalid type in options symbol %q", symbol)
+	}
+	strike, err := parseStrike(s[n+7:])
+	if err != nil {
+		return Contract{}, fmt.Errorf("invalid strike in options symbol %q", symbol)
+	}
+
+	c := Contract{Underlying: s[:n], Expiration: exp, Type: typ, Strike: strike}
+	if err := c.Validate(); err != nil {
+		return Contract{}, fmt.Errorf("invalid options symbol %q: %w", symbol, err)
+	}
+	return c, nil
+}
+
+// MustParse is like Parse but panics if the symbol is invalid.
+func MustParse(symbol string) Contract {
+	c, err := Parse(symbol)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Valid reports whether a symbol is a valid OCC symbol, with or without the O: prefix.
+func Valid(symbol string) bool {
+	_, err := Parse(symbol)
+	return err == nil
+}
+
+func parseStrike(s string) (float64, error) {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return 0, errors.New("strike must be digits")
+		}
+	}
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return float64(v) / strikeScale, nil
+}
+
+// Validate reports whether the contract can be represented as an OCC symbol.
+func (c Contract) Validate() error {
+	if c.Underlying == "" || len(c.Underlying) > maxRoot {
+		return fmt.Errorf("underlying must be 1 to %d characters", maxRoot)
+	}
+	for _, r := range c.Underlying {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return fmt.Errorf("underlying %q must be uppercase letters and digits", c.Underlying)
+		}
+	}
+	if c.Expiration.IsZero() {
+		return errors.New("expiration is required")
+	}
+	if y := c.Expiration.Year(); y < 2000 || y > 2099 {
+		return fmt.Errorf("expiration year %d is out of range", y)
+	}
+	if c.Type != Call && c.Type != Put {
+		return fmt.Errorf("invalid contract type %v", c.Type)
+	}
+	if c.Strike <= 0 || c.Strike > maxStrike {
+		return fmt.Errorf("strike %v is out of range", c.Strike)
+	}
+	if scaled := c.Strike * strikeScale; math.Abs(scaled-math.Round(scaled)) > 1e-6 {
+		return fmt.Errorf("strike %v has more than three decimals", c.Strike)
+	}
+	return nil
+}
+
+// Symbol returns the OCC symbol of the contract without the O: prefix. The contract
+// should be valid.
+func (c Contract) Symbol() string {
+	strike := int64(math.Round(c.Strike * strikeScale))
+	return fmt.Sprintf("%s%s%c%08d", c.Underlying, c.Expiration.Format("060102"), byte(c.Type), strike)
+}
+
+// Ticker returns the ticker of the contract in the REST and WebSocket APIs, e.g.
+// O:A230616C00070000. The contract should be valid.
+func (c Contract) Ticker() string {
+	return Prefix + c.Symbol()
+}
+
+func (c Contract) String() string {
+	return c.Ticker()
+}
+
+// Tickers returns the tickers of contracts, e.g. to subscribe to them.
+func Tickers(contracts []Contract) []string {
+	out := make([]string, len(contracts))
+	for i, c := range contracts {
+		out[i] = c.Ticker()
+	}
+	return out
+}
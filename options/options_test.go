@@ -0,0 +1,121 @@
+package options
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/massive-com/client-go/v3/rest"
+	"github.com/massive-com/client-go/v3/rest/gen"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	c, err := Parse("O:A230616C00070000")
+	assert.Nil(t, err)
+	assert.Equal(t, Contract{
+		Underlying: "A",
+		Expiration: time.Date(2023, 6, 16, 0, 0, 0, 0, time.UTC),
+		Type:       Call,
+		Strike:     70,
+	}, c)
+	assert.Equal(t, "O:A230616C00070000", c.Ticker())
+	assert.Equal(t, "A230616C00070000", c.Symbol())
+
+	// the prefix is optional, and fractional strikes and adjusted roots are supported
+	c, err = Parse("SPXW241220P05912500")
+	assert.Nil(t, err)
+	assert.Equal(t, "SPXW", c.Underlying)
+	assert.Equal(t, Put, c.Type)
+	assert.Equal(t, 5912.5, c.Strike)
+	assert.Equal(t, "O:SPXW241220P05912500", c.String())
+
+	c = MustParse("O:AAPL1250117C00152500")
+	assert.Equal(t, "AAPL1", c.Underlying)
+	assert.Equal(t, 152.5, c.Strike)
+
+	for _, s := range []string{
+		"",
+		"O:",
+		"AAPL",
+		"O:230616C00070000",        // no root
+		"O:ABCDEFG230616C00070000", // root too long
+		"O:A231316C00070000",       // bad month
+		"O:A230616X00070000",       // bad type
+		"O:A230616C0007000",        // short strike
+		"O:A230616C-0070000",       // signed strike
+		"O:A230616C00000000",       // zero strike
+		"O:a230616C00070000",       // lowercase root
+		"O:A690616C00070000",       // year outside 2000-2099
+	} {
+		assert.False(t, Valid(s), s)
+	}
+	assert.Panics(t, func() { MustParse("AAPL") })
+}
+
+func TestBuild(t *testing.T) {
+	c := Contract{Underlying: "SPY", Expiration: time.Date(2025, 12, 19, 0, 0, 0, 0, time.UTC), Type: Put, Strike: 0.5}
+	assert.Nil(t, c.Validate())
+	assert.Equal(t, "O:SPY251219P00000500", c.Ticker())
+
+	parsed, err := Parse(c.Ticker())
+	assert.Nil(t, err)
+	assert.Equal(t, c, parsed)
+
+	c.Strike = 100.0001
+	assert.NotNil(t, c.Validate())
+	c.Strike = 100000
+	assert.NotNil(t, c.Validate())
+	c.Strike = 1
+	c.Type = 'X'
+	assert.NotNil(t, c.Validate())
+	c.Type = Call
+	c.Expiration = time.Time{}
+	assert.NotNil(t, c.Validate())
+}
+
+func TestParseType(t *testing.T) {
+	for s, want := range map[string]Type{"call": Call, "PUT": Put, "c": Call, "P": Put} {
+		typ, err := ParseType(s)
+		assert.Nil(t, err)
+		assert.Equal(t, want, typ)
+	}
+	_, err := ParseType("other")
+	assert.NotNil(t, err)
+	assert.Equal(t, "call", Call.String())
+	assert.Equal(t, "put", Put.String())
+}
+
+func TestFromResponses(t *testing.T) {
+	client := rest.New("test")
+
+	var contracts gen.ListOptionsContractsResponse
+	err := json.Unmarshal([]byte(`{"results": [
+		{"ticker": "O:A230616C00070000", "underlying_ticker": "A", "contract_type": "call"},
+		{"ticker": "O:A230616P00070000", "underlying_ticker": "A", "contract_type": "put"}
+	]}`), &contracts.JSON200)
+	assert.Nil(t, err)
+
+	list, err := FromContracts(client, &contracts)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"O:A230616C00070000", "O:A230616P00070000"}, Tickers(list))
+
+	var chain gen.GetOptionsChainResponse
+	err = json.Unmarshal([]byte(`{"request_id": "1", "results": [
+		{"details": {"ticker": "O:SPY251219C00650000", "contract_type": "call", "strike_price": 650}}
+	]}`), &chain.JSON200)
+	assert.Nil(t, err)
+
+	list, err = FromChain(client, &chain)
+	assert.Nil(t, err)
+	if assert.Len(t, list, 1) {
+		assert.Equal(t, "SPY", list[0].Underlying)
+		assert.Equal(t, 650.0, list[0].Strike)
+	}
+
+	// invalid tickers are reported
+	err = json.Unmarshal([]byte(`{"results": [{"ticker": "O:BAD"}]}`), &contracts.JSON200)
+	assert.Nil(t, err)
+	_, err = FromContracts(client, &contracts)
+	assert.NotNil(t, err)
+}
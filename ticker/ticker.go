@@ -0,0 +1,361 @@
+// Package ticker converts tickers between the forms used by the REST and WebSocket
+// APIs.
+//
+// The APIs identify the same instrument differently: the REST API prefixes crypto,
+// forex, index and options tickers (X:BTCUSD, C:EURUSD, I:SPX, O:A230616C00070000),
+// while WebSocket subscriptions use pairs like BTC-USD and EUR/USD. A Ticker knows its
+// asset class and renders either form, so subscriptions and REST requests can share
+// one identifier.
+package ticker
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/massive-com/client-go/v3/futures"
+	"github.com/massive-com/client-go/v3/options"
+	massivews "github.com/massive-com/client-go/v3/websocket"
+	"github.com/massive-com/client-go/v3/websocket/models"
+)
+
+// AssetClass is the asset class of a ticker.
+type AssetClass string
+
+const (
+	Stocks  AssetClass = "stocks"
+	Options AssetClass = "options"
+	Crypto  AssetClass = "crypto"
+	Forex   AssetClass = "fx"
+	Indices AssetClass = "indices"
+	Futures AssetClass = "futures"
+)
+
+// The REST prefixes of each asset class.
+const (
+	cryptoPrefix = "X:"
+	forexPrefix  = "C:"
+	indexPrefix  = "I:"
+)
+
+// cryptoQuotes are the quote currencies crypto pairs are split on when the REST form
+// doesn't separate them, longest first so USDT wins over USD.
+var cryptoQuotes = []string{"USDT", "USDC", "USD", "EUR", "GBP", "JPY", "AUD", "CAD", "CHF", "BTC", "ETH"}
+
+// Ticker is an instrument of any asset class.
+type Ticker struct {
+	AssetClass AssetClass
+
+	// Symbol is the ticker without a prefix: the stock ticker, the index ticker, the
+	// OCC symbol of an options contract or the code of a futures contract. It's empty
+	// for crypto and forex pairs.
+	Symbol string
+
+	// Base and Quote are the currencies of crypto and forex pairs.
+	Base  string
+	Quote string
+}
+
+// Stock returns the ticker of a stock.
+func Stock(symbol string) Ticker {
+	return Ticker{AssetClass: Stocks, Symbol: symbol}
+}
+
+// Index returns the ticker of an index, with or without the I: prefix.
+func Index(symbol string) Ticker {
+	return Ticker{AssetClass: Indices, Symbol: strings.TrimPrefix(symbol, indexPrefix)}
+}
+
+// Option returns the ticker of an options contract.
+func Option(c options.Contract) Ticker {
+	return Ticker{AssetClass: Options, Symbol: c.Symbol()}
+}
+
+// Future returns the ticker of a futures contract.
+func Future(c futures.Contract) Ticker {
+	return Ticker{AssetClass: Futures, Symbol: c.Code()}
+}
+
+// CryptoPair returns the ticker of a crypto pair, e.g. BTC and USD.
+func CryptoPair(base, quote string) Ticker {
+	return Ticker{AssetClass: Crypto, Base: strings.ToUpper(base), Quote: strings.ToUpper(quote)}
+}
+
+// ForexPair returns the ticker of a forex pair, e.g. EUR and USD.
+func ForexPair(base, quote string) Ticker {
+	return Ticker{AssetClass: Forex, Base: strings.ToUpper(base), Quote: strings.ToUpper(quote)}
+}
+
+// Parse parses a ticker in any of the forms used by the REST and WebSocket APIs. The
+// asset class is inferred from the prefix (X:, C:, I: or O:) or the pair separator
+// (BTC-USD for crypto and EUR/USD for forex); anything else is a stock. A dash only
+// makes a crypto pair if it's followed by a known quote currency, since share classes
+// like BRK-B use it too. Use ParseAs for futures, whose codes look like stock tickers,
+// and for crypto pairs with other quote currencies.
+func Parse(s string) (Ticker, error) {
+	switch {
+	case strings.HasPrefix(s, options.Prefix):
+		return ParseAs(Options, s)
+	case strings.HasPrefix(s, cryptoPrefix):
+		return ParseAs(Crypto, s)
+	case strings.HasPrefix(s, forexPrefix):
+		return ParseAs(Forex, s)
+	case strings.HasPrefix(s, indexPrefix):
+		return ParseAs(Indices, s)
+	case isCryptoPair(s):
+		return ParseAs(Crypto, s)
+	case strings.Contains(s, "/"):
+		return ParseAs(Forex, s)
+	default:
+		return ParseAs(Stocks, s)
+	}
+}
+
+// ParseAs parses a ticker of a known asset class in any of the forms used by the REST
+// and WebSocket APIs.
+func ParseAs(assetClass AssetClass, s string) (Ticker, error) {
+	if s == "" || s == "*" {
+		return Ticker{}, fmt.Errorf("invalid ticker %q", s)
+	}
+
+	switch assetClass {
+	case Stocks:
+		return Stock(s), nil
+	case Indices:
+		t := Index(s)
+		if t.Symbol == "" {
+			return Ticker{}, fmt.Errorf("invalid index ticker %q", s)
+		}
+		return t, nil
+	case Options:
+		c, err := options.Parse(s)
+		if err != nil {
+			return Ticker{}, err
+		}
+		return Option(c), nil
+	case Futures:
+		if _, err := futures.Parse(s); err != nil {
+			return Ticker{}, err
+		}
+		// keep the code as given since the year may have more than one digit
+		return Ticker{AssetClass: Futures, Symbol: s}, nil
+	case Crypto:
+		base, quote, ok := splitPair(strings.TrimPrefix(s, cryptoPrefix), cryptoQuotes)
+		if !ok {
+			return Ticker{}, fmt.Errorf("invalid crypto pair %q", s)
+		}
+		return CryptoPair(base, quote), nil
+	case Forex:
+		base, quote, ok := splitPair(strings.TrimPrefix(s, forexPrefix), nil)
+		if !ok {
+			return Ticker{}, fmt.Errorf("invalid forex pair %q", s)
+		}
+		return ForexPair(base, quote), nil
+	default:
+		return Ticker{}, fmt.Errorf("unknown asset class %q", assetClass)
+	}
+}
+
+// MustParse is like Parse but panics if the ticker is invalid.
+func MustParse(s string) Ticker {
+	t, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// splitPair splits a pair on - or /, or else on a known quote currency, or else in two
+// three letter currencies.
+func splitPair(s string, quotes []string) (string, string, bool) {
+	if i := strings.IndexAny(s, "-/"); i >= 0 {
+		base, quote := s[:i], s[i+1:]
+		return base, quote, isCurrency(base) && isCurrency(quote)
+	}
+
+	s = strings.ToUpper(s)
+	for _, q := range quotes {
+		if base := strings.TrimSuffix(s, q); base != s && isCurrency(base) {
+			return base, q, true
+		}
+	}
+	if len(s) == 6 && isCurrency(s) {
+		return s[:3], s[3:], true
+	}
+	return "", "", false
+}
+
+// isCryptoPair reports whether s looks like a WebSocket crypto pair, i.e. a base and a
+// known quote currency separated by a dash.
+func isCryptoPair(s string) bool {
+	_, quote, ok := strings.Cut(s, "-")
+	if !ok {
+		return false
+	}
+	for _, q := range cryptoQuotes {
+		if strings.EqualFold(quote, q) {
+			return true
+		}
+	}
+	return false
+}
+
+func isCurrency(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'A' || r > 'Z') && (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// REST returns the ticker as used by the REST API, e.g. AAPL, O:A230616C00070000,
+// X:BTCUSD, C:EURUSD, I:SPX or ESZ5.
+func (t Ticker) REST() string {
+	switch t.AssetClass {
+	case Options:
+		return options.Prefix + t.Symbol
+	case Crypto:
+		return cryptoPrefix + t.Base + t.Quote
+	case Forex:
+		return forexPrefix + t.Base + t.Quote
+	case Indices:
+		return indexPrefix + t.Symbol
+	default:
+		return t.Symbol
+	}
+}
+
+// WebSocket returns the ticker as used to subscribe to every WebSocket topic of its
+// market, e.g. AAPL, O:A230616C00070000, BTC-USD, EUR/USD, I:SPX or ESZ5.
+func (t Ticker) WebSocket() string {
+	switch t.AssetClass {
+	case Options:
+		return options.Prefix + t.Symbol
+	case Crypto:
+		return t.Base + "-" + t.Quote
+	case Forex:
+		return t.Base + "/" + t.Quote
+	case Indices:
+		return indexPrefix + t.Symbol
+	default:
+		return t.Symbol
+	}
+}
+
+// String returns the REST form of the ticker.
+func (t Ticker) String() string {
+	return t.REST()
+}
+
+// Market returns the WebSocket market of the ticker. Futures tickers return the
+// all-venue futures market.
+func (t Ticker) Market() massivews.Market {
+	switch t.AssetClass {
+	case Options:
+		return massivews.Options
+	case Crypto:
+		return massivews.Crypto
+	case Forex:
+		return massivews.Forex
+	case Indices:
+		return massivews.Indices
+	case Futures:
+		return massivews.Futures
+	default:
+		return massivews.Stocks
+	}
+}
+
+// Option returns the contract of an options ticker.
+func (t Ticker) Option() (options.Contract, error) {
+	if t.AssetClass != Options {
+		return options.Contract{}, fmt.Errorf("%v is not an options ticker", t)
+	}
+	return options.Parse(t.Symbol)
+}
+
+// Future returns the contract of a futures ticker, resolving a one digit year with
+// the current time like futures.Parse. FutureAt resolves it relative to another time.
+func (t Ticker) Future() (futures.Contract, error) {
+	return t.FutureAt(time.Now())
+}
+
+// FutureAt returns the contract of a futures ticker, resolving the year relative to
+// ref like futures.ParseAt.
+func (t Ticker) FutureAt(ref time.Time) (futures.Contract, error) {
+	if t.AssetClass != Futures {
+		return futures.Contract{}, fmt.Errorf("%v is not a futures ticker", t)
+	}
+	return futures.ParseAt(t.Symbol, ref)
+}
+
+// AssetClassOf returns the asset class of a WebSocket market.
+func AssetClassOf(market massivews.Market) AssetClass {
+	switch market {
+	case massivews.Options:
+		return Options
+	case massivews.Forex:
+		return Forex
+	case massivews.Crypto:
+		return Crypto
+	case massivews.Indices:
+		return Indices
+	case massivews.Futures, massivews.FuturesCME, massivews.FuturesCBOT, massivews.FuturesNYMEX, massivews.FuturesCOMEX:
+		return Futures
+	default:
+		return Stocks
+	}
+}
+
+// Tickers returns the WebSocket form of tickers, e.g. to subscribe to them.
+func Tickers(tickers []Ticker) []string {
+	out := make([]string, len(tickers))
+	for i, t := range tickers {
+		out[i] = t.WebSocket()
+	}
+	return out
+}
+
+// Of returns the ticker of a WebSocket message. It returns false for messages without
+// a ticker or with one that can't be parsed.
+func Of(msg any) (Ticker, bool) {
+	var t Ticker
+	var err error
+	switch m := msg.(type) {
+	case models.CryptoTrade:
+		t, err = ParseAs(Crypto, m.Pair)
+	case models.CryptoQuote:
+		t, err = ParseAs(Crypto, m.Pair)
+	case models.Level2Book:
+		t, err = ParseAs(Crypto, m.Pair)
+	case models.ForexQuote:
+		t, err = ParseAs(Forex, m.Pair)
+	case models.FuturesTrade:
+		t, err = ParseAs(Futures, m.Symbol)
+	case models.FuturesQuote:
+		t, err = ParseAs(Futures, m.Symbol)
+	case models.FuturesAggregate:
+		t, err = ParseAs(Futures, m.Symbol)
+	case models.IndexValue:
+		t, err = ParseAs(Indices, m.Ticker)
+	case models.Imbalance:
+		t, err = Parse(m.Symbol)
+	case models.LimitUpLimitDown:
+		t, err = Parse(m.Symbol)
+	case models.LaunchpadValue:
+		t, err = Parse(m.Ticker)
+	case models.FairMarketValue:
+		t, err = Parse(m.Ticker)
+	case interface{ GetSymbol() string }:
+		// stock and options trades, quotes and aggregates, index and launchpad
+		// aggregates, and forex or crypto aggregates
+		t, err = Parse(m.GetSymbol())
+	default:
+		return Ticker{}, false
+	}
+	return t, err == nil
+}
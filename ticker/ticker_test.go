@@ -0,0 +1,138 @@
+package ticker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/massive-com/client-go/v3/options"
+	massivews "github.com/massive-com/client-go/v3/websocket"
+	"github.com/massive-com/client-go/v3/websocket/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		in         string
+		assetClass AssetClass
+		rest, ws   string
+	}{
+		{"AAPL", Stocks, "AAPL", "AAPL"},
+		{"BRK.A", Stocks, "BRK.A", "BRK.A"},
+		{"BRK-B", Stocks, "BRK-B", "BRK-B"},
+		{"BF-B", Stocks, "BF-B", "BF-B"},
+		{"O:A230616C00070000", Options, "O:A230616C00070000", "O:A230616C00070000"},
+		{"X:BTCUSD", Crypto, "X:BTCUSD", "BTC-USD"},
+		{"X:ETHUSDT", Crypto, "X:ETHUSDT", "ETH-USDT"},
+		{"X:BTC-USD", Crypto, "X:BTCUSD", "BTC-USD"},
+		{"BTC-USD", Crypto, "X:BTCUSD", "BTC-USD"},
+		{"eth-btc", Crypto, "X:ETHBTC", "ETH-BTC"},
+		{"C:EURUSD", Forex, "C:EURUSD", "EUR/USD"},
+		{"C:EUR-USD", Forex, "C:EURUSD", "EUR/USD"},
+		{"EUR/USD", Forex, "C:EURUSD", "EUR/USD"},
+		{"I:SPX", Indices, "I:SPX", "I:SPX"},
+	} {
+		got, err := Parse(tc.in)
+		if !assert.Nil(t, err, tc.in) {
+			continue
+		}
+		assert.Equal(t, tc.assetClass, got.AssetClass, tc.in)
+		assert.Equal(t, tc.rest, got.REST(), tc.in)
+		assert.Equal(t, tc.ws, got.WebSocket(), tc.in)
+
+		// both forms parse back to the same ticker
+		for _, s := range []string{got.REST(), got.WebSocket()} {
+			again, err := ParseAs(got.AssetClass, s)
+			assert.Nil(t, err, s)
+			assert.Equal(t, got, again, s)
+		}
+	}
+
+	for _, s := range []string{"", "*", "O:AAPL", "X:", "X:BTC", "C:EURUSDX", "-USD", "I:"} {
+		_, err := Parse(s)
+		assert.NotNil(t, err, s)
+	}
+	assert.Panics(t, func() { MustParse("X:") })
+}
+
+func TestParseAs(t *testing.T) {
+	fut, err := ParseAs(Futures, "ESZ5")
+	assert.Nil(t, err)
+	assert.Equal(t, "ESZ5", fut.REST())
+	assert.Equal(t, "ESZ5", fut.WebSocket())
+	c, err := fut.Future()
+	assert.Nil(t, err)
+	assert.Equal(t, "ES", c.Root)
+	c, err = fut.FutureAt(time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC))
+	assert.Nil(t, err)
+	assert.Equal(t, 2025, c.Year)
+	_, err = ParseAs(Futures, "AAPL")
+	assert.NotNil(t, err)
+
+	// forex pairs without a separator are split in two currencies
+	fx, err := ParseAs(Forex, "usdjpy")
+	assert.Nil(t, err)
+	assert.Equal(t, "USD/JPY", fx.WebSocket())
+
+	_, err = ParseAs("bonds", "US10Y")
+	assert.NotNil(t, err)
+}
+
+func TestConstructors(t *testing.T) {
+	assert.Equal(t, MustParse("X:BTCUSD"), CryptoPair("btc", "usd"))
+	assert.Equal(t, MustParse("C:EURUSD"), ForexPair("EUR", "USD"))
+	assert.Equal(t, MustParse("I:SPX"), Index("SPX"))
+	assert.Equal(t, MustParse("I:SPX"), Index("I:SPX"))
+	assert.Equal(t, "MSFT", Stock("MSFT").String())
+
+	opt := Option(options.MustParse("O:SPY251219C00650000"))
+	assert.Equal(t, "O:SPY251219C00650000", opt.REST())
+	c, err := opt.Option()
+	assert.Nil(t, err)
+	assert.Equal(t, 650.0, c.Strike)
+	_, err = Stock("SPY").Option()
+	assert.NotNil(t, err)
+	_, err = Stock("SPY").Future()
+	assert.NotNil(t, err)
+
+	assert.Equal(t, []string{"BTC-USD", "EUR/USD", "AAPL"}, Tickers([]Ticker{CryptoPair("BTC", "USD"), ForexPair("EUR", "USD"), Stock("AAPL")}))
+}
+
+func TestMarkets(t *testing.T) {
+	for _, m := range []massivews.Market{massivews.Stocks, massivews.Options, massivews.Forex, massivews.Crypto, massivews.Indices, massivews.Futures} {
+		assert.Equal(t, m, Ticker{AssetClass: AssetClassOf(m)}.Market())
+	}
+	assert.Equal(t, Futures, AssetClassOf(massivews.FuturesCME))
+}
+
+func TestOf(t *testing.T) {
+	for _, tc := range []struct {
+		msg  any
+		want string
+	}{
+		{models.EquityTrade{Symbol: "AAPL"}, "AAPL"},
+		{models.EquityQuote{Symbol: "O:A230616C00070000"}, "O:A230616C00070000"},
+		{models.EquityAgg{Symbol: "I:SPX"}, "I:SPX"},
+		{models.EquityAgg{Symbol: "X:BTC-USD"}, "X:BTCUSD"}, // launchpad aggregates
+		{models.CurrencyAgg{Pair: "BTC-USD"}, "X:BTCUSD"},
+		{models.CurrencyAgg{Pair: "EUR/USD"}, "C:EURUSD"},
+		{models.CryptoTrade{Pair: "BTC-USD"}, "X:BTCUSD"},
+		{models.CryptoQuote{Pair: "ETH-USD"}, "X:ETHUSD"},
+		{models.Level2Book{Pair: "BTC-USD"}, "X:BTCUSD"},
+		{models.ForexQuote{Pair: "EUR/USD"}, "C:EURUSD"},
+		{models.FuturesTrade{Symbol: "ESZ5"}, "ESZ5"},
+		{models.FuturesAggregate{Symbol: "ESZ5"}, "ESZ5"},
+		{models.IndexValue{Ticker: "I:SPX"}, "I:SPX"},
+		{models.Imbalance{Symbol: "MSFT"}, "MSFT"},
+		{models.LaunchpadValue{Ticker: "C:EURUSD"}, "C:EURUSD"},
+		{models.FairMarketValue{Ticker: "X:BTC-USD"}, "X:BTCUSD"},
+	} {
+		got, ok := Of(tc.msg)
+		assert.True(t, ok, tc.want)
+		assert.Equal(t, tc.want, got.REST())
+	}
+
+	_, ok := Of(models.ControlMessage{})
+	assert.False(t, ok)
+	_, ok = Of(models.EquityTrade{})
+	assert.False(t, ok)
+}
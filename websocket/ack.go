@@ -0,0 +1,146 @@
+package massivews
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrAckTimeout is returned when the server doesn't acknowledge authentication or a
+// subscription within the configured timeout.
+var ErrAckTimeout = errors.New("timed out waiting for server acknowledgement")
+
+// acks tracks the subscriptions that are waiting for a server acknowledgement.
+type acks map[string][]chan error
+
+// waitForAuth blocks until the process thread reports the result of authentication
+// or the timeout elapses.
+func (c *Client) waitForAuth(timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-c.authResult:
+		return err
+	case <-timer.C:
+		return fmt.Errorf("authentication: %w", ErrAckTimeout)
+	}
+}
+
+// notifyAuth hands the result of authentication to a Connect call that is waiting
+// for it. It reports whether anyone was waiting.
+func (c *Client) notifyAuth(err error) bool {
+	if !c.awaitingAuth.Load() {
+		return false
+	}
+	select {
+	case c.authResult <- err:
+		return true
+	default:
+		return false
+	}
+}
+
+// expectAcks registers a waiter for the acknowledgement of every subscription param.
+func (c *Client) expectAcks(params []string) []chan error {
+	c.amtx.Lock()
+	defer c.amtx.Unlock()
+
+	waiters := make([]chan error, 0, len(params))
+	for _, p := range params {
+		ch := make(chan error, 1)
+		c.acks[p] = append(c.acks[p], ch)
+		waiters = append(waiters, ch)
+	}
+	return waiters
+}
+
+// cancelAcks removes waiters that are no longer being waited on.
+func (c *Client) cancelAcks(params []string, waiters []chan error) {
+	c.amtx.Lock()
+	defer c.amtx.Unlock()
+
+	for i, p := range params {
+		chans := c.acks[p]
+		for j, ch := range chans {
+			if ch == waiters[i] {
+				chans = append(chans[:j], chans[j+1:]...)
+				break
+			}
+		}
+		if len(chans) == 0 {
+			delete(c.acks, p)
+		} else {
+			c.acks[p] = chans
+		}
+	}
+}
+
+// waitForAcks blocks until every waiter received an acknowledgement, any of them
+// received an error, or the timeout elapses.
+func (c *Client) waitForAcks(params []string, waiters []chan error, timeout time.Duration) error {
+	defer c.cancelAcks(params, waiters)
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for i, ch := range waiters {
+		select {
+		case err := <-ch:
+			if err != nil {
+				return fmt.Errorf("failed to subscribe to '%v': %w", params[i], err)
+			}
+		case <-timer.C:
+			return fmt.Errorf("subscription to '%v': %w", params[i], ErrAckTimeout)
+		}
+	}
+
+	return nil
+}
+
+// ack resolves the waiters for a subscription that the server acknowledged.
+func (c *Client) ack(message string) {
+	param, ok := strings.CutPrefix(message, "subscribed to: ")
+	if !ok {
+		return
+	}
+
+	c.amtx.Lock()
+	defer c.amtx.Unlock()
+
+	for _, ch := range c.acks[param] {
+		ch <- nil
+	}
+	delete(c.acks, param)
+}
+
+// nack fails the pending subscriptions named in an error status from the server,
+// e.g. "not authorized: T.AAPL". Error messages that don't name any pending
+// subscription can't be attributed, so they fail every pending subscription.
+func (c *Client) nack(message string) {
+	c.amtx.Lock()
+	defer c.amtx.Unlock()
+
+	named := make(map[string]bool)
+	for _, word := range strings.FieldsFunc(message, func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(`,'"`, r)
+	}) {
+		// params may contain colons (e.g. options tickers) but not end with one
+		word = strings.TrimRight(word, ":.")
+		if _, ok := c.acks[word]; ok {
+			named[word] = true
+		}
+	}
+
+	for param, chans := range c.acks {
+		if len(named) > 0 && !named[param] {
+			continue
+		}
+		for _, ch := range chans {
+			ch <- errors.New(message)
+		}
+		delete(c.acks, param)
+	}
+}
@@ -0,0 +1,114 @@
+package massivews
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/massive-com/client-go/v3/websocket/fakeserver"
+	"github.com/stretchr/testify/assert"
+)
+
+// newAckServer starts a fake server that accepts the "good" API key and never
+// answers the "silent" one. Tickers named BAD are rejected and tickers named SLOW are
+// never answered.
+func newAckServer() *fakeserver.Server {
+	return fakeserver.New(fakeserver.Config{
+		APIKey:    "good",
+		Authorize: func(param string) bool { return !strings.HasSuffix(param, ".BAD") },
+		Unanswered: func(param string) bool {
+			return param == "silent" || strings.HasSuffix(param, ".SLOW")
+		},
+	})
+}
+
+func newAckClient(t *testing.T, s *fakeserver.Server, apiKey string) *Client {
+	var retries uint64 = 0
+	c, err := New(Config{
+		APIKey:           apiKey,
+		Feed:             Feed(s.URL()),
+		Market:           Stocks,
+		MaxRetries:       &retries,
+		AuthTimeout:      time.Second,
+		SubscribeTimeout: 200 * time.Millisecond,
+	})
+	assert.Nil(t, err)
+	return c
+}
+
+func TestConnectWaitsForAuth(t *testing.T) {
+	s := newAckServer()
+	defer s.Close()
+
+	c := newAckClient(t, s, "good")
+	assert.Nil(t, c.Connect())
+	assert.Equal(t, Authenticated, c.State())
+	c.Close()
+
+	// a rejected key is returned from Connect instead of the error channel
+	c = newAckClient(t, s, "bad")
+	err := c.Connect()
+	assert.ErrorContains(t, err, "authentication failed")
+	c.Close()
+
+	// no answer at all
+	c = newAckClient(t, s, "silent")
+	c.authTimeout = 50 * time.Millisecond
+	err = c.Connect()
+	assert.True(t, errors.Is(err, ErrAckTimeout))
+	assert.Equal(t, Closed, c.State())
+	c.Close()
+}
+
+func TestSubscribeWaitsForAck(t *testing.T) {
+	s := newAckServer()
+	defer s.Close()
+
+	c := newAckClient(t, s, "good")
+	defer c.Close()
+
+	// subscribing before connecting doesn't wait
+	assert.Nil(t, c.Subscribe(StocksQuotes, "SLOW"))
+	assert.Nil(t, c.Connect())
+
+	assert.Nil(t, c.Subscribe(StocksTrades, "AAPL", "TSLA"))
+	assert.Eventually(t, func() bool { return c.State() == Subscribed }, time.Second, 10*time.Millisecond)
+
+	err := c.Subscribe(StocksTrades, "BAD")
+	assert.ErrorContains(t, err, "not authorized: T.BAD")
+
+	err = c.Subscribe(StocksTrades, "SLOW")
+	assert.True(t, errors.Is(err, ErrAckTimeout))
+
+	c.amtx.Lock()
+	assert.Empty(t, c.acks)
+	c.amtx.Unlock()
+}
+
+func TestNack(t *testing.T) {
+	c, err := New(Config{APIKey: "test", Feed: RealTime, Market: Options})
+	assert.Nil(t, err)
+
+	params := []string{"T.AAPL", "Q.O:SPY251219C00650000", "T.MSFT"}
+	waiters := c.expectAcks(params)
+
+	// only the subscription named in the message fails
+	c.nack("not authorized: Q.O:SPY251219C00650000.")
+	assert.ErrorContains(t, <-waiters[1], "not authorized")
+	assert.Len(t, waiters[0], 0)
+	assert.Len(t, waiters[2], 0)
+
+	c.nack("'T.MSFT', 'T.AAPL' are not available")
+	assert.NotNil(t, <-waiters[0])
+	assert.NotNil(t, <-waiters[2])
+	assert.Empty(t, c.acks)
+
+	// a message that doesn't name a subscription fails all of them
+	waiters = c.expectAcks(params)
+	c.nack("too many subscriptions")
+	for _, ch := range waiters {
+		assert.ErrorContains(t, <-ch, "too many subscriptions")
+	}
+	assert.Empty(t, c.acks)
+}
@@ -0,0 +1,206 @@
+package massivews
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/massive-com/client-go/v3/websocket/models"
+	"golang.org/x/exp/maps"
+)
+
+// Backfiller fetches the messages that were missed while a client was disconnected.
+// See the backfill package for an implementation that uses the REST client.
+type Backfiller interface {
+	// Backfill returns the messages for the request in the order they should be
+	// delivered. Messages must be the same types the client pushes to its output
+	// channel (e.g. models.EquityTrade, models.EquityAgg).
+	Backfill(ctx context.Context, req BackfillRequest) ([]any, error)
+}
+
+// BackfillRequest describes a window of data that was missed during an outage.
+type BackfillRequest struct {
+	// Market is the market of the client that reconnected.
+	Market Market
+
+	// Subscriptions are the tickers that were subscribed per topic. Wildcard
+	// subscriptions are left out since they can't be backfilled.
+	Subscriptions map[Topic][]string
+
+	// From is the time the last message was received before the disconnect.
+	From time.Time
+
+	// To is the time the client reconnected.
+	To time.Time
+}
+
+// dedupWindow is how long past the end of a backfill window live messages are
+// checked against the backfilled ones before the check is turned off.
+const dedupWindow = time.Minute
+
+// backfillDedup tracks the messages that were delivered by a backfill so the
+// same messages can be dropped if they are also delivered live.
+type backfillDedup struct {
+	keys map[string]struct{}
+	to   int64
+}
+
+// queueBackfill queues a backfill request for the process thread. If a previous
+// request hasn't been handled yet, the two windows are merged.
+func (c *Client) queueBackfill(from, to time.Time) {
+	req := BackfillRequest{
+		Market:        c.market,
+		Subscriptions: make(map[Topic][]string),
+		From:          from,
+		To:            to,
+	}
+	for topic, tickers := range c.subs {
+		if _, all := tickers["*"]; all {
+			continue
+		}
+		req.Subscriptions[topic] = maps.Keys(tickers)
+	}
+	if len(req.Subscriptions) == 0 {
+		return
+	}
+
+	select {
+	case prev := <-c.bQueue:
+		if prev.From.Before(req.From) {
+			req.From = prev.From
+		}
+	default:
+	}
+	c.bQueue <- req
+}
+
+// backfillRun is a backfill that is being fetched. Live messages received in the
+// meantime are held back until the backfill is delivered, and remembered so the
+// backfill doesn't deliver them a second time.
+type backfillRun struct {
+	req  BackfillRequest
+	live map[string]struct{}
+	held []any
+}
+
+type backfillResult struct {
+	msgs []any
+	err  error
+}
+
+// startBackfill fetches a backfill request in the background so the process thread
+// keeps reading live data. The process thread delivers the result once it arrives.
+func (c *Client) startBackfill(req BackfillRequest) {
+	c.backfilling = &backfillRun{req: req, live: make(map[string]struct{})}
+	c.ptomb.Go(func() error {
+		ctx, cancel := context.WithTimeout(c.ptomb.Context(context.Background()), c.backfillTimeout)
+		defer cancel()
+
+		c.log.Debugf("backfilling %v to %v", req.From, req.To)
+		msgs, err := c.backfiller.Backfill(ctx, req)
+		c.bResult <- backfillResult{msgs: msgs, err: err}
+		return nil
+	})
+}
+
+// finishBackfill delivers a fetched backfill in timestamp order, skipping messages
+// that were also received live, then delivers the live messages that were held back
+// and starts dropping live messages that duplicate the backfilled ones. Backfilled
+// messages are older than the live ones the gap detector has seen, so they're sent
+// without going through it.
+func (c *Client) finishBackfill(res backfillResult) {
+	run := c.backfilling
+	c.backfilling = nil
+	if res.err != nil {
+		c.log.Errorf("failed to backfill: %v", res.err)
+	}
+
+	sortByTime(res.msgs)
+	dedup := &backfillDedup{keys: make(map[string]struct{}), to: run.req.To.UnixMilli()}
+	for _, msg := range res.msgs {
+		if key, _, ok := backfillKey(msg); ok {
+			if _, live := run.live[key]; live {
+				continue
+			}
+			dedup.keys[key] = struct{}{}
+		}
+		if !c.send(msg) {
+			return
+		}
+	}
+	for _, msg := range run.held {
+		if !c.emit(msg) {
+			return
+		}
+	}
+	c.dedup = dedup
+}
+
+// hold holds back a live message received while the backfill is being fetched and
+// remembers it so the backfill can skip it.
+func (r *backfillRun) hold(msg any) {
+	if key, ts, ok := backfillKey(msg); ok && ts <= r.req.To.UnixMilli()+dedupWindow.Milliseconds() {
+		r.live[key] = struct{}{}
+	}
+	r.held = append(r.held, msg)
+}
+
+// sortByTime stably sorts messages by their timestamp. Messages without one keep
+// their place relative to each other at the end.
+func sortByTime(msgs []any) {
+	times := make([]time.Time, len(msgs))
+	for i, msg := range msgs {
+		times[i], _ = messageTime(msg)
+	}
+	sort.Stable(byTime{msgs, times})
+}
+
+type byTime struct {
+	msgs  []any
+	times []time.Time
+}
+
+func (b byTime) Len() int { return len(b.msgs) }
+
+func (b byTime) Less(i, j int) bool {
+	if b.times[i].IsZero() || b.times[j].IsZero() {
+		return !b.times[i].IsZero() && b.times[j].IsZero()
+	}
+	return b.times[i].Before(b.times[j])
+}
+
+func (b byTime) Swap(i, j int) {
+	b.msgs[i], b.msgs[j] = b.msgs[j], b.msgs[i]
+	b.times[i], b.times[j] = b.times[j], b.times[i]
+}
+
+// check reports whether a live message was already delivered by a backfill and
+// whether the message is far enough past the backfill window to stop checking.
+func (d *backfillDedup) check(msg any) (duplicate, expired bool) {
+	key, ts, ok := backfillKey(msg)
+	if !ok {
+		return false, false
+	}
+	if ts > d.to+dedupWindow.Milliseconds() {
+		return false, true
+	}
+	_, duplicate = d.keys[key]
+	return duplicate, false
+}
+
+// backfillKey returns a key that identifies a message that can be backfilled and
+// the timestamp of the message in Unix milliseconds.
+func backfillKey(msg any) (string, int64, bool) {
+	switch m := msg.(type) {
+	case models.EquityTrade:
+		return fmt.Sprintf("%v|%v|%v|%v|%v|%v", m.EventType.EventType, m.Symbol, m.Exchange, m.ID, m.SequenceNumber, m.Timestamp), int64(m.Timestamp), true
+	case models.CryptoTrade:
+		return fmt.Sprintf("%v|%v|%v|%v|%v", m.EventType.EventType, m.Pair, m.Exchange, m.ID, m.Timestamp), int64(m.Timestamp), true
+	case models.EquityAgg:
+		return fmt.Sprintf("%v|%v|%v", m.EventType.EventType, m.Symbol, m.StartTimestamp), int64(m.StartTimestamp), true
+	case models.CurrencyAgg:
+		return fmt.Sprintf("%v|%v|%v", m.EventType.EventType, m.Pair, m.StartTimestamp), int64(m.StartTimestamp), true
+	}
+	return "", 0, false
+}
@@ -0,0 +1,313 @@
+// Package backfill fills the gaps left by websocket reconnects using the REST API.
+//
+// Set a Backfiller on massivews.Config.Backfiller and, after every reconnect, the
+// client fetches the trades and aggregates that were missed for each subscribed
+// ticker in the background and delivers them in timestamp order before live data.
+package backfill
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/massive-com/client-go/v3/internal/restiter"
+	"github.com/massive-com/client-go/v3/rest"
+	"github.com/massive-com/client-go/v3/rest/gen"
+	"github.com/massive-com/client-go/v3/ticker"
+	massivews "github.com/massive-com/client-go/v3/websocket"
+	"github.com/massive-com/client-go/v3/websocket/models"
+)
+
+// maxLimit is the largest page size accepted by the trades and aggregates endpoints.
+const maxLimit = 50000
+
+// Backfiller implements massivews.Backfiller with the REST client. Trades and second
+// and minute aggregates are supported; other topics are skipped.
+type Backfiller struct {
+	client *rest.Client
+}
+
+// New creates a backfiller that uses the given REST client. The client should have
+// pagination enabled so that busy tickers are fully backfilled.
+func New(client *rest.Client) *Backfiller {
+	return &Backfiller{client: client}
+}
+
+// Backfill fetches the missed window for every subscribed ticker.
+func (b *Backfiller) Backfill(ctx context.Context, req massivews.BackfillRequest) ([]any, error) {
+	var out []any
+	for topic, tickers := range req.Subscriptions {
+		for _, ticker := range tickers {
+			msgs, err := b.fetch(ctx, req.Market, topic, ticker, req.From, req.To)
+			if err != nil {
+				return out, fmt.Errorf("failed to backfill '%v': %w", ticker, err)
+			}
+			out = append(out, msgs...)
+		}
+	}
+	return out, nil
+}
+
+func (b *Backfiller) fetch(ctx context.Context, market massivews.Market, topic massivews.Topic, ticker string, from, to time.Time) ([]any, error) {
+	switch topic {
+	case massivews.StocksTrades, massivews.OptionsTrades:
+		return b.equityTrades(ctx, market, ticker, from, to)
+	case massivews.CryptoTrades:
+		return b.cryptoTrades(ctx, ticker, from, to)
+	case massivews.StocksSecAggs, massivews.OptionsSecAggs, massivews.IndexSecAggs, massivews.ForexSecAggs, massivews.CryptoSecAggs:
+		return b.aggs(ctx, market, topic, ticker, time.Second, from, to)
+	case massivews.StocksMinAggs, massivews.OptionsMinAggs, massivews.IndexMinAggs, massivews.ForexMinAggs, massivews.CryptoMinAggs:
+		return b.aggs(ctx, market, topic, ticker, time.Minute, from, to)
+	}
+	return nil, nil
+}
+
+// trade is a trade as returned by any of the REST trades endpoints.
+type trade struct {
+	Conditions           []int32 `json:"conditions"`
+	Exchange             int32   `json:"exchange"`
+	ID                   string  `json:"id"`
+	Price                float64 `json:"price"`
+	Size                 float64 `json:"size"`
+	SequenceNumber       int64   `json:"sequence_number"`
+	SipTimestamp         int64   `json:"sip_timestamp"`
+	ParticipantTimestamp int64   `json:"participant_timestamp"`
+	ReceivedTimestamp    int64   `json:"received_timestamp"`
+	Tape                 int32   `json:"tape"`
+	TrfID                int64   `json:"trf_id"`
+	TrfTimestamp         int64   `json:"trf_timestamp"`
+}
+
+// agg is an aggregate as returned by any of the REST aggregates endpoints.
+type agg struct {
+	Open      float64 `json:"o"`
+	High      float64 `json:"h"`
+	Low       float64 `json:"l"`
+	Close     float64 `json:"c"`
+	Volume    float64 `json:"v"`
+	VWAP      float64 `json:"vw"`
+	Count     int64   `json:"n"`
+	OTC       bool    `json:"otc"`
+	Timestamp int64   `json:"t"`
+}
+
+func (b *Backfiller) equityTrades(ctx context.Context, market massivews.Market, ticker string, from, to time.Time) ([]any, error) {
+	gte, lt := strconv.FormatInt(from.UnixNano(), 10), strconv.FormatInt(to.UnixNano(), 10)
+
+	var resp any
+	var err error
+	if market == massivews.Options {
+		resp, err = b.client.GetOptionsTradesWithResponse(ctx, ticker, &gen.GetOptionsTradesParams{
+			TimestampGte: &gte,
+			TimestampLt:  &lt,
+			Order:        rest.Ptr(gen.GetOptionsTradesParamsOrderAsc),
+			Sort:         rest.Ptr(gen.GetOptionsTradesParamsSortTimestamp),
+			Limit:        rest.Int(maxLimit),
+		})
+	} else {
+		resp, err = b.client.GetStocksTradesWithResponse(ctx, ticker, &gen.GetStocksTradesParams{
+			TimestampGte: &gte,
+			TimestampLt:  &lt,
+			Order:        rest.Ptr(gen.GetStocksTradesParamsOrderAsc),
+			Sort:         rest.Ptr(gen.GetStocksTradesParamsSortTimestamp),
+			Limit:        rest.Int(maxLimit),
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	trades, err := restiter.Results[trade](b.client, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]any, 0, len(trades))
+	for _, t := range trades {
+		out = append(out, equityTrade(ticker, t))
+	}
+	return out, nil
+}
+
+func (b *Backfiller) cryptoTrades(ctx context.Context, pair string, from, to time.Time) ([]any, error) {
+	gte, lt := strconv.FormatInt(from.UnixNano(), 10), strconv.FormatInt(to.UnixNano(), 10)
+	resp, err := b.client.GetCryptoTradesWithResponse(ctx, restTicker(massivews.Crypto, pair), &gen.GetCryptoTradesParams{
+		TimestampGte: &gte,
+		TimestampLt:  &lt,
+		Order:        rest.Ptr(gen.GetCryptoTradesParamsOrderAsc),
+		Sort:         rest.Ptr(gen.GetCryptoTradesParamsSortTimestamp),
+		Limit:        rest.Int(maxLimit),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	trades, err := restiter.Results[trade](b.client, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]any, 0, len(trades))
+	for _, t := range trades {
+		out = append(out, cryptoTrade(pair, t))
+	}
+	return out, nil
+}
+
+func (b *Backfiller) aggs(ctx context.Context, market massivews.Market, topic massivews.Topic, ticker string, span time.Duration, from, to time.Time) ([]any, error) {
+	// only whole windows that closed before the reconnect are backfilled, the
+	// window that was open at the time is delivered live
+	start := from.Truncate(span).UnixMilli()
+	end := to.Truncate(span).UnixMilli()
+	if end <= start {
+		return nil, nil
+	}
+	fromMs, toMs := strconv.FormatInt(start, 10), strconv.FormatInt(end-1, 10)
+	timespan := "minute"
+	if span == time.Second {
+		timespan = "second"
+	}
+
+	var resp any
+	var err error
+	switch market {
+	case massivews.Options:
+		resp, err = b.client.GetOptionsAggregatesWithResponse(ctx, ticker, 1, gen.GetOptionsAggregatesParamsTimespan(timespan), fromMs, toMs,
+			&gen.GetOptionsAggregatesParams{Sort: "asc", Limit: rest.Int(maxLimit)})
+	case massivews.Indices:
+		resp, err = b.client.GetIndicesAggregatesWithResponse(ctx, ticker, 1, gen.GetIndicesAggregatesParamsTimespan(timespan), fromMs, toMs,
+			&gen.GetIndicesAggregatesParams{Sort: "asc", Limit: rest.Int(maxLimit)})
+	case massivews.Forex:
+		resp, err = b.client.GetForexAggregatesWithResponse(ctx, restTicker(market, ticker), 1, gen.GetForexAggregatesParamsTimespan(timespan), fromMs, toMs,
+			&gen.GetForexAggregatesParams{Sort: "asc", Limit: rest.Int(maxLimit)})
+	case massivews.Crypto:
+		resp, err = b.client.GetCryptoAggregatesWithResponse(ctx, restTicker(market, ticker), 1, gen.GetCryptoAggregatesParamsTimespan(timespan), fromMs, toMs,
+			&gen.GetCryptoAggregatesParams{Sort: "asc", Limit: rest.Int(maxLimit)})
+	default:
+		resp, err = b.client.GetStocksAggregatesWithResponse(ctx, ticker, 1, gen.GetStocksAggregatesParamsTimespan(timespan), fromMs, toMs,
+			&gen.GetStocksAggregatesParams{Sort: "asc", Limit: rest.Int(maxLimit)})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	aggs, err := restiter.Results[agg](b.client, resp)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]any, 0, len(aggs))
+	for _, a := range aggs {
+		if a.Timestamp < start || a.Timestamp+span.Milliseconds() > end {
+			continue
+		}
+		if market == massivews.Forex || market == massivews.Crypto {
+			out = append(out, currencyAgg(topic, ticker, span, a))
+		} else {
+			out = append(out, equityAgg(ticker, span, a))
+		}
+	}
+	return out, nil
+}
+
+func equityTrade(ticker string, t trade) models.EquityTrade {
+	ts := t.SipTimestamp
+	if ts == 0 {
+		ts = t.ParticipantTimestamp
+	}
+	return models.EquityTrade{
+		EventType:                       models.EventType{EventType: "T"},
+		Symbol:                          ticker,
+		Exchange:                        t.Exchange,
+		ID:                              t.ID,
+		Tape:                            t.Tape,
+		Price:                           t.Price,
+		Size:                            int64(t.Size),
+		Conditions:                      t.Conditions,
+		Timestamp:                       models.UnixMillis(ts / int64(time.Millisecond)),
+		SequenceNumber:                  t.SequenceNumber,
+		TradeReportingFacilityID:        t.TrfID,
+		TradeReportingFacilityTimestamp: models.UnixMillis(t.TrfTimestamp / int64(time.Millisecond)),
+	}
+}
+
+func cryptoTrade(pair string, t trade) models.CryptoTrade {
+	return models.CryptoTrade{
+		EventType:         models.EventType{EventType: "XT"},
+		Pair:              pair,
+		Exchange:          t.Exchange,
+		ID:                t.ID,
+		Price:             t.Price,
+		Size:              t.Size,
+		Conditions:        t.Conditions,
+		Timestamp:         models.UnixMillis(t.ParticipantTimestamp / int64(time.Millisecond)),
+		ReceivedTimestamp: models.UnixMillis(t.ReceivedTimestamp / int64(time.Millisecond)),
+	}
+}
+
+func equityAgg(ticker string, span time.Duration, a agg) models.EquityAgg {
+	ev := "AM"
+	if span == time.Second {
+		ev = "A"
+	}
+	var avg float64
+	if a.Count > 0 {
+		avg = a.Volume / float64(a.Count)
+	}
+	return models.EquityAgg{
+		EventType:      models.EventType{EventType: ev},
+		Symbol:         ticker,
+		Volume:         a.Volume,
+		VWAP:           a.VWAP,
+		Open:           a.Open,
+		Close:          a.Close,
+		High:           a.High,
+		Low:            a.Low,
+		AverageSize:    avg,
+		StartTimestamp: models.UnixMillis(a.Timestamp),
+		EndTimestamp:   models.UnixMillis(a.Timestamp + span.Milliseconds()),
+		OTC:            a.OTC,
+	}
+}
+
+func currencyAgg(topic massivews.Topic, pair string, span time.Duration, a agg) models.CurrencyAgg {
+	var ev string
+	switch topic {
+	case massivews.ForexSecAggs:
+		ev = "CAS"
+	case massivews.ForexMinAggs:
+		ev = "CA"
+	case massivews.CryptoSecAggs:
+		ev = "XAS"
+	default:
+		ev = "XA"
+	}
+	var avg int32
+	if a.Count > 0 {
+		avg = int32(a.Volume / float64(a.Count))
+	}
+	return models.CurrencyAgg{
+		EventType:      models.EventType{EventType: ev},
+		Pair:           pair,
+		Open:           a.Open,
+		Close:          a.Close,
+		High:           a.High,
+		Low:            a.Low,
+		Volume:         a.Volume,
+		VWAP:           a.VWAP,
+		StartTimestamp: models.UnixMillis(a.Timestamp),
+		EndTimestamp:   models.UnixMillis(a.Timestamp + span.Milliseconds()),
+		AVGTradeSize:   avg,
+	}
+}
+
+// restTicker converts a websocket ticker (e.g. BTC-USD, EUR/USD) to the REST ticker
+// format (e.g. X:BTCUSD, C:EURUSD). Tickers that can't be parsed are left as is.
+func restTicker(market massivews.Market, t string) string {
+	parsed, err := ticker.ParseAs(ticker.AssetClassOf(market), t)
+	if err != nil {
+		return t
+	}
+	return parsed.REST()
+}
@@ -0,0 +1,66 @@
+package backfill
+
+import (
+	"testing"
+	"time"
+
+	massivews "github.com/massive-com/client-go/v3/websocket"
+	"github.com/massive-com/client-go/v3/websocket/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestTicker(t *testing.T) {
+	assert.Equal(t, "X:BTCUSD", restTicker(massivews.Crypto, "BTC-USD"))
+	assert.Equal(t, "C:EURUSD", restTicker(massivews.Forex, "EUR/USD"))
+	assert.Equal(t, "AAPL", restTicker(massivews.Stocks, "AAPL"))
+	assert.Equal(t, "I:SPX", restTicker(massivews.Indices, "I:SPX"))
+}
+
+func TestEquityTrade(t *testing.T) {
+	tr := equityTrade("AAPL", trade{
+		Conditions:     []int32{12, 37},
+		Exchange:       4,
+		ID:             "52983525029461",
+		Price:          171.5,
+		Size:           100,
+		SequenceNumber: 1063,
+		SipTimestamp:   1686926400123456789,
+		Tape:           3,
+		TrfID:          202,
+		TrfTimestamp:   1686926400123000000,
+	})
+	assert.Equal(t, models.EquityTrade{
+		EventType:                       models.EventType{EventType: "T"},
+		Symbol:                          "AAPL",
+		Exchange:                        4,
+		ID:                              "52983525029461",
+		Tape:                            3,
+		Price:                           171.5,
+		Size:                            100,
+		Conditions:                      []int32{12, 37},
+		Timestamp:                       1686926400123,
+		SequenceNumber:                  1063,
+		TradeReportingFacilityID:        202,
+		TradeReportingFacilityTimestamp: 1686926400123,
+	}, tr)
+
+	// options trades only have a participant timestamp in some cases
+	tr = equityTrade("O:A230616C00070000", trade{ParticipantTimestamp: 1686926400123456789})
+	assert.Equal(t, models.UnixMillis(1686926400123), tr.Timestamp)
+}
+
+func TestAggs(t *testing.T) {
+	a := agg{Open: 1, High: 3, Low: 0.5, Close: 2, Volume: 100, VWAP: 1.5, Count: 4, Timestamp: 1686926400000}
+
+	eq := equityAgg("AAPL", time.Minute, a)
+	assert.Equal(t, "AM", eq.EventType.EventType)
+	assert.Equal(t, models.UnixMillis(1686926460000), eq.EndTimestamp)
+	assert.Equal(t, float64(25), eq.AverageSize)
+	assert.Equal(t, "A", equityAgg("AAPL", time.Second, a).EventType.EventType)
+
+	cur := currencyAgg(massivews.CryptoMinAggs, "BTC-USD", time.Minute, a)
+	assert.Equal(t, "XA", cur.EventType.EventType)
+	assert.Equal(t, "BTC-USD", cur.Pair)
+	assert.Equal(t, int32(25), cur.AVGTradeSize)
+	assert.Equal(t, "CAS", currencyAgg(massivews.ForexSecAggs, "EUR/USD", time.Second, a).EventType.EventType)
+}
@@ -0,0 +1,175 @@
+package massivews
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/massive-com/client-go/v3/websocket/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeBackfiller struct {
+	reqs    chan BackfillRequest
+	release chan struct{}
+	msgs    []any
+}
+
+func (f *fakeBackfiller) Backfill(ctx context.Context, req BackfillRequest) ([]any, error) {
+	f.reqs <- req
+	if f.release != nil {
+		select {
+		case <-f.release:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return f.msgs, nil
+}
+
+func TestBackfill(t *testing.T) {
+	trade := func(id string, ts int64) models.EquityTrade {
+		return models.EquityTrade{EventType: models.EventType{EventType: "T"}, Symbol: "AAPL", ID: id, Timestamp: models.UnixMillis(ts)}
+	}
+	older, overlap, live := trade("1", 900), trade("2", 1000), trade("3", 1001)
+	f := &fakeBackfiller{
+		reqs:    make(chan BackfillRequest, 1),
+		release: make(chan struct{}),
+		msgs:    []any{overlap, trade("0", 600), older}, // out of order, as per-ticker results can be
+	}
+	c, err := New(Config{
+		APIKey:     "test",
+		Feed:       RealTime,
+		Market:     Stocks,
+		Backfiller: f,
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, c.Subscribe(StocksTrades, "AAPL"))
+	assert.Nil(t, c.Subscribe(StocksQuotes)) // wildcards can't be backfilled
+
+	from, to := time.UnixMilli(500), time.UnixMilli(1000)
+	c.queueBackfill(from, to)
+	c.ptomb.Go(c.process)
+
+	req := <-f.reqs
+	assert.Equal(t, BackfillRequest{
+		Market:        Stocks,
+		Subscriptions: map[Topic][]string{StocksTrades: {"AAPL"}},
+		From:          from,
+		To:            to,
+	}, req)
+
+	// live data is held back while the backfill is fetched
+	data, _ := json.Marshal([]models.EquityTrade{overlap, live})
+	c.rQueue <- data
+	assert.Never(t, func() bool { return len(c.Output()) > 0 }, 100*time.Millisecond, 10*time.Millisecond)
+
+	// the backfill is delivered first in timestamp order without what was also
+	// received live, then the live data
+	close(f.release)
+	assert.Equal(t, trade("0", 600), <-c.Output())
+	assert.Equal(t, older, <-c.Output())
+	assert.Equal(t, overlap, <-c.Output())
+	assert.Equal(t, live, <-c.Output())
+
+	// live messages that were already backfilled are dropped
+	data, _ = json.Marshal([]models.EquityTrade{older, trade("4", 1002)})
+	c.rQueue <- data
+	assert.Equal(t, trade("4", 1002), <-c.Output())
+	assert.Len(t, c.Output(), 0)
+
+	c.ptomb.Kill(nil)
+	assert.Nil(t, c.ptomb.Wait())
+}
+
+func TestBackfillGapDetector(t *testing.T) {
+	trade := func(seq int64) models.EquityTrade {
+		return models.EquityTrade{EventType: models.EventType{EventType: "T"}, Symbol: "AAPL", SequenceNumber: seq, Timestamp: models.UnixMillis(seq)}
+	}
+	d := NewGapDetector(GapDetectorConfig{GapThreshold: 1})
+	f := &fakeBackfiller{reqs: make(chan BackfillRequest, 1), msgs: []any{trade(5), trade(6), trade(8)}}
+	c, err := New(Config{APIKey: "test", Feed: RealTime, Market: Stocks, GapDetector: d, Backfiller: f})
+	assert.Nil(t, err)
+	assert.Nil(t, c.Subscribe(StocksTrades, "AAPL"))
+
+	// the live data seen before the outage is newer than the backfill
+	d.Observe(trade(9))
+	d.Observe(trade(10))
+	stats := d.Stats()
+
+	c.queueBackfill(time.UnixMilli(0), time.UnixMilli(1000))
+	c.ptomb.Go(c.process)
+	<-f.reqs
+	for _, seq := range []int64{5, 6, 8} {
+		assert.Equal(t, trade(seq), <-c.Output())
+	}
+
+	// the backfill didn't count as out of order, and the gap in it isn't reported
+	assert.Equal(t, stats, d.Stats())
+	assert.Len(t, d.Events(), 0)
+
+	c.ptomb.Kill(nil)
+	assert.Nil(t, c.ptomb.Wait())
+}
+
+func TestBackfillClose(t *testing.T) {
+	f := &fakeBackfiller{reqs: make(chan BackfillRequest, 1), release: make(chan struct{})}
+	c, err := New(Config{APIKey: "test", Feed: RealTime, Market: Stocks, Backfiller: f})
+	assert.Nil(t, err)
+	assert.Nil(t, c.Subscribe(StocksTrades, "AAPL"))
+	c.queueBackfill(time.UnixMilli(0), time.UnixMilli(1000))
+	c.ptomb.Go(c.process)
+	<-f.reqs
+
+	// a backfill that's still being fetched is cancelled when the client stops
+	c.ptomb.Kill(nil)
+	assert.Nil(t, c.ptomb.Wait())
+}
+
+func TestSortByTime(t *testing.T) {
+	quote := models.EquityQuote{Symbol: "AAPL", Timestamp: 2}
+	agg := models.EquityAgg{Symbol: "AAPL", EndTimestamp: 3}
+	trade := models.CryptoTrade{Pair: "BTC-USD", Timestamp: 1}
+	msgs := []any{"unknown", agg, quote, trade}
+	sortByTime(msgs)
+	assert.Equal(t, []any{trade, quote, agg, "unknown"}, msgs)
+}
+
+func TestQueueBackfill(t *testing.T) {
+	c, err := New(Config{APIKey: "test", Feed: RealTime, Market: Crypto, Backfiller: &fakeBackfiller{}})
+	assert.Nil(t, err)
+
+	// nothing to backfill
+	c.queueBackfill(time.UnixMilli(0), time.UnixMilli(1000))
+	assert.Len(t, c.bQueue, 0)
+
+	// pending requests are merged
+	assert.Nil(t, c.Subscribe(CryptoTrades, "BTC-USD"))
+	c.queueBackfill(time.UnixMilli(0), time.UnixMilli(1000))
+	c.queueBackfill(time.UnixMilli(2000), time.UnixMilli(3000))
+	req := <-c.bQueue
+	assert.Equal(t, time.UnixMilli(0), req.From)
+	assert.Equal(t, time.UnixMilli(3000), req.To)
+}
+
+func TestBackfillDedup(t *testing.T) {
+	agg := models.EquityAgg{EventType: models.EventType{EventType: "AM"}, Symbol: "AAPL", StartTimestamp: 60000}
+	key, _, ok := backfillKey(agg)
+	assert.True(t, ok)
+
+	d := &backfillDedup{keys: map[string]struct{}{key: {}}, to: 120000}
+	duplicate, expired := d.check(agg)
+	assert.True(t, duplicate)
+	assert.False(t, expired)
+
+	// messages that can't be backfilled are never duplicates
+	duplicate, expired = d.check(models.EquityQuote{Symbol: "AAPL"})
+	assert.False(t, duplicate)
+	assert.False(t, expired)
+
+	// the check stops once live data is well past the backfill window
+	agg.StartTimestamp = models.UnixMillis(120000 + dedupWindow.Milliseconds() + 1)
+	_, expired = d.check(agg)
+	assert.True(t, expired)
+}
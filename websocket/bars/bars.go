@@ -0,0 +1,499 @@
+// Package bars builds custom bars from live trades and aggregates.
+package bars
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/massive-com/client-go/v3/websocket/models"
+	"gopkg.in/tomb.v2"
+)
+
+// Kind is the rule that decides when a bar closes.
+type Kind int
+
+const (
+	// Time bars cover fixed windows of Interval, aligned to the Unix epoch.
+	Time Kind = iota
+
+	// Tick bars close after Threshold trades.
+	Tick
+
+	// Volume bars close once their volume reaches Threshold.
+	Volume
+
+	// Dollar bars close once their notional value (price times size) reaches Threshold.
+	Dollar
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Time:
+		return "time"
+	case Tick:
+		return "tick"
+	case Volume:
+		return "volume"
+	case Dollar:
+		return "dollar"
+	default:
+		return "unknown"
+	}
+}
+
+// Bar is an OHLC bar for a symbol.
+type Bar struct {
+	Symbol string
+
+	// Start and End are the bounds of the window for time bars, or the times of the
+	// first and last trade for other bars.
+	Start time.Time
+	End   time.Time
+
+	Open  float64
+	High  float64
+	Low   float64
+	Close float64
+
+	// Volume includes trades that were excluded from OHLC by their conditions.
+	Volume float64
+
+	// VWAP is the volume weighted average price of the trades used for OHLC.
+	VWAP float64
+
+	// Trades is the number of trades in the bar, or zero for bars built from aggregates.
+	Trades int64
+
+	// Empty is true for time bars where nothing traded. Their prices are all the
+	// previous close.
+	Empty bool
+}
+
+// Config is a set of bar builder options.
+type Config struct {
+	// Kind is the type of bar to build.
+	Kind Kind
+
+	// Interval is the width of time bars (e.g. 5 * time.Second or time.Hour). It must
+	// be at least a millisecond, the precision of most timestamps.
+	Interval time.Duration
+
+	// Threshold is the number of trades, volume or notional value that closes a tick,
+	// volume or dollar bar. A trade that crosses the threshold is kept whole in the
+	// bar it closes.
+	Threshold float64
+
+	// ExcludeConditions are trade condition codes that leave a trade out of OHLC and
+	// VWAP. Excluded trades still count towards volume. A symbol's first bar only
+	// opens with a trade that isn't excluded, since there's no price to give it
+	// before that; excluded trades in a window that never gets one are dropped. The
+	// conditions package lists the codes that don't update the high and low with
+	// Table.Excluded.
+	ExcludeConditions []int32
+
+	// Delay is how long the clock waits after a window ends before closing it, to
+	// allow for trades that arrive late. Trades for windows that have already been
+	// emitted are dropped and counted.
+	Delay time.Duration
+
+	// MaxEmptyBars is how many empty time bars in a row are emitted for a symbol
+	// before it's considered idle. Idle symbols get no more empty bars and are
+	// forgotten until they trade again, so a gap of any length costs at most this many
+	// bars. Omitting this uses 100 bars.
+	MaxEmptyBars int
+
+	// EventBuffer is the size of the bar channel. Bars are dropped and counted when
+	// the channel is full. Omitting this uses a buffer of 1000 bars.
+	EventBuffer int
+}
+
+// Stats is a snapshot of bar builder counters.
+type Stats struct {
+	Bars     uint64
+	Excluded uint64
+	Late     uint64
+	Dropped  uint64
+
+	// Rejected is the number of aggregates that were ignored because they don't fit
+	// in a single window of the interval.
+	Rejected uint64
+}
+
+// Builder builds bars per symbol. Feed it every message from the client's output
+// channel with Observe: any models.Trade, i.e. stock, crypto and futures trades, is
+// supported for every kind of bar, and stock aggregates (A and AM) can be upsampled
+// into wider time bars. An aggregate has to fit in a single window, so the interval
+// must be a multiple of its width (e.g. minutes for AM); aggregates that span more
+// than one window are ignored and counted as rejected. Anything else is ignored.
+//
+// Time bars close when a message for a later window arrives or when the builder's
+// clock passes the end of the window. Start runs that clock so bars are emitted even
+// when nothing trades; Advance drives it manually.
+type Builder struct {
+	kind      Kind
+	interval  time.Duration
+	threshold float64
+	exclude   map[int32]struct{}
+	delay     time.Duration
+	maxEmpty  int
+
+	mtx     sync.Mutex
+	symbols map[string]*state
+	bars    chan Bar
+	stats   Stats
+
+	started bool
+	t       tomb.Tomb
+}
+
+// state is the bar in progress for a symbol.
+type state struct {
+	bar          Bar
+	open         bool    // the bar has trades or aggregates and a price to emit it with
+	priced       bool    // the bar has OHLC
+	notional     float64 // price times size of priced trades, for VWAP
+	pricedVolume float64 // volume of priced trades, for VWAP
+	value        float64 // notional of every trade, for dollar bars
+	last         float64 // the close of the previous bar
+	empty        int     // the number of empty bars emitted in a row
+	closed       time.Time
+}
+
+// New creates a bar builder.
+func New(config Config) (*Builder, error) {
+	switch config.Kind {
+	case Time:
+		if config.Interval < time.Millisecond {
+			return nil, errors.New("invalid bar options: time bars need an interval of at least a millisecond")
+		}
+	case Tick, Volume, Dollar:
+		if config.Threshold <= 0 {
+			return nil, errors.New("invalid bar options: threshold must be positive")
+		}
+	default:
+		return nil, errors.New("invalid bar options: unknown kind")
+	}
+	if config.Delay < 0 {
+		return nil, errors.New("invalid bar options: delay can't be negative")
+	}
+	if config.MaxEmptyBars < 1 {
+		config.MaxEmptyBars = 100
+	}
+	if config.EventBuffer < 1 {
+		config.EventBuffer = 1000
+	}
+
+	exclude := make(map[int32]struct{}, len(config.ExcludeConditions))
+	for _, c := range config.ExcludeConditions {
+		exclude[c] = struct{}{}
+	}
+
+	return &Builder{
+		kind:      config.Kind,
+		interval:  config.Interval,
+		threshold: config.Threshold,
+		exclude:   exclude,
+		delay:     config.Delay,
+		maxEmpty:  config.MaxEmptyBars,
+		symbols:   make(map[string]*state),
+		bars:      make(chan Bar, config.EventBuffer),
+	}, nil
+}
+
+// Bars returns the channel of closed bars.
+func (b *Builder) Bars() <-chan Bar {
+	return b.bars
+}
+
+// Stats returns a snapshot of the builder's counters.
+func (b *Builder) Stats() Stats {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.stats
+}
+
+// Start runs a clock that closes time bars as their windows end. It does nothing for
+// other kinds of bar, or if the clock was already started.
+func (b *Builder) Start() {
+	if b.kind != Time {
+		return
+	}
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if b.started {
+		return
+	}
+	b.started = true
+	b.t.Go(func() error {
+		ticker := time.NewTicker(min(b.interval/10, time.Second))
+		defer ticker.Stop()
+		for {
+			select {
+			case <-b.t.Dying():
+				return nil
+			case now := <-ticker.C:
+				b.Advance(now)
+			}
+		}
+	})
+}
+
+// Close stops the clock started by Start.
+func (b *Builder) Close() error {
+	b.mtx.Lock()
+	started := b.started
+	b.mtx.Unlock()
+	if !started {
+		return nil
+	}
+	b.t.Kill(nil)
+	return b.t.Wait()
+}
+
+// Advance closes every time bar whose window, plus the delay, ended at or before now.
+// Symbols that have traded before get an empty bar for every window where nothing
+// traded, up to MaxEmptyBars in a row, after which they're forgotten. It returns the
+// bars that were closed.
+func (b *Builder) Advance(now time.Time) []Bar {
+	if b.kind != Time {
+		return nil
+	}
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	syms := make([]string, 0, len(b.symbols))
+	for sym := range b.symbols {
+		syms = append(syms, sym)
+	}
+	sort.Strings(syms)
+
+	var out []Bar
+	cutoff := now.Add(-b.delay)
+	for _, sym := range syms {
+		s := b.symbols[sym]
+		out = append(out, b.closeUntil(s, cutoff)...)
+		if s.bar.Start.IsZero() {
+			delete(b.symbols, sym)
+		}
+	}
+	return out
+}
+
+// Observe applies a trade or aggregate. It returns the bars it closed; they are also
+// pushed to the bar channel.
+func (b *Builder) Observe(msg any) []Bar {
+	switch m := msg.(type) {
+	case models.EquityTrade:
+		return b.trade(m.Symbol, m.Price, float64(m.Size), m.Conditions, m.Time())
+	case models.CryptoTrade:
+		return b.trade(m.Pair, m.Price, m.Size, m.Conditions, m.Time())
+	case models.EquityAgg:
+		if b.kind != Time {
+			return nil
+		}
+		return b.agg(m)
+	case models.Trade:
+		return b.trade(m.GetSymbol(), m.GetPrice(), m.GetSize(), nil, m.Time())
+	}
+	return nil
+}
+
+func (b *Builder) trade(sym string, price, size float64, conditions []int32, t time.Time) []Bar {
+	priced := !b.excluded(conditions)
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if !priced {
+		b.stats.Excluded++
+	}
+
+	s, out, ok := b.prepare(sym, t)
+	if !ok {
+		return nil
+	}
+
+	if priced {
+		s.price(price, price, price, price)
+		s.notional += price * size
+		s.pricedVolume += size
+	}
+	if b.kind != Time {
+		if s.bar.Trades == 0 {
+			s.bar.Start = t
+		}
+		s.bar.End = t
+	}
+	s.bar.Volume += size
+	s.bar.Trades++
+	s.value += price * size
+	// an excluded trade has no price, so it only opens the bar if there's a previous
+	// close to price it with
+	s.open = s.priced || s.last != 0
+	if !s.open {
+		return out
+	}
+
+	var reached bool
+	switch b.kind {
+	case Tick:
+		reached = float64(s.bar.Trades) >= b.threshold
+	case Volume:
+		reached = s.bar.Volume >= b.threshold
+	case Dollar:
+		reached = s.value >= b.threshold
+	}
+	if reached {
+		out = append(out, b.emit(s))
+	}
+	return out
+}
+
+func (b *Builder) agg(m models.EquityAgg) []Bar {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	start := m.StartTime()
+	if start.Truncate(b.interval).Add(b.interval).Before(aggEnd(m)) {
+		b.stats.Rejected++
+		return nil
+	}
+
+	s, out, ok := b.prepare(m.Symbol, start)
+	if !ok {
+		return nil
+	}
+
+	s.price(m.Open, m.High, m.Low, m.Close)
+	s.bar.Volume += m.Volume
+	s.notional += m.VWAP * m.Volume
+	s.pricedVolume += m.Volume
+	s.open = true
+	return out
+}
+
+// prepare returns the state for a symbol, closing any time bars that end before t.
+// It reports false if t belongs to a window that was already emitted.
+func (b *Builder) prepare(sym string, t time.Time) (*state, []Bar, bool) {
+	s, ok := b.symbols[sym]
+	if !ok {
+		s = &state{bar: Bar{Symbol: sym}}
+		b.symbols[sym] = s
+	}
+	if b.kind != Time {
+		return s, nil, true
+	}
+
+	if !s.closed.IsZero() && t.Before(s.closed) {
+		b.stats.Late++
+		return s, nil, false
+	}
+	out := b.closeUntil(s, t)
+	if s.bar.Start.IsZero() {
+		s.bar.Start = t.Truncate(b.interval)
+		s.bar.End = s.bar.Start.Add(b.interval)
+	}
+	return s, out, true
+}
+
+// closeUntil emits the bar in progress and any empty bars after it, for every window
+// that ends at or before t. A symbol that is idle, i.e. has nothing to carry forward or
+// has already had MaxEmptyBars empty bars, is left without a window. Excluded trades
+// waiting for a price keep the window until it ends.
+func (b *Builder) closeUntil(s *state, t time.Time) []Bar {
+	var out []Bar
+	for !s.bar.Start.IsZero() && !s.bar.End.After(t) {
+		if !s.open && (s.last == 0 || s.empty >= b.maxEmpty) {
+			s.reset() // drop excluded trades that never got a price
+			break
+		}
+		end := s.bar.End
+		out = append(out, b.emit(s))
+		s.closed = end
+		s.bar.Start = end
+		s.bar.End = end.Add(b.interval)
+	}
+	if !s.open && s.bar.Trades == 0 && (s.last == 0 || s.empty >= b.maxEmpty) {
+		// wait for the next trade to open a window
+		s.bar.Start, s.bar.End = time.Time{}, time.Time{}
+	}
+	return out
+}
+
+// aggEnd returns the end of an aggregate's window. Aggregates without an end
+// timestamp are assumed to span a second (A) or a minute (AM).
+func aggEnd(m models.EquityAgg) time.Time {
+	if m.EndTimestamp > m.StartTimestamp {
+		return m.EndTime()
+	}
+	switch m.EventType.EventType {
+	case "A":
+		return m.StartTime().Add(time.Second)
+	case "AM":
+		return m.StartTime().Add(time.Minute)
+	}
+	return m.StartTime()
+}
+
+// emit closes the bar in progress for a symbol and pushes it to the bar channel.
+func (b *Builder) emit(s *state) Bar {
+	bar := s.bar
+	switch {
+	case !s.open:
+		bar.Empty = true
+		bar.Open, bar.High, bar.Low, bar.Close = s.last, s.last, s.last, s.last
+	case !s.priced:
+		// only excluded trades, so the price doesn't move
+		bar.Open, bar.High, bar.Low, bar.Close = s.last, s.last, s.last, s.last
+	}
+	if s.pricedVolume > 0 {
+		bar.VWAP = s.notional / s.pricedVolume
+	}
+	if bar.Close != 0 {
+		s.last = bar.Close
+	}
+	if bar.Empty {
+		s.empty++
+	} else {
+		s.empty = 0
+	}
+	s.reset()
+
+	b.stats.Bars++
+	select {
+	case b.bars <- bar:
+	default:
+		b.stats.Dropped++
+	}
+	return bar
+}
+
+func (b *Builder) excluded(conditions []int32) bool {
+	for _, c := range conditions {
+		if _, ok := b.exclude[c]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// price merges OHLC into the bar in progress.
+func (s *state) price(open, high, low, close float64) {
+	if !s.priced {
+		s.bar.Open, s.bar.High, s.bar.Low = open, high, low
+		s.priced = true
+	}
+	s.bar.High = max(s.bar.High, high)
+	s.bar.Low = min(s.bar.Low, low)
+	s.bar.Close = close
+}
+
+func (s *state) reset() {
+	s.bar = Bar{Symbol: s.bar.Symbol, Start: s.bar.Start, End: s.bar.End}
+	s.open, s.priced = false, false
+	s.notional, s.pricedVolume, s.value = 0, 0, 0
+}
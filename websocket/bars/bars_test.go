@@ -0,0 +1,272 @@
+package bars
+
+import (
+	"testing"
+	"time"
+
+	"github.com/massive-com/client-go/v3/websocket/models"
+	"github.com/stretchr/testify/assert"
+)
+
+var t0 = time.UnixMilli(1710253800000) // 2024-03-12 14:30 UTC
+
+func trade(sym string, price float64, size int64, at time.Duration, conditions ...int32) models.EquityTrade {
+	return models.EquityTrade{
+		EventType: models.EventType{EventType: "T"}, Symbol: sym, Price: price, Size: size,
+		Conditions: conditions, Timestamp: models.Millis(t0.Add(at)),
+	}
+}
+
+func TestNewValidation(t *testing.T) {
+	_, err := New(Config{Kind: Time})
+	assert.NotNil(t, err)
+	_, err = New(Config{Kind: Volume})
+	assert.NotNil(t, err)
+	_, err = New(Config{Kind: Kind(10), Threshold: 1})
+	assert.NotNil(t, err)
+	_, err = New(Config{Kind: Time, Interval: time.Second, Delay: -time.Second})
+	assert.NotNil(t, err)
+	_, err = New(Config{Kind: Time, Interval: 5 * time.Nanosecond})
+	assert.NotNil(t, err)
+}
+
+func TestTimeBars(t *testing.T) {
+	b, err := New(Config{Kind: Time, Interval: 5 * time.Second, ExcludeConditions: []int32{2}})
+	assert.Nil(t, err)
+
+	assert.Empty(t, b.Observe(trade("AAPL", 100, 10, 0)))
+	assert.Empty(t, b.Observe(trade("AAPL", 102, 10, time.Second)))
+	assert.Empty(t, b.Observe(trade("AAPL", 90, 100, 2*time.Second, 2))) // excluded from OHLC
+	assert.Empty(t, b.Observe(trade("AAPL", 99, 20, 4*time.Second)))
+
+	// a trade in a later window closes the bar and the empty window in between
+	bars := b.Observe(trade("AAPL", 101, 5, 12*time.Second))
+	assert.Equal(t, []Bar{
+		{
+			Symbol: "AAPL", Start: t0, End: t0.Add(5 * time.Second), Open: 100, High: 102, Low: 99, Close: 99,
+			Volume: 140, VWAP: (100*10 + 102*10 + 99*20) / 40.0, Trades: 4,
+		},
+		{
+			Symbol: "AAPL", Start: t0.Add(5 * time.Second), End: t0.Add(10 * time.Second),
+			Open: 99, High: 99, Low: 99, Close: 99, Empty: true,
+		},
+	}, bars)
+
+	// a late trade for an emitted window is dropped
+	assert.Empty(t, b.Observe(trade("AAPL", 50, 1, 3*time.Second)))
+
+	// the clock closes windows when nothing trades
+	bars = b.Advance(t0.Add(20 * time.Second))
+	assert.Len(t, bars, 2)
+	assert.Equal(t, Bar{
+		Symbol: "AAPL", Start: t0.Add(10 * time.Second), End: t0.Add(15 * time.Second),
+		Open: 101, High: 101, Low: 101, Close: 101, Volume: 5, VWAP: 101, Trades: 1,
+	}, bars[0])
+	assert.True(t, bars[1].Empty)
+	assert.Equal(t, 101.0, bars[1].Close)
+	assert.Empty(t, b.Advance(t0.Add(24*time.Second)))
+
+	assert.Equal(t, Stats{Bars: 4, Excluded: 1, Late: 1}, b.Stats())
+	assert.Len(t, b.Bars(), 4)
+}
+
+func TestTimeBarsDelay(t *testing.T) {
+	b, _ := New(Config{Kind: Time, Interval: time.Minute, Delay: 2 * time.Second})
+	b.Observe(trade("MSFT", 400, 1, 30*time.Second))
+
+	assert.Empty(t, b.Advance(t0.Add(time.Minute+time.Second)))
+	// inside the delay, a late trade still makes it into the bar
+	b.Observe(trade("MSFT", 401, 1, 59*time.Second))
+
+	bars := b.Advance(t0.Add(time.Minute + 2*time.Second))
+	assert.Len(t, bars, 1)
+	assert.Equal(t, 401.0, bars[0].Close)
+	assert.Equal(t, int64(2), bars[0].Trades)
+}
+
+func TestTimeBarsGap(t *testing.T) {
+	b, _ := New(Config{Kind: Time, Interval: time.Second, MaxEmptyBars: 3})
+	b.Observe(trade("AAPL", 100, 1, 0))
+	b.Observe(trade("MSFT", 400, 1, 0))
+
+	// a trade after a long gap only closes MaxEmptyBars empty bars
+	bars := b.Observe(trade("AAPL", 101, 1, 24*time.Hour))
+	assert.Len(t, bars, 4)
+	assert.False(t, bars[0].Empty)
+	for _, bar := range bars[1:] {
+		assert.True(t, bar.Empty)
+		assert.Equal(t, 100.0, bar.Close)
+	}
+
+	// the clock stops at MaxEmptyBars too and forgets idle symbols
+	bars = b.Advance(t0.Add(48 * time.Hour))
+	assert.Len(t, bars, 1+3+4)
+	assert.Empty(t, b.symbols)
+	assert.Empty(t, b.Advance(t0.Add(72*time.Hour)))
+
+	// a symbol that trades again starts over
+	assert.Empty(t, b.Observe(trade("MSFT", 401, 1, 72*time.Hour)))
+	bars = b.Advance(t0.Add(72*time.Hour + time.Second))
+	assert.Equal(t, []Bar{{
+		Symbol: "MSFT", Start: t0.Add(72 * time.Hour), End: t0.Add(72*time.Hour + time.Second),
+		Open: 401, High: 401, Low: 401, Close: 401, Volume: 1, VWAP: 401, Trades: 1,
+	}}, bars)
+
+	// the default cap bounds a gap of days
+	b, _ = New(Config{Kind: Time, Interval: time.Millisecond})
+	b.Observe(trade("AAPL", 100, 1, 0))
+	assert.Len(t, b.Advance(t0.Add(10*24*time.Hour)), 101)
+}
+
+func TestTimeBarsUpsample(t *testing.T) {
+	b, _ := New(Config{Kind: Time, Interval: 5 * time.Minute})
+
+	agg := func(start time.Duration, o, h, l, c, v, vw float64) models.EquityAgg {
+		return models.EquityAgg{
+			EventType: models.EventType{EventType: "AM"}, Symbol: "AAPL", Open: o, High: h, Low: l, Close: c,
+			Volume: v, VWAP: vw, StartTimestamp: models.Millis(t0.Add(start)), EndTimestamp: models.Millis(t0.Add(start + time.Minute)),
+		}
+	}
+	for i, a := range []models.EquityAgg{
+		agg(0, 10, 12, 9, 11, 100, 10.5),
+		agg(time.Minute, 11, 15, 11, 14, 300, 13),
+		agg(4*time.Minute, 14, 14, 8, 9, 100, 10),
+	} {
+		assert.Empty(t, b.Observe(a), i)
+	}
+
+	bars := b.Observe(agg(5*time.Minute, 9, 9, 9, 9, 1, 9))
+	assert.Equal(t, []Bar{{
+		Symbol: "AAPL", Start: t0, End: t0.Add(5 * time.Minute), Open: 10, High: 15, Low: 8, Close: 9,
+		Volume: 500, VWAP: (10.5*100 + 13*300 + 10*100) / 500,
+	}}, bars)
+}
+
+func TestTimeBarsUpsampleRejected(t *testing.T) {
+	b, _ := New(Config{Kind: Time, Interval: 30 * time.Second})
+
+	// minute aggregates don't fit in 30 second windows
+	assert.Empty(t, b.Observe(models.EquityAgg{
+		EventType: models.EventType{EventType: "AM"}, Symbol: "AAPL", Open: 1, High: 1, Low: 1, Close: 1,
+		StartTimestamp: models.Millis(t0), EndTimestamp: models.Millis(t0.Add(time.Minute)),
+	}))
+	// without an end timestamp the width comes from the event type
+	assert.Empty(t, b.Observe(models.EquityAgg{
+		EventType: models.EventType{EventType: "AM"}, Symbol: "AAPL", Open: 1, High: 1, Low: 1, Close: 1,
+		StartTimestamp: models.Millis(t0.Add(time.Minute)),
+	}))
+	assert.Empty(t, b.Advance(t0.Add(time.Hour)))
+	assert.Equal(t, Stats{Rejected: 2}, b.Stats())
+
+	// and neither do aggregates that straddle two windows
+	b, _ = New(Config{Kind: Time, Interval: 90 * time.Second})
+	assert.Empty(t, b.Observe(models.EquityAgg{
+		EventType: models.EventType{EventType: "AM"}, Symbol: "AAPL", Open: 1, High: 1, Low: 1, Close: 1,
+		StartTimestamp: models.Millis(t0.Add(time.Minute)), EndTimestamp: models.Millis(t0.Add(2 * time.Minute)),
+	}))
+	assert.Equal(t, uint64(1), b.Stats().Rejected)
+
+	// per-second aggregates fit
+	assert.Empty(t, b.Observe(models.EquityAgg{
+		EventType: models.EventType{EventType: "A"}, Symbol: "AAPL", Open: 1, High: 1, Low: 1, Close: 1,
+		StartTimestamp: models.Millis(t0.Add(time.Minute)), EndTimestamp: models.Millis(t0.Add(time.Minute + time.Second)),
+	}))
+	assert.Len(t, b.Advance(t0.Add(90*time.Second)), 1)
+}
+
+func TestTimeBarsExcludedFirst(t *testing.T) {
+	b, _ := New(Config{Kind: Time, Interval: 5 * time.Second, ExcludeConditions: []int32{2}})
+
+	// a window with only excluded trades and no previous close isn't emitted
+	assert.Empty(t, b.Observe(trade("AAPL", 90, 100, 0, 2)))
+	assert.Empty(t, b.Advance(t0.Add(time.Minute)))
+	assert.Empty(t, b.symbols)
+
+	// an excluded trade doesn't price the bar, but its volume counts once a trade
+	// that isn't excluded arrives
+	assert.Empty(t, b.Observe(trade("AAPL", 90, 100, time.Minute, 2)))
+	assert.Empty(t, b.Observe(trade("AAPL", 100, 10, time.Minute+time.Second)))
+	bars := b.Advance(t0.Add(time.Minute + 5*time.Second))
+	assert.Equal(t, []Bar{{
+		Symbol: "AAPL", Start: t0.Add(time.Minute), End: t0.Add(time.Minute + 5*time.Second),
+		Open: 100, High: 100, Low: 100, Close: 100, Volume: 110, VWAP: 100, Trades: 2,
+	}}, bars)
+
+	// tick bars wait for a price too
+	b, _ = New(Config{Kind: Tick, Threshold: 2, ExcludeConditions: []int32{2}})
+	assert.Empty(t, b.Observe(trade("AAPL", 90, 100, 0, 2)))
+	assert.Empty(t, b.Observe(trade("AAPL", 90, 100, time.Second, 2)))
+	bars = b.Observe(trade("AAPL", 100, 10, 2*time.Second))
+	assert.Equal(t, []Bar{{
+		Symbol: "AAPL", Start: t0, End: t0.Add(2 * time.Second),
+		Open: 100, High: 100, Low: 100, Close: 100, Volume: 210, VWAP: 100, Trades: 3,
+	}}, bars)
+}
+
+func TestTickBars(t *testing.T) {
+	b, _ := New(Config{Kind: Tick, Threshold: 3})
+	b.Observe(trade("AAPL", 1, 1, 0))
+	b.Observe(trade("AAPL", 3, 1, time.Second))
+	bars := b.Observe(trade("AAPL", 2, 1, 2*time.Second))
+	assert.Equal(t, []Bar{{
+		Symbol: "AAPL", Start: t0, End: t0.Add(2 * time.Second), Open: 1, High: 3, Low: 1, Close: 2,
+		Volume: 3, VWAP: 2, Trades: 3,
+	}}, bars)
+
+	b.Observe(trade("AAPL", 5, 1, 10*time.Second))
+	assert.Empty(t, b.Advance(t0.Add(time.Hour)))
+}
+
+func TestVolumeBars(t *testing.T) {
+	b, _ := New(Config{Kind: Volume, Threshold: 100})
+	b.Observe(models.CryptoTrade{Pair: "BTC-USD", Price: 10, Size: 60, Timestamp: models.Millis(t0)})
+	bars := b.Observe(models.CryptoTrade{Pair: "BTC-USD", Price: 11, Size: 60, Timestamp: models.Millis(t0.Add(time.Second))})
+	assert.Len(t, bars, 1)
+	assert.Equal(t, 120.0, bars[0].Volume)
+	assert.Equal(t, 11.0, bars[0].Close)
+
+	// the next bar starts from scratch
+	bars = b.Observe(models.CryptoTrade{Pair: "BTC-USD", Price: 12, Size: 50, Timestamp: models.Millis(t0.Add(2 * time.Second))})
+	assert.Empty(t, bars)
+}
+
+func TestDollarBars(t *testing.T) {
+	b, _ := New(Config{Kind: Dollar, Threshold: 1000})
+	fut := func(price float64, size int64) models.FuturesTrade {
+		return models.FuturesTrade{Symbol: "ESZ4", Price: price, Size: size, Timestamp: models.Millis(t0)}
+	}
+	assert.Empty(t, b.Observe(fut(100, 5)))
+	bars := b.Observe(fut(100, 5))
+	assert.Len(t, bars, 1)
+	assert.Equal(t, 10.0, bars[0].Volume)
+
+	// other messages are ignored
+	assert.Empty(t, b.Observe(models.EquityQuote{Symbol: "ESZ4"}))
+	assert.Empty(t, b.Observe(models.EquityAgg{Symbol: "ESZ4"}))
+}
+
+func TestBuilderStart(t *testing.T) {
+	b, _ := New(Config{Kind: Time, Interval: 50 * time.Millisecond})
+	b.Start()
+	b.Start() // starting again does nothing
+	defer b.Close()
+
+	b.Observe(models.FuturesTrade{Symbol: "ESZ4", Price: 100, Size: 1, Timestamp: models.Millis(time.Now())})
+	select {
+	case bar := <-b.Bars():
+		assert.Equal(t, 100.0, bar.Close)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for a bar")
+	}
+
+	// empty bars keep coming without trades
+	select {
+	case bar := <-b.Bars():
+		assert.True(t, bar.Empty)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for an empty bar")
+	}
+
+	assert.Nil(t, b.Close())
+	assert.Nil(t, b.Close())
+}
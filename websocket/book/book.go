@@ -0,0 +1,341 @@
+// Package book maintains local crypto level 2 order books from XL2 messages.
+package book
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/massive-com/client-go/v3/websocket/models"
+)
+
+// Level is a price level in a book.
+type Level struct {
+	Price float64
+	Size  float64
+}
+
+// Book is a snapshot of the order book of a pair on an exchange.
+type Book struct {
+	// Pair is the crypto pair (e.g. BTC-USD).
+	Pair string
+
+	// Exchange is the crypto exchange ID.
+	Exchange int32
+
+	// Bids are the bid levels from the highest price down.
+	Bids []Level
+
+	// Asks are the ask levels from the lowest price up.
+	Asks []Level
+
+	// Time is the timestamp of the last update.
+	Time time.Time
+}
+
+// BestBid returns the highest bid.
+func (b Book) BestBid() (Level, bool) {
+	if len(b.Bids) == 0 {
+		return Level{}, false
+	}
+	return b.Bids[0], true
+}
+
+// BestAsk returns the lowest ask.
+func (b Book) BestAsk() (Level, bool) {
+	if len(b.Asks) == 0 {
+		return Level{}, false
+	}
+	return b.Asks[0], true
+}
+
+// Depth returns up to n levels of each side. A negative n returns no levels.
+func (b Book) Depth(n int) (bids, asks []Level) {
+	return first(b.Bids, n), first(b.Asks, n)
+}
+
+// Mid returns the price halfway between the best bid and ask.
+func (b Book) Mid() (float64, bool) {
+	bid, ask, ok := b.top()
+	if !ok {
+		return 0, false
+	}
+	return (bid.Price + ask.Price) / 2, true
+}
+
+// Spread returns the difference between the best ask and bid.
+func (b Book) Spread() (float64, bool) {
+	bid, ask, ok := b.top()
+	if !ok {
+		return 0, false
+	}
+	return ask.Price - bid.Price, true
+}
+
+// BidSize returns the cumulative size of the top n bid levels, or zero for a negative n.
+func (b Book) BidSize(n int) float64 {
+	return cumulative(b.Bids, n)
+}
+
+// AskSize returns the cumulative size of the top n ask levels, or zero for a negative n.
+func (b Book) AskSize(n int) float64 {
+	return cumulative(b.Asks, n)
+}
+
+func (b Book) top() (Level, Level, bool) {
+	bid, ok := b.BestBid()
+	if !ok {
+		return Level{}, Level{}, false
+	}
+	ask, ok := b.BestAsk()
+	if !ok {
+		return Level{}, Level{}, false
+	}
+	return bid, ask, true
+}
+
+// first returns up to n levels, clamping n to the range of levels.
+func first(levels []Level, n int) []Level {
+	return levels[:max(0, min(n, len(levels)))]
+}
+
+func cumulative(levels []Level, n int) float64 {
+	var size float64
+	for _, l := range first(levels, n) {
+		size += l.Size
+	}
+	return size
+}
+
+// Event is a change to a book.
+type Event struct {
+	// Pair is the crypto pair of the book.
+	Pair string
+
+	// Exchange is the crypto exchange ID of the book.
+	Exchange int32
+
+	// BestBid and BestAsk are the top of the book after the change. They are zero if
+	// the side is empty.
+	BestBid Level
+	BestAsk Level
+
+	// TopChanged is true if the best bid or ask changed.
+	TopChanged bool
+
+	// Time is the timestamp of the update.
+	Time time.Time
+}
+
+// Config is a set of book options.
+type Config struct {
+	// Incremental is a flag indicating that XL2 messages only contain the levels that
+	// changed, where a size of zero removes a level. Omitting this treats every
+	// message as a snapshot of the sides it contains.
+	Incremental bool
+
+	// Depth is the maximum number of levels kept per side. Omitting this keeps 100
+	// levels, the maximum depth sent by the server.
+	Depth int
+
+	// EventBuffer is the size of the event channel. Events are dropped and counted
+	// when the channel is full. Omitting this uses a buffer of 1000 events.
+	EventBuffer int
+}
+
+// Books maintains an order book per pair and exchange. Feed it every message from
+// the client's output channel with Observe; anything other than XL2 is ignored.
+type Books struct {
+	incremental bool
+	depth       int
+
+	mtx     sync.Mutex
+	books   map[key]*Book
+	events  chan Event
+	dropped uint64
+}
+
+type key struct {
+	pair     string
+	exchange int32
+}
+
+// New creates an empty set of books.
+func New(config Config) *Books {
+	if config.Depth < 1 {
+		config.Depth = 100
+	}
+	if config.EventBuffer < 1 {
+		config.EventBuffer = 1000
+	}
+
+	return &Books{
+		incremental: config.Incremental,
+		depth:       config.Depth,
+		books:       make(map[key]*Book),
+		events:      make(chan Event, config.EventBuffer),
+	}
+}
+
+// Events returns the channel of book changes.
+func (b *Books) Events() <-chan Event {
+	return b.events
+}
+
+// Dropped returns the number of events that were dropped because the event channel
+// was full.
+func (b *Books) Dropped() uint64 {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	return b.dropped
+}
+
+// Book returns a snapshot of the book for a pair on an exchange.
+func (b *Books) Book(pair string, exchange int32) (Book, bool) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	book, ok := b.books[key{pair, exchange}]
+	if !ok {
+		return Book{}, false
+	}
+	return book.copy(), true
+}
+
+// Books returns a snapshot of every book for a pair, ordered by exchange.
+func (b *Books) Books(pair string) []Book {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	var books []Book
+	for k, book := range b.books {
+		if k.pair == pair {
+			books = append(books, book.copy())
+		}
+	}
+	sort.Slice(books, func(i, j int) bool { return books[i].Exchange < books[j].Exchange })
+	return books
+}
+
+// Reset clears every book, e.g. after a reconnect when incremental updates were missed.
+func (b *Books) Reset() {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.books = make(map[key]*Book)
+}
+
+// Observe applies an XL2 message to its book. It returns the change event and
+// whether the book changed; the event is also pushed to the event channel.
+func (b *Books) Observe(msg any) (Event, bool) {
+	m, ok := msg.(models.Level2Book)
+	if !ok {
+		return Event{}, false
+	}
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	k := key{m.Pair, m.ExchangeID}
+	book, ok := b.books[k]
+	if !ok {
+		book = &Book{Pair: m.Pair, Exchange: m.ExchangeID}
+		b.books[k] = book
+	}
+	bid, _ := book.BestBid()
+	ask, _ := book.BestAsk()
+
+	bidsChanged := b.apply(&book.Bids, m.BidPrices, func(a, b float64) bool { return a > b })
+	asksChanged := b.apply(&book.Asks, m.AskPrices, func(a, b float64) bool { return a < b })
+	if m.Timestamp > 0 {
+		book.Time = m.Time()
+	}
+	if !bidsChanged && !asksChanged {
+		return Event{}, false
+	}
+
+	ev := Event{Pair: m.Pair, Exchange: m.ExchangeID, Time: book.Time}
+	ev.BestBid, _ = book.BestBid()
+	ev.BestAsk, _ = book.BestAsk()
+	ev.TopChanged = ev.BestBid != bid || ev.BestAsk != ask
+
+	select {
+	case b.events <- ev:
+	default:
+		b.dropped++
+	}
+	return ev, true
+}
+
+// apply updates one side of a book with [price, size] pairs and reports whether it
+// changed. better reports whether a price ranks ahead of another on this side.
+func (b *Books) apply(side *[]Level, updates [][]float64, better func(a, b float64) bool) bool {
+	if !b.incremental {
+		if len(updates) == 0 {
+			return false // the side wasn't sent
+		}
+		levels := make([]Level, 0, len(updates))
+		for _, u := range updates {
+			if len(u) >= 2 && u[1] > 0 {
+				levels = append(levels, Level{Price: u[0], Size: u[1]})
+			}
+		}
+		sort.SliceStable(levels, func(i, j int) bool { return better(levels[i].Price, levels[j].Price) })
+		levels = levels[:min(b.depth, len(levels))]
+		if equal(*side, levels) {
+			return false
+		}
+		*side = levels
+		return true
+	}
+
+	changed := false
+	for _, u := range updates {
+		if len(u) < 2 {
+			continue
+		}
+		price, size := u[0], u[1]
+		levels := *side
+		i := sort.Search(len(levels), func(i int) bool { return !better(levels[i].Price, price) })
+		exists := i < len(levels) && levels[i].Price == price
+
+		switch {
+		case size <= 0 && exists:
+			*side = append(levels[:i], levels[i+1:]...)
+			changed = true
+		case size > 0 && exists:
+			if levels[i].Size != size {
+				levels[i].Size = size
+				changed = true
+			}
+		case size > 0:
+			levels = append(levels, Level{})
+			copy(levels[i+1:], levels[i:])
+			levels[i] = Level{Price: price, Size: size}
+			*side = levels
+			changed = true
+		}
+	}
+	if len(*side) > b.depth {
+		*side = (*side)[:b.depth]
+	}
+	return changed
+}
+
+func (book *Book) copy() Book {
+	out := *book
+	out.Bids = append([]Level(nil), book.Bids...)
+	out.Asks = append([]Level(nil), book.Asks...)
+	return out
+}
+
+func equal(a, b []Level) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,204 @@
+package book
+
+import (
+	"testing"
+	"time"
+
+	massivews "github.com/massive-com/client-go/v3/websocket"
+	"github.com/massive-com/client-go/v3/websocket/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func xl2(pair string, exchange int32, ts models.UnixMillis, bids, asks [][]float64) models.Level2Book {
+	return models.Level2Book{
+		EventType: models.EventType{EventType: "XL2"}, Pair: pair, ExchangeID: exchange,
+		Timestamp: ts, BidPrices: bids, AskPrices: asks,
+	}
+}
+
+//go:generate go run ../internal/capturegen xl2 testdata/crypto_xl2.capture.gz
+
+// replay feeds every message of a capture to the books. The capture in testdata is
+// synthetic, generated by internal/capturegen in the format of the live XL2 feed.
+func replay(t *testing.T, books *Books, path string) int {
+	t.Helper()
+	r, err := massivews.OpenReplayer(path, massivews.ReplayConfig{})
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	defer r.Close()
+
+	r.Start()
+	n := 0
+	for msg := range r.Output() {
+		books.Observe(msg)
+		n++
+	}
+	assert.Nil(t, r.Wait())
+	return n
+}
+
+func TestBooksCapture(t *testing.T) {
+	books := New(Config{EventBuffer: 1000})
+	n := replay(t, books, "testdata/crypto_xl2.capture.gz")
+	assert.Equal(t, 200, n)
+	assert.Len(t, books.Events(), 200)
+	assert.Zero(t, books.Dropped())
+
+	all := books.Books("BTC-USD")
+	if !assert.Len(t, all, 2) {
+		return
+	}
+	assert.Equal(t, int32(1), all[0].Exchange)
+	assert.Equal(t, int32(2), all[1].Exchange)
+
+	for _, pair := range []string{"BTC-USD", "ETH-USD"} {
+		for _, exchange := range []int32{1, 2} {
+			b, ok := books.Book(pair, exchange)
+			if !assert.True(t, ok) {
+				continue
+			}
+			assert.Len(t, b.Bids, 10)
+			assert.Len(t, b.Asks, 10)
+			assert.False(t, b.Time.IsZero())
+
+			// sides are sorted best first and never cross
+			for i := 1; i < len(b.Bids); i++ {
+				assert.Greater(t, b.Bids[i-1].Price, b.Bids[i].Price)
+				assert.Less(t, b.Asks[i-1].Price, b.Asks[i].Price)
+			}
+			bid, _ := b.BestBid()
+			ask, _ := b.BestAsk()
+			assert.Less(t, bid.Price, ask.Price)
+
+			spread, ok := b.Spread()
+			assert.True(t, ok)
+			assert.InDelta(t, ask.Price-bid.Price, spread, 1e-9)
+			mid, ok := b.Mid()
+			assert.True(t, ok)
+			assert.InDelta(t, (bid.Price+ask.Price)/2, mid, 1e-9)
+		}
+	}
+
+	_, ok := books.Book("BTC-USD", 3)
+	assert.False(t, ok)
+}
+
+func TestBookSnapshot(t *testing.T) {
+	books := New(Config{})
+
+	ev, ok := books.Observe(xl2("BTC-USD", 1, 1000,
+		[][]float64{{99, 2}, {100, 1}, {98, 3}},
+		[][]float64{{102, 4}, {101, 5}}))
+	assert.True(t, ok)
+	assert.Equal(t, Event{
+		Pair: "BTC-USD", Exchange: 1, BestBid: Level{100, 1}, BestAsk: Level{101, 5},
+		TopChanged: true, Time: time.UnixMilli(1000),
+	}, ev)
+
+	b, ok := books.Book("BTC-USD", 1)
+	assert.True(t, ok)
+	assert.Equal(t, []Level{{100, 1}, {99, 2}, {98, 3}}, b.Bids)
+	assert.Equal(t, []Level{{101, 5}, {102, 4}}, b.Asks)
+
+	bids, asks := b.Depth(2)
+	assert.Equal(t, []Level{{100, 1}, {99, 2}}, bids)
+	assert.Equal(t, []Level{{101, 5}, {102, 4}}, asks)
+	assert.Equal(t, 3.0, b.BidSize(2))
+	assert.Equal(t, 6.0, b.BidSize(10))
+	assert.Equal(t, 9.0, b.AskSize(2))
+
+	// negative depths are clamped to no levels
+	bids, asks = b.Depth(-1)
+	assert.Empty(t, bids)
+	assert.Empty(t, asks)
+	assert.Zero(t, b.BidSize(-1))
+	assert.Zero(t, b.AskSize(-3))
+
+	mid, _ := b.Mid()
+	assert.Equal(t, 100.5, mid)
+	spread, _ := b.Spread()
+	assert.Equal(t, 1.0, spread)
+
+	// a message with only one side replaces that side and keeps the other
+	ev, ok = books.Observe(xl2("BTC-USD", 1, 2000, nil, [][]float64{{101, 5}, {103, 1}}))
+	assert.True(t, ok)
+	assert.False(t, ev.TopChanged)
+	b, _ = books.Book("BTC-USD", 1)
+	assert.Len(t, b.Bids, 3)
+	assert.Equal(t, []Level{{101, 5}, {103, 1}}, b.Asks)
+
+	// an identical snapshot isn't a change
+	_, ok = books.Observe(xl2("BTC-USD", 1, 3000, nil, [][]float64{{101, 5}, {103, 1}}))
+	assert.False(t, ok)
+
+	// snapshots are copies
+	b.Bids[0].Size = 50
+	b, _ = books.Book("BTC-USD", 1)
+	assert.Equal(t, Level{100, 1}, b.Bids[0])
+
+	// other messages are ignored
+	_, ok = books.Observe(models.CryptoQuote{Pair: "BTC-USD"})
+	assert.False(t, ok)
+	assert.Len(t, books.Events(), 2)
+}
+
+func TestBookIncremental(t *testing.T) {
+	books := New(Config{Incremental: true, Depth: 3})
+
+	books.Observe(xl2("ETH-USD", 2, 1000,
+		[][]float64{{10, 1}, {9, 1}},
+		[][]float64{{11, 1}, {12, 1}}))
+
+	// insert a better bid, resize a level and remove the best ask
+	ev, ok := books.Observe(xl2("ETH-USD", 2, 2000,
+		[][]float64{{10.5, 2}, {9, 4}},
+		[][]float64{{11, 0}}))
+	assert.True(t, ok)
+	assert.True(t, ev.TopChanged)
+	assert.Equal(t, Level{10.5, 2}, ev.BestBid)
+	assert.Equal(t, Level{12, 1}, ev.BestAsk)
+
+	b, _ := books.Book("ETH-USD", 2)
+	assert.Equal(t, []Level{{10.5, 2}, {10, 1}, {9, 4}}, b.Bids)
+	assert.Equal(t, []Level{{12, 1}}, b.Asks)
+
+	// levels past the depth are trimmed
+	books.Observe(xl2("ETH-USD", 2, 3000, [][]float64{{10.75, 1}}, nil))
+	b, _ = books.Book("ETH-USD", 2)
+	assert.Equal(t, []Level{{10.75, 1}, {10.5, 2}, {10, 1}}, b.Bids)
+
+	// removing a missing level or repeating a size isn't a change
+	_, ok = books.Observe(xl2("ETH-USD", 2, 4000, [][]float64{{5, 0}, {10, 1}}, [][]float64{{13}}))
+	assert.False(t, ok)
+
+	// a bid-side change doesn't move the top of the book
+	ev, ok = books.Observe(xl2("ETH-USD", 2, 5000, [][]float64{{10, 0}}, nil))
+	assert.True(t, ok)
+	assert.False(t, ev.TopChanged)
+
+	books.Reset()
+	_, ok = books.Book("ETH-USD", 2)
+	assert.False(t, ok)
+}
+
+func TestBookEmpty(t *testing.T) {
+	var b Book
+	_, ok := b.BestBid()
+	assert.False(t, ok)
+	_, ok = b.Mid()
+	assert.False(t, ok)
+	_, ok = b.Spread()
+	assert.False(t, ok)
+	assert.Zero(t, b.BidSize(5))
+	bids, asks := b.Depth(5)
+	assert.Empty(t, bids)
+	assert.Empty(t, asks)
+}
+
+func TestBooksDropped(t *testing.T) {
+	books := New(Config{EventBuffer: 1})
+	books.Observe(xl2("BTC-USD", 1, 1000, [][]float64{{1, 1}}, nil))
+	books.Observe(xl2("BTC-USD", 1, 2000, [][]float64{{2, 1}}, nil))
+	assert.Equal(t, uint64(1), books.Dropped())
+}
@@ -4,9 +4,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
 	"net/url"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
@@ -18,12 +20,16 @@ import (
 )
 
 const (
-	writeWait      = 5 * time.Second
-	pongWait       = 30 * time.Second
-	pingPeriod     = pongWait - 5*time.Second // send ping 5 seconds before deadline
-	maxMessageSize = 1_000_000                // 1MB
+	defaultWriteWait      = 5 * time.Second
+	defaultPongWait       = 30 * time.Second
+	defaultMaxMessageSize = 1_000_000 // 1MB
 )
 
+// ErrReadLimitExceeded is pushed to the error channel when a message from the server
+// is larger than Config.MaxMessageSize. The client is closed instead of reconnecting,
+// since the server would likely send the same message again.
+var ErrReadLimitExceeded = errors.New("read limit exceeded")
+
 // Client defines a client to the Massive WebSocket API.
 type Client struct {
 	apiKey string
@@ -31,6 +37,15 @@ type Client struct {
 	market Market
 	url    string
 
+	dialer      *websocket.Dialer
+	header      http.Header
+	compression bool
+
+	writeWait      time.Duration
+	pongWait       time.Duration
+	pingPeriod     time.Duration
+	maxMessageSize int64
+
 	shouldClose bool
 	backoff     backoff.BackOff
 
@@ -49,7 +64,31 @@ type Client struct {
 	err                  chan error
 
 	reconnectCallback func(error)
+	gaps              *GapDetector
 	log               Logger
+
+	backfiller      Backfiller
+	backfillTimeout time.Duration
+	bQueue          chan BackfillRequest
+	bResult         chan backfillResult
+	backfilling     *backfillRun
+	dedup           *backfillDedup
+	lastRead        atomic.Int64
+	stats           *stats
+	watchdog        *watchdog
+	recorder        *Recorder
+
+	smtx    sync.Mutex
+	state   State
+	states  chan StateEvent
+	attempt int
+
+	authTimeout      time.Duration
+	awaitingAuth     atomic.Bool
+	authResult       chan error
+	subscribeTimeout time.Duration
+	amtx             sync.Mutex
+	acks             acks
 }
 
 // New creates a client for the Massive WebSocket API.
@@ -69,9 +108,35 @@ func New(config Config) (*Client, error) {
 		rawData:              config.RawData,
 		bypassRawDataRouting: config.BypassRawDataRouting,
 		output:               make(chan any, 100000),
-		err:                  make(chan error),
+		err:                  make(chan error, 1),
 		log:                  config.Log,
 		reconnectCallback:    config.ReconnectCallback,
+		gaps:                 config.GapDetector,
+		backfiller:           config.Backfiller,
+		backfillTimeout:      config.BackfillTimeout,
+		bQueue:               make(chan BackfillRequest, 1),
+		bResult:              make(chan backfillResult, 1),
+		stats:                newStats(),
+		watchdog:             newWatchdog(config),
+		recorder:             config.Recorder,
+		states:               make(chan StateEvent, 100),
+		authTimeout:          config.AuthTimeout,
+		authResult:           make(chan error, 1),
+		subscribeTimeout:     config.SubscribeTimeout,
+		acks:                 make(acks),
+		dialer:               newDialer(config),
+		header:               config.Header,
+		compression:          config.EnableCompression,
+		writeWait:            config.WriteWait,
+		pongWait:             config.PongWait,
+		pingPeriod:           config.PingPeriod,
+		maxMessageSize:       config.MaxMessageSize,
+	}
+
+	if c.recorder != nil {
+		if err := c.recorder.use(c.feed, c.market); err != nil {
+			return nil, fmt.Errorf("invalid client options: %w", err)
+		}
 	}
 
 	uri, err := url.Parse(string(c.feed))
@@ -90,7 +155,8 @@ func New(config Config) (*Client, error) {
 
 // Connect dials the WebSocket server and starts the read/write and process threads.
 // If any subscription messages are pushed before connecting, it will also send those
-// to the server.
+// to the server. If Config.AuthTimeout is set, it also waits for the server to accept
+// the API key; if authentication fails or times out, the client is closed.
 func (c *Client) Connect() error {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
@@ -99,24 +165,59 @@ func (c *Client) Connect() error {
 		return nil
 	}
 
+	if c.authTimeout > 0 {
+		c.awaitingAuth.Store(true)
+		defer func() {
+			c.awaitingAuth.Store(false)
+			select {
+			case <-c.authResult:
+			default:
+			}
+		}()
+	}
+
 	notify := func(err error, _ time.Duration) {
 		c.log.Errorf(err.Error())
 	}
+	c.attempt = 0
 	if err := backoff.RetryNotify(c.connect(false), c.backoff, notify); err != nil {
+		c.setState(Disconnected, err, 0)
 		return err
 	}
 
+	if c.authTimeout > 0 {
+		if err := c.waitForAuth(c.authTimeout); err != nil {
+			if errors.Is(err, ErrAckTimeout) {
+				c.close(false, err)
+			}
+			return err
+		}
+	}
+
 	return nil
 }
 
 // Subscribe sends a subscription message for a topic and set of tickers. If no
-// tickers are passed, it will subscribe to all tickers for a given topic.
+// tickers are passed, it will subscribe to all tickers for a given topic. If
+// Config.SubscribeTimeout is set and the client is connected, it also waits for the
+// server to acknowledge every ticker.
 func (c *Client) Subscribe(topic Topic, tickers ...string) error {
+	params, waiters, err := c.subscribe(topic, tickers...)
+	if err != nil || waiters == nil {
+		return err
+	}
+	return c.waitForAcks(params, waiters, c.subscribeTimeout)
+}
+
+func (c *Client) subscribe(topic Topic, tickers ...string) ([]string, []chan error, error) {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
 
 	if !c.market.supports(topic) {
-		return fmt.Errorf("topic '%v' not supported for market '%v'", topic.prefix(), c.market)
+		return nil, nil, fmt.Errorf("topic '%v' not supported for market '%v'", topic.prefix(), c.market)
+	}
+	if !c.feed.serves(c.market, topic) {
+		return nil, nil, fmt.Errorf("topic '%v' not served on feed '%v'", topic.prefix(), c.feed)
 	}
 
 	if len(tickers) == 0 || slices.Contains(tickers, "*") {
@@ -125,13 +226,25 @@ func (c *Client) Subscribe(topic Topic, tickers ...string) error {
 
 	subscribe, err := getSub(models.Subscribe, topic, tickers...)
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+
+	var params []string
+	var waiters []chan error
+	if c.subscribeTimeout > 0 && c.conn != nil {
+		for _, t := range tickers {
+			params = append(params, topic.prefix()+"."+t)
+		}
+		waiters = c.expectAcks(params)
 	}
 
 	c.subs.add(topic, tickers...)
+	if c.watchdog != nil {
+		c.watchdog.add(topic, time.Now())
+	}
 	c.wQueue <- subscribe
 
-	return nil
+	return params, waiters, nil
 }
 
 // Unsubscribe sends a message to unsubscribe from a topic and set of tickers. If no
@@ -154,6 +267,9 @@ func (c *Client) Unsubscribe(topic Topic, tickers ...string) error {
 	}
 
 	c.subs.delete(topic, tickers...)
+	if _, ok := c.subs[topic]; !ok && c.watchdog != nil {
+		c.watchdog.remove(topic)
+	}
 	c.wQueue <- unsubscribe
 
 	return nil
@@ -165,32 +281,54 @@ func (c *Client) Output() <-chan any {
 }
 
 // Error returns an error channel. If the client hits a fatal error (e.g. auth failed),
-// it will push an error to this channel and close the connection.
+// it will push an error to this channel and close the connection. The error is pushed
+// before the output channel is closed, so it can still be read once Output is drained.
 func (c *Client) Error() <-chan error {
 	return c.err
 }
 
 // Close attempts to gracefully close the connection to the server.
 func (c *Client) Close() {
+	c.shutdown(nil)
+}
+
+// shutdown closes the client because of reason, or because the user closed it if
+// reason is nil.
+func (c *Client) shutdown(reason error) {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
-	c.close(false)
+	c.close(false, reason)
 }
 
-func newConn(uri string) (*websocket.Conn, error) {
-	conn, res, err := websocket.DefaultDialer.Dial(uri, nil)
+// newDialer returns a copy of the configured dialer (or the default one) so that
+// client options don't modify a dialer owned by the user.
+func newDialer(config Config) *websocket.Dialer {
+	dialer := *websocket.DefaultDialer
+	if config.Dialer != nil {
+		dialer = *config.Dialer
+	}
+	if config.EnableCompression {
+		dialer.EnableCompression = true
+	}
+	return &dialer
+}
+
+func (c *Client) newConn() (*websocket.Conn, error) {
+	conn, res, err := c.dialer.Dial(c.url, c.header)
 	if err != nil {
 		return nil, fmt.Errorf("failed to dial server: %w", err)
 	} else if res.StatusCode != 101 {
 		return nil, errors.New("server failed to switch protocols")
 	}
 
-	conn.SetReadLimit(maxMessageSize)
-	if err := conn.SetReadDeadline(time.Now().Add(pongWait)); err != nil {
+	conn.EnableWriteCompression(c.compression)
+	conn.SetReadLimit(c.maxMessageSize)
+	if err := conn.SetReadDeadline(time.Now().Add(c.pongWait)); err != nil {
 		return nil, fmt.Errorf("failed to set read deadline: %w", err)
 	}
-	conn.SetPongHandler(func(string) error {
-		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(payload string) error {
+		c.pong(payload, time.Now())
+		return conn.SetReadDeadline(time.Now().Add(c.pongWait))
 	})
 
 	return conn, nil
@@ -198,15 +336,24 @@ func newConn(uri string) (*websocket.Conn, error) {
 
 func (c *Client) connect(reconnect bool) func() error {
 	return func() error {
+		c.attempt++
+		if reconnect {
+			c.setState(Reconnecting, nil, c.attempt)
+		} else {
+			c.setState(Connecting, nil, c.attempt)
+		}
+
 		// dial the server
-		conn, err := newConn(c.url)
+		conn, err := c.newConn()
 		if err != nil {
 			return err
 		}
 		c.conn = conn
 
 		// reset write queue and push auth message
-		c.wQueue = make(chan json.RawMessage, 1000)
+		for len(c.wQueue) > 0 {
+			<-c.wQueue
+		}
 		auth, err := json.Marshal(models.ControlMessage{
 			Action: models.Auth,
 			Params: c.apiKey,
@@ -222,10 +369,22 @@ func (c *Client) connect(reconnect bool) func() error {
 			c.wQueue <- msg
 		}
 
+		// queue a backfill of the outage before any live data can be read
+		if reconnect && c.backfiller != nil {
+			from := time.Now()
+			if last := c.lastRead.Load(); last > 0 {
+				from = time.Unix(0, last)
+			}
+			c.queueBackfill(from, time.Now())
+		}
+
 		// start the threads
 		c.rwtomb = tomb.Tomb{}
 		c.rwtomb.Go(c.read)
 		c.rwtomb.Go(c.write)
+		if c.watchdog != nil {
+			c.rwtomb.Go(c.watch)
+		}
 		if !reconnect {
 			c.ptomb = tomb.Tomb{}
 			c.ptomb.Go(c.process)
@@ -235,34 +394,66 @@ func (c *Client) connect(reconnect bool) func() error {
 	}
 }
 
+// reconnect redials the server after an unexpected disconnect. If the client can't
+// reconnect, it's closed and the error is pushed to the error channel.
 func (c *Client) reconnect() {
 	c.mtx.Lock()
 	defer c.mtx.Unlock()
 
+	if err := c.redial(); err != nil {
+		c.log.Errorf(err.Error())
+		c.report(err)
+		c.close(false, err)
+	}
+}
+
+// redial replaces the connection, returning the error that should close the client
+// if it couldn't be replaced. The caller holds the lock.
+func (c *Client) redial() error {
 	if c.shouldClose {
-		return
+		return nil
+	}
+
+	// the server would send the same message again after reconnecting
+	if err := c.rwtomb.Err(); errors.Is(err, ErrReadLimitExceeded) {
+		return fmt.Errorf("%w: closing connection", err)
 	}
 
 	c.log.Debugf("unexpected disconnect: reconnecting")
-	c.close(true)
+	c.setState(Disconnected, c.close(true, nil), 0)
 
 	notify := func(err error, _ time.Duration) {
 		c.log.Errorf(err.Error())
+		c.stats.reconnectFailures.Add(1)
 		if c.reconnectCallback != nil {
 			c.reconnectCallback(err)
 		}
 	}
+	c.attempt = 0
 	err := backoff.RetryNotify(c.connect(true), c.backoff, notify)
 	if err != nil {
-		err = fmt.Errorf("error reconnecting: %w: closing connection", err)
-		c.log.Errorf(err.Error())
-		c.close(false)
-		c.err <- err
-	} else {
-		// Callback on success.
-		if c.reconnectCallback != nil {
-			c.reconnectCallback(nil)
-		}
+		return fmt.Errorf("error reconnecting: %w: closing connection", err)
+	}
+
+	c.stats.reconnects.Add(1)
+	if c.gaps != nil {
+		c.gaps.reconnected()
+	}
+	// Callback on success.
+	if c.reconnectCallback != nil {
+		c.reconnectCallback(nil)
+	}
+	return nil
+}
+
+// report pushes an error that closes the client to the error channel. It's sent
+// before the output channel is closed and never blocks, so the client can always
+// shut down; the channel holds one error and later ones are only logged.
+func (c *Client) report(err error) {
+	select {
+	case c.err <- err:
+	default:
+		c.log.Errorf("error channel is full, dropping error: %v", err)
 	}
 }
 
@@ -271,14 +462,19 @@ func (c *Client) closeOutput() {
 	c.log.Debugf("output channel closed")
 }
 
-func (c *Client) close(reconnect bool) {
+// close stops the threads and closes the connection. Unless reconnecting, it also
+// closes the output and moves the client to the Closed state with reason, which is
+// the only place that state is set. It returns the error that stopped the read/write
+// threads, if any.
+func (c *Client) close(reconnect bool, reason error) error {
 	if c.conn == nil {
-		return
+		return nil
 	}
 
 	c.rwtomb.Kill(nil)
-	if err := c.rwtomb.Wait(); err != nil {
-		c.log.Errorf("r/w threads closed: %v", err)
+	rwErr := c.rwtomb.Wait()
+	if rwErr != nil {
+		c.log.Errorf("r/w threads closed: %v", rwErr)
 	}
 
 	if !reconnect {
@@ -288,12 +484,15 @@ func (c *Client) close(reconnect bool) {
 		}
 		c.shouldClose = true
 		c.closeOutput()
+		c.setState(Closed, reason, 0)
 	}
 
 	if c.conn != nil {
 		_ = c.conn.Close()
 		c.conn = nil
 	}
+
+	return rwErr
 }
 
 func (c *Client) read() error {
@@ -310,21 +509,30 @@ func (c *Client) read() error {
 			if err != nil {
 				if websocket.IsCloseError(err, websocket.CloseNormalClosure) {
 					return nil
+				} else if errors.Is(err, websocket.ErrReadLimit) {
+					return fmt.Errorf("%w: message larger than %d bytes", ErrReadLimitExceeded, c.maxMessageSize)
 				} else if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure) {
 					return fmt.Errorf("connection closed unexpectedly: %w", err)
 				}
 				return fmt.Errorf("failed to read message: %w", err)
 			}
-			if err := c.conn.SetReadDeadline(time.Now().Add(pongWait)); err != nil {
+			if err := c.conn.SetReadDeadline(time.Now().Add(c.pongWait)); err != nil {
 				return fmt.Errorf("failed to set read deadline: %w", err)
 			}
+			now := time.Now()
+			c.lastRead.Store(now.UnixNano())
+			if c.recorder != nil {
+				if err := c.recorder.record(now, msg); err != nil {
+					c.log.Errorf("recording stopped: %v", err)
+				}
+			}
 			c.rQueue <- msg
 		}
 	}
 }
 
 func (c *Client) write() error {
-	ticker := time.NewTicker(pingPeriod)
+	ticker := time.NewTicker(c.pingPeriod)
 	defer func() {
 		c.log.Debugf("write thread closed")
 		ticker.Stop()
@@ -334,16 +542,16 @@ func (c *Client) write() error {
 	for {
 		select {
 		case <-c.rwtomb.Dying():
-			if err := c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(writeWait)); err != nil {
+			if err := c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(c.writeWait)); err != nil {
 				return fmt.Errorf("failed to gracefully close: %w", err)
 			}
 			return nil
 		case <-ticker.C:
-			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)); err != nil {
+			if err := c.conn.WriteControl(websocket.PingMessage, pingPayload(time.Now()), time.Now().Add(c.writeWait)); err != nil {
 				return fmt.Errorf("failed to send ping message: %w", err)
 			}
 		case msg := <-c.wQueue:
-			if err := c.conn.SetWriteDeadline(time.Now().Add(writeWait)); err != nil {
+			if err := c.conn.SetWriteDeadline(time.Now().Add(c.writeWait)); err != nil {
 				return fmt.Errorf("failed to set write deadline: %w", err)
 			}
 			if err := c.conn.WriteMessage(websocket.TextMessage, msg); err != nil {
@@ -358,50 +566,73 @@ func (c *Client) process() (err error) {
 		// this client should close if it hits a fatal error (e.g. auth failed)
 		c.log.Debugf("process thread closed")
 		if err != nil {
-			go c.Close()
-			c.err <- err
+			go c.shutdown(err)
+			if !c.notifyAuth(err) {
+				c.report(err)
+			}
 		}
 	}()
 
 	for {
+		// a pending backfill is always started before live data so that live messages
+		// it overlaps with are remembered
+		var bQueue <-chan BackfillRequest
+		if c.backfilling == nil {
+			bQueue = c.bQueue
+			select {
+			case req := <-bQueue:
+				c.startBackfill(req)
+				continue
+			default:
+			}
+		}
+
 		select {
 		case <-c.ptomb.Dying():
 			return nil
+		case req := <-bQueue:
+			c.startBackfill(req)
+		case res := <-c.bResult:
+			c.finishBackfill(res)
 		case data := <-c.rQueue:
 			if c.rawData && c.bypassRawDataRouting {
-				c.output <- data // push raw bytes to output channel
+				c.send(data) // push raw bytes to output channel
 				continue
 			}
 
-			var msgs []json.RawMessage
-			if err := json.Unmarshal(data, &msgs); err != nil {
-				c.log.Errorf("failed to process raw messages: %v", err)
-				continue
-			}
-			if err := c.route(msgs); err != nil {
+			if err := c.route(data); err != nil {
 				return err
 			}
 		}
 	}
 }
 
-func (c *Client) route(msgs []json.RawMessage) error {
-	for _, msg := range msgs {
-		var ev models.EventType
-		err := json.Unmarshal(msg, &ev)
-		if err != nil {
-			c.log.Errorf("failed to process message: %v", err)
-			continue
+// route decodes a frame from the server and handles every message in it. Each
+// message is decoded once, after peeking its event type.
+func (c *Client) route(frame []byte) error {
+	var fatal error
+	err := eachMessage(frame, func(msg []byte) error {
+		ev, ok := eventType(msg)
+		if !ok {
+			c.log.Errorf("failed to process message: missing event type")
+			return nil
 		}
 
-		switch ev.EventType {
+		switch ev {
 		case "status":
-			if err := c.handleStatus(msg); err != nil {
-				return err
-			}
+			fatal = c.handleStatus(msg)
+			return fatal
 		default:
-			c.handleData(ev.EventType, msg)
+			c.stats.record(ev, len(msg), time.Now())
+			c.handleData(ev, msg)
 		}
+		return nil
+	})
+	if fatal != nil {
+		return fatal
+	}
+	if err != nil {
+		c.log.Errorf("failed to process raw messages: %v", err)
 	}
 
 	return nil
@@ -419,13 +650,20 @@ func (c *Client) handleStatus(msg json.RawMessage) error {
 		c.log.Debugf("connection successful")
 	case "auth_success":
 		c.log.Debugf("authentication successful")
+		c.setState(Authenticated, nil, 0)
+		c.notifyAuth(nil)
 	case "auth_failed":
 		// this is a fatal error so need to close the connection
 		return errors.New("authentication failed: closing connection")
 	case "success":
 		c.log.Debugf("received a successful status message: %v", sanitize(cm.Message))
+		if strings.HasPrefix(cm.Message, "subscribed to") && c.State() == Authenticated {
+			c.setState(Subscribed, nil, 0)
+		}
+		c.ack(cm.Message)
 	case "error":
 		c.log.Errorf("received an error status message: %v", sanitize(cm.Message))
+		c.nack(cm.Message)
 	default:
 		c.log.Infof("unknown status message '%v': %v", sanitize(cm.Status), sanitize(cm.Message))
 	}
@@ -435,232 +673,75 @@ func (c *Client) handleStatus(msg json.RawMessage) error {
 
 func (c *Client) handleData(eventType string, msg json.RawMessage) {
 	if c.rawData {
-		c.output <- msg // push raw JSON to output channel
+		c.send(msg) // push raw JSON to output channel
 		return
 	}
 
-	switch c.market {
-	case Stocks:
-		switch eventType {
-		case "A", "AM":
-			var out models.EquityAgg
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		case "T":
-			var out models.EquityTrade
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		case "Q":
-			var out models.EquityQuote
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		case "LULD":
-			var out models.LimitUpLimitDown
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		case "NOI":
-			var out models.Imbalance
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		case "FMV":
-			var out models.FairMarketValue
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		case "LV":
-			var out models.LaunchpadValue
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		default:
-			c.log.Infof("unknown message type '%s' for market %s", sanitize(eventType), c.market)
-		}
-	case Options:
-		switch eventType {
-		case "A", "AM":
-			var out models.EquityAgg
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		case "T":
-			var out models.EquityTrade
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		case "Q":
-			var out models.EquityQuote
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		case "FMV":
-			var out models.FairMarketValue
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		case "LV":
-			var out models.LaunchpadValue
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		default:
-			c.log.Infof("unknown message type '%s' for market %s", sanitize(eventType), c.market)
-		}
-	case Forex:
-		switch eventType {
-		case "CA", "CAS":
-			var out models.CurrencyAgg
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		case "C":
-			var out models.ForexQuote
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		case "FMV":
-			var out models.FairMarketValue
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		case "LV":
-			var out models.LaunchpadValue
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		default:
-			c.log.Infof("unknown message type '%s' for market %s", sanitize(eventType), c.market)
-		}
-	case Crypto:
-		switch eventType {
-		case "XA", "XAS":
-			var out models.CurrencyAgg
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		case "XT":
-			var out models.CryptoTrade
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		case "XQ":
-			var out models.CryptoQuote
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		case "XL2":
-			var out models.Level2Book
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		case "FMV":
-			var out models.FairMarketValue
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		case "LV":
-			var out models.LaunchpadValue
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		default:
-			c.log.Infof("unknown message type '%s' for market %s", sanitize(eventType), c.market)
-		}
-	case Indices:
-		switch eventType {
-		case "A", "AM":
-			var out models.EquityAgg
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		case "V":
-			var out models.IndexValue
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		default:
-			c.log.Infof("unknown message type '%s' for market %s", sanitize(eventType), c.market)
-		}
-	case Futures, FuturesCME, FuturesCBOT, FuturesNYMEX, FuturesCOMEX:
-		switch eventType {
-		case "A", "AM":
-			var out models.FuturesAggregate
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		case "T":
-			var out models.FuturesTrade
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		case "Q":
-			var out models.FuturesQuote
-			if err := json.Unmarshal(msg, &out); err != nil {
-				c.log.Errorf("failed to unmarshal message: %v", err)
-				return
-			}
-			c.output <- out
-		default:
-			c.log.Infof("unknown message type '%s' for market %s", sanitize(eventType), c.market)
+	decode, knownMarket := lookupEvent(c.market, eventType)
+	if !knownMarket {
+		// unknown markets accept every subscription, so their data is delivered
+		// undecoded rather than dropped
+		c.send(msg)
+		return
+	}
+	if decode == nil {
+		c.log.Infof("unknown message type '%s' for market %s", sanitize(eventType), c.market)
+		return
+	}
+
+	out, err := decode(msg)
+	if err != nil {
+		c.log.Errorf("failed to unmarshal message: %v", err)
+		return
+	}
+	c.push(eventType, out)
+}
+
+// push records the latency of a decoded message and sends it to the output channel.
+func (c *Client) push(eventType string, out any) {
+	if t, ok := messageTime(out); ok {
+		c.stats.latency(eventType, time.Since(t))
+	}
+	c.deliver(out)
+}
+
+// deliver sends a live message to the output channel unless it was already backfilled.
+// While a backfill is being fetched, the message is held back until the backfill
+// has been delivered.
+func (c *Client) deliver(out any) {
+	if c.dedup != nil {
+		duplicate, expired := c.dedup.check(out)
+		if expired {
+			c.dedup = nil
+		} else if duplicate {
+			return
 		}
-	default:
-		c.log.Infof("unknown market %s", c.market)
+	}
+	if c.backfilling != nil {
+		c.backfilling.hold(out)
+		return
+	}
+	c.emit(out)
+}
+
+// emit checks a message for sequence gaps and sends it to the output channel. It
+// gives up and returns false if the process thread is stopped while the channel is
+// full.
+func (c *Client) emit(out any) bool {
+	if c.gaps != nil {
+		c.gaps.Observe(out)
+	}
+	return c.send(out)
+}
+
+// send sends a message to the output channel. It gives up and returns false if the
+// process thread is stopped while the channel is full.
+func (c *Client) send(out any) bool {
+	select {
+	case c.output <- out:
+		return true
+	case <-c.ptomb.Dying():
+		return false
 	}
 }
 
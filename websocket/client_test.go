@@ -1,7 +1,7 @@
 package massivews
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -9,40 +9,14 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
-	"github.com/massive-com/client-go/v3/websocket/models"
+	"github.com/massive-com/client-go/v3/websocket/fakeserver"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 )
 
-func connect(w http.ResponseWriter, r *http.Request) {
-	upgrader := websocket.Upgrader{}
-	c, err := upgrader.Upgrade(w, r, nil)
-	if err != nil {
-		return
-	}
-	defer c.Close()
-
-	for {
-		mt, msg, err := c.ReadMessage()
-		if err != nil {
-			return
-		}
-
-		var cm models.ControlMessage
-		_ = json.Unmarshal(msg, &cm)
-		if cm.Action == "auth" && cm.Params == "good" {
-			res := []models.ControlMessage{{EventType: models.EventType{EventType: "status"}, Status: "auth_success"}}
-			data, _ := json.Marshal(res)
-			err = c.WriteMessage(mt, data)
-		} else {
-			res := []models.ControlMessage{{EventType: models.EventType{EventType: "status"}, Status: "auth_failed"}}
-			data, _ := json.Marshal(res)
-			err = c.WriteMessage(mt, data)
-		}
-		if err != nil {
-			return
-		}
-	}
+// newServer starts a fake server that accepts the "good" API key.
+func newServer() *fakeserver.Server {
+	return fakeserver.New(fakeserver.Config{APIKey: "good"})
 }
 
 func TestNew(t *testing.T) {
@@ -64,12 +38,12 @@ func TestNew(t *testing.T) {
 }
 
 func TestConnectAuthSuccess(t *testing.T) {
-	s := httptest.NewServer(http.HandlerFunc(connect))
+	s := newServer()
 	defer s.Close()
 
 	log := logrus.New()
 	log.SetLevel(logrus.DebugLevel)
-	u := "ws" + strings.TrimPrefix(s.URL, "http")
+	u := s.URL()
 	var retries uint64 = 0
 	c, err := New(Config{
 		APIKey:     "good",
@@ -102,12 +76,12 @@ func TestConnectAuthSuccess(t *testing.T) {
 }
 
 func TestConnectAuthFailure(t *testing.T) {
-	s := httptest.NewServer(http.HandlerFunc(connect))
+	s := newServer()
 	defer s.Close()
 
 	log := logrus.New()
 	log.SetLevel(logrus.DebugLevel)
-	u := "ws" + strings.TrimPrefix(s.URL, "http")
+	u := s.URL()
 	var retries uint64 = 0
 	c, err := New(Config{
 		APIKey:     "bad",
@@ -132,12 +106,12 @@ func TestConnectAuthFailure(t *testing.T) {
 }
 
 func TestConnectRetryFailure(t *testing.T) {
-	s := httptest.NewServer(http.HandlerFunc(connect))
+	s := newServer()
 	defer s.Close()
 
 	log := logrus.New()
 	log.SetLevel(logrus.DebugLevel)
-	u := "wss" + strings.TrimPrefix(s.URL, "http") // connecting to wss should fail
+	u := "wss" + strings.TrimPrefix(s.URL(), "ws") // connecting to wss should fail
 	var retries uint64 = 1
 	c, err := New(Config{
 		APIKey:     "bad",
@@ -154,13 +128,13 @@ func TestConnectRetryFailure(t *testing.T) {
 }
 
 func TestReconnectCallback(t *testing.T) {
-	s := httptest.NewServer(http.HandlerFunc(connect))
+	s := newServer()
 	defer s.Close()
 
 	reconnectCallbackCount := 0
 	log := logrus.New()
 	log.SetLevel(logrus.DebugLevel)
-	u := "ws" + strings.TrimPrefix(s.URL, "http")
+	u := s.URL()
 	var retries uint64 = 0
 	c, err := New(Config{
 		APIKey:     "good",
@@ -181,3 +155,92 @@ func TestReconnectCallback(t *testing.T) {
 	c.Close()
 	assert.Equal(t, 1, reconnectCallbackCount)
 }
+
+func TestDialerOptions(t *testing.T) {
+	headers := make(chan http.Header, 1)
+	fake := newServer()
+	defer fake.Close()
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers <- r.Header.Clone()
+		fake.ServeHTTP(w, r)
+	}))
+	defer s.Close()
+
+	dialer := &websocket.Dialer{HandshakeTimeout: 5 * time.Second}
+	u := "ws" + strings.TrimPrefix(s.URL, "http")
+	var retries uint64 = 0
+	c, err := New(Config{
+		APIKey:            "good",
+		Feed:              Feed(u),
+		Market:            Market(""),
+		MaxRetries:        &retries,
+		Dialer:            dialer,
+		Header:            http.Header{"User-Agent": []string{"my-app/1.0"}},
+		EnableCompression: true,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 5*time.Second, c.dialer.HandshakeTimeout)
+	assert.False(t, dialer.EnableCompression) // the user's dialer isn't modified
+
+	err = c.Connect()
+	assert.Nil(t, err)
+	defer c.Close()
+
+	h := <-headers
+	assert.Equal(t, "my-app/1.0", h.Get("User-Agent"))
+	assert.Contains(t, h.Get("Sec-Websocket-Extensions"), "permessage-deflate")
+}
+
+func TestTimingOptions(t *testing.T) {
+	c, err := New(Config{APIKey: "test", Feed: RealTime, Market: Stocks})
+	assert.Nil(t, err)
+	assert.Equal(t, 5*time.Second, c.writeWait)
+	assert.Equal(t, 30*time.Second, c.pongWait)
+	assert.Equal(t, 25*time.Second, c.pingPeriod)
+	assert.Equal(t, int64(1_000_000), c.maxMessageSize)
+
+	c, err = New(Config{APIKey: "test", Feed: RealTime, Market: Stocks, PongWait: 2 * time.Minute, MaxMessageSize: 10_000_000})
+	assert.Nil(t, err)
+	assert.Equal(t, 115*time.Second, c.pingPeriod)
+	assert.Equal(t, int64(10_000_000), c.maxMessageSize)
+
+	c, err = New(Config{APIKey: "test", Feed: RealTime, Market: Stocks, PongWait: 2 * time.Second})
+	assert.Nil(t, err)
+	assert.Equal(t, 1800*time.Millisecond, c.pingPeriod)
+
+	_, err = New(Config{APIKey: "test", PongWait: 10 * time.Second, PingPeriod: 10 * time.Second})
+	assert.NotNil(t, err)
+	_, err = New(Config{APIKey: "test", WriteWait: -time.Second})
+	assert.NotNil(t, err)
+}
+
+func TestReadLimitExceeded(t *testing.T) {
+	s := newServer()
+	defer s.Close()
+
+	u := s.URL()
+	var retries uint64 = 0
+	c, err := New(Config{
+		APIKey:         "good",
+		Feed:           Feed(u),
+		Market:         Crypto,
+		MaxRetries:     &retries,
+		MaxMessageSize: 100,
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, c.Connect())
+	defer c.Close()
+	assert.Eventually(t, func() bool { return s.Connections() == 1 }, time.Second, 10*time.Millisecond)
+	s.SendRaw([]byte(`[{"ev":"XL2","pair":"BTC-USD","b":[` + strings.Repeat("[1,1],", 100) + `[1,1]]}]`))
+
+	// the client is closed instead of reconnecting to the same message
+	err = <-c.Error()
+	assert.True(t, errors.Is(err, ErrReadLimitExceeded))
+	for ev := range c.StateChanges() {
+		assert.NotEqual(t, Reconnecting, ev.State)
+		if ev.State == Closed {
+			assert.True(t, errors.Is(ev.Reason, ErrReadLimitExceeded))
+			break
+		}
+	}
+}
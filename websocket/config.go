@@ -2,6 +2,10 @@ package massivews
 
 import (
 	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
 )
 
 // Config is a set of WebSocket client options.
@@ -36,6 +40,86 @@ type Config struct {
 	// if the reconnect attempt has failed and is being retried, and will be nil on reconnect success.
 	ReconnectCallback func(error)
 
+	// GapDetector is an optional sequence gap detector. If set, every decoded trade and
+	// quote is checked for gaps, out of order and duplicate sequence numbers, and the
+	// detector is told about reconnects so anomalies across them can be flagged.
+	GapDetector *GapDetector
+
+	// Backfiller is an optional source of the data that was missed while the client
+	// was disconnected. If set, after every successful reconnect the client fetches
+	// the outage window for each subscribed ticker (wildcards excluded) in the
+	// background. Live data keeps being read in the meantime but is held back, so the
+	// backfill is pushed to the output channel first, sorted by timestamp, and live
+	// delivery resumes once it's done (or has failed or timed out). Messages received
+	// both live and by the backfill are only delivered once. Backfilled messages
+	// aren't checked by the GapDetector.
+	Backfiller Backfiller
+
+	// BackfillTimeout is the maximum amount of time a backfill can take. Omitting this
+	// uses a timeout of 30 seconds.
+	BackfillTimeout time.Duration
+
+	// Dialer is an optional WebSocket dialer used to connect to the server. It can be
+	// used to go through a proxy, set a TLS config (e.g. to pin a CA) or set a handshake
+	// timeout. Omitting this uses websocket.DefaultDialer. The dialer is copied so it
+	// is safe to share between clients.
+	Dialer *websocket.Dialer
+
+	// Header is an optional set of HTTP headers sent with the opening handshake (e.g. a
+	// custom User-Agent).
+	Header http.Header
+
+	// EnableCompression is a flag indicating whether the client should negotiate
+	// per-message compression (RFC 7692) with the server and compress outgoing messages.
+	EnableCompression bool
+
+	// WriteWait is the time allowed to write a message to the server. Omitting this
+	// uses 5 seconds.
+	WriteWait time.Duration
+
+	// PongWait is the time allowed to read the next message or pong from the server
+	// before the connection is considered dead. Omitting this uses 30 seconds.
+	PongWait time.Duration
+
+	// PingPeriod is how often pings are sent to the server. It must be less than
+	// PongWait. Omitting this uses PongWait minus 5 seconds, or 90% of PongWait if
+	// PongWait is 5 seconds or less.
+	PingPeriod time.Duration
+
+	// MaxMessageSize is the maximum size in bytes of a message read from the server.
+	// A larger message closes the client and pushes ErrReadLimitExceeded to the error
+	// channel.
+	// Omitting this uses 1MB, which large XL2 snapshots or busy wildcard subscriptions
+	// can exceed.
+	MaxMessageSize int64
+
+	// AuthTimeout is how long Connect waits for the server to accept or reject the API
+	// key. Omitting this makes Connect return as soon as the connection is dialed, in
+	// which case an authentication failure is only reported through Client.Error.
+	AuthTimeout time.Duration
+
+	// SubscribeTimeout is how long Subscribe waits for the server to acknowledge every
+	// ticker when the client is connected. Omitting this makes Subscribe return as soon
+	// as the message is queued.
+	SubscribeTimeout time.Duration
+
+	// StaleThreshold enables the stale-feed watchdog. If a subscribed topic receives no
+	// data for this long while the connection is otherwise healthy, the client forces a
+	// reconnect. The disconnect is reported as a state change with ErrStaleFeed as the
+	// reason. Omitting this disables the watchdog.
+	StaleThreshold time.Duration
+
+	// MarketHours optionally limits the stale-feed watchdog to the times it reports as
+	// open (e.g. USMarketHours), since quiet topics are expected outside of trading
+	// hours. The threshold starts counting again when the market opens.
+	MarketHours func(time.Time) bool
+
+	// Recorder optionally records every frame read from the server to a capture that
+	// can be played back with a Replayer. The user owns the recorder and should close
+	// it after closing the client. A recorder can't be shared by clients of different
+	// feeds or markets.
+	Recorder *Recorder
+
 	// Log is an optional logger. Any logger implementation can be used as long as it
 	// implements the basic Logger interface. Omitting this will disable client logging.
 	Log Logger
@@ -50,6 +134,37 @@ func (c *Config) validate() error {
 		c.Log = &nopLogger{}
 	}
 
+	if c.WriteWait < 0 || c.PongWait < 0 || c.PingPeriod < 0 || c.MaxMessageSize < 0 {
+		return errors.New("timeouts and message size can't be negative")
+	}
+	if c.WriteWait == 0 {
+		c.WriteWait = defaultWriteWait
+	}
+	if c.PongWait == 0 {
+		c.PongWait = defaultPongWait
+	}
+	if c.PingPeriod == 0 {
+		// send ping 5 seconds before deadline
+		c.PingPeriod = c.PongWait - 5*time.Second
+		if c.PongWait <= 5*time.Second {
+			c.PingPeriod = c.PongWait * 9 / 10
+		}
+	}
+	if c.PingPeriod >= c.PongWait {
+		return errors.New("ping period must be less than pong wait")
+	}
+	if c.MaxMessageSize == 0 {
+		c.MaxMessageSize = defaultMaxMessageSize
+	}
+
+	if c.StaleThreshold < 0 {
+		return errors.New("stale threshold can't be negative")
+	}
+
+	if c.BackfillTimeout <= 0 {
+		c.BackfillTimeout = 30 * time.Second
+	}
+
 	return nil
 }
 
@@ -93,27 +208,16 @@ const (
 	FuturesCOMEX Market = "futures/comex"
 )
 
+// supports reports whether a topic exists in the market according to the topic
+// matrix, which is generated from the WebSocket spec. Unknown markets support every
+// topic and their messages are delivered as json.RawMessage, since there's no decoder
+// for them.
 func (m Market) supports(topic Topic) bool {
-	// FMV is supported for Stocks, Options, Forex, and Crypto but is not within the range
-	// so we need to make sure FMV is suppored if these markets are used.
-	isBusinessFairMarketValue := topic == BusinessFairMarketValue
-
-	switch m {
-	case Stocks:
-		return isBusinessFairMarketValue || (topic > stocksMin && topic < stocksMax)
-	case Options:
-		return isBusinessFairMarketValue || (topic > optionsMin && topic < optionsMax)
-	case Forex:
-		return isBusinessFairMarketValue || (topic > forexMin && topic < forexMax)
-	case Crypto:
-		return isBusinessFairMarketValue || (topic > cryptoMin && topic < cryptoMax)
-	case Indices:
-		return topic == IndexSecAggs || topic == IndexMinAggs || topic == IndexValue
-	case Futures, FuturesCME, FuturesCBOT, FuturesNYMEX, FuturesCOMEX:
-		return topic > futuresMin && topic < futuresMax
+	if !m.known() {
+		return true // assume user knows what they're doing if they use some unknown market
 	}
-
-	return true // assume user knows what they're doing if they use some unknown market
+	_, ok := lookupTopic(m, topic)
+	return ok
 }
 
 // Topic is the data type used to subscribe and retrieve data from the server.
@@ -164,6 +268,8 @@ const (
 	IndexMinAggs Topic = 91
 	IndexValue   Topic = 92
 
+	// BusinessFairMarketValue is only served on business feeds. Subscribing to it on
+	// any other feed returns an error.
 	BusinessFairMarketValue Topic = 100
 
 	futuresMin    Topic = 110
@@ -0,0 +1,215 @@
+package massivews
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// errUnexpectedEnd is returned when a frame ends in the middle of a value.
+var errUnexpectedEnd = errors.New("unexpected end of frame")
+
+// eachMessage calls fn with every message in a frame, which is a JSON array of
+// messages. Messages are slices of the frame, so nothing is copied, and the frame is
+// only scanned far enough to find where each message starts and ends. Decoding the
+// message itself is left to fn.
+func eachMessage(frame []byte, fn func(msg []byte) error) error {
+	i := skipSpace(frame, 0)
+	if i >= len(frame) || frame[i] != '[' {
+		return errors.New("frame is not an array")
+	}
+
+	for i++; ; i++ {
+		i = skipSpace(frame, i)
+		if i >= len(frame) {
+			return errUnexpectedEnd
+		}
+		if frame[i] == ']' {
+			return nil
+		}
+
+		end, err := skipValue(frame, i)
+		if err != nil {
+			return err
+		}
+		if err := fn(frame[i:end]); err != nil {
+			return err
+		}
+
+		i = skipSpace(frame, end)
+		if i >= len(frame) {
+			return errUnexpectedEnd
+		}
+		switch frame[i] {
+		case ',':
+		case ']':
+			return nil
+		default:
+			return fmt.Errorf("invalid character %q after message", frame[i])
+		}
+	}
+}
+
+// eventType peeks the "ev" field of a message without decoding the rest of it.
+func eventType(msg []byte) (string, bool) {
+	i := skipSpace(msg, 0)
+	if i >= len(msg) || msg[i] != '{' {
+		return "", false
+	}
+
+	for i++; ; i++ {
+		i = skipSpace(msg, i)
+		if i >= len(msg) || msg[i] != '"' {
+			return "", false
+		}
+		keyEnd, err := skipString(msg, i)
+		if err != nil {
+			return "", false
+		}
+		key := msg[i+1 : keyEnd-1]
+
+		i = skipSpace(msg, keyEnd)
+		if i >= len(msg) || msg[i] != ':' {
+			return "", false
+		}
+		i = skipSpace(msg, i+1)
+
+		valueEnd, err := skipValue(msg, i)
+		if err != nil {
+			return "", false
+		}
+		if string(key) == "ev" {
+			if msg[i] != '"' {
+				return "", false
+			}
+			value := msg[i+1 : valueEnd-1]
+			if bytes.IndexByte(value, '\\') >= 0 {
+				var ev string
+				if err := json.Unmarshal(msg[i:valueEnd], &ev); err != nil {
+					return "", false
+				}
+				return ev, true
+			}
+			return intern(value), true
+		}
+
+		i = skipSpace(msg, valueEnd)
+		if i >= len(msg) || msg[i] != ',' {
+			return "", false
+		}
+	}
+}
+
+// intern returns known event types as constants so peeking them doesn't allocate.
+func intern(ev []byte) string {
+	switch string(ev) {
+	case "status":
+		return "status"
+	case "T":
+		return "T"
+	case "Q":
+		return "Q"
+	case "A":
+		return "A"
+	case "AM":
+		return "AM"
+	case "NOI":
+		return "NOI"
+	case "LULD":
+		return "LULD"
+	case "LV":
+		return "LV"
+	case "FMV":
+		return "FMV"
+	case "C":
+		return "C"
+	case "CA":
+		return "CA"
+	case "CAS":
+		return "CAS"
+	case "XT":
+		return "XT"
+	case "XQ":
+		return "XQ"
+	case "XA":
+		return "XA"
+	case "XAS":
+		return "XAS"
+	case "XL2":
+		return "XL2"
+	case "V":
+		return "V"
+	}
+	return string(ev)
+}
+
+func skipSpace(data []byte, i int) int {
+	for i < len(data) {
+		switch data[i] {
+		case ' ', '\t', '\r', '\n':
+			i++
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// skipString returns the index after the string that starts at data[i].
+func skipString(data []byte, i int) (int, error) {
+	for i++; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		}
+	}
+	return 0, errUnexpectedEnd
+}
+
+// skipValue returns the index after the value that starts at data[i]. Objects and
+// arrays are matched by nesting depth only; their contents are validated when the
+// message is decoded.
+func skipValue(data []byte, i int) (int, error) {
+	if i >= len(data) {
+		return 0, errUnexpectedEnd
+	}
+
+	switch data[i] {
+	case '"':
+		return skipString(data, i)
+	case '{', '[':
+		depth := 0
+		for ; i < len(data); i++ {
+			switch data[i] {
+			case '"':
+				end, err := skipString(data, i)
+				if err != nil {
+					return 0, err
+				}
+				i = end - 1
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return i + 1, nil
+				}
+			}
+		}
+		return 0, errUnexpectedEnd
+	case ',', ']', '}', ':':
+		return 0, fmt.Errorf("invalid character %q looking for a value", data[i])
+	default:
+		// numbers and literals end at the next delimiter
+		for j := i; j < len(data); j++ {
+			switch data[j] {
+			case ',', ']', '}', ' ', '\t', '\r', '\n':
+				return j, nil
+			}
+		}
+		return len(data), nil
+	}
+}
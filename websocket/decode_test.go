@@ -0,0 +1,176 @@
+package massivews
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/massive-com/client-go/v3/websocket/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEachMessage(t *testing.T) {
+	frame := []byte(` [ {"ev":"T","c":[1,2]} , {"ev":"Q","m":"a ] \" }"},{"n":{"x":[{}]}}, 12 ]`)
+	var msgs []string
+	err := eachMessage(frame, func(msg []byte) error {
+		msgs = append(msgs, string(msg))
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{`{"ev":"T","c":[1,2]}`, `{"ev":"Q","m":"a ] \" }"}`, `{"n":{"x":[{}]}}`, `12`}, msgs)
+
+	// empty frames
+	assert.Nil(t, eachMessage([]byte(`[]`), func([]byte) error { t.Fatal("unexpected message"); return nil }))
+
+	// errors from the callback stop the scan
+	stop := errors.New("stop")
+	calls := 0
+	err = eachMessage([]byte(`[{},{}]`), func([]byte) error { calls++; return stop })
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, calls)
+
+	// malformed frames
+	for _, frame := range []string{``, `{}`, `[`, `[{}`, `[{"a":"b}]`, `[{} {}]`, `[,]`} {
+		assert.NotNil(t, eachMessage([]byte(frame), func([]byte) error { return nil }), frame)
+	}
+}
+
+func TestEventType(t *testing.T) {
+	for msg, expected := range map[string]string{
+		`{"ev":"T","sym":"AAPL"}`:                              "T",
+		`{ "sym" : "AAPL", "c":[1,{"ev":"x"}], "ev" : "XL2" }`: "XL2",
+		`{"ev":"status","status":"auth_success"}`:              "status",
+		`{"ev":"NEW"}`: "NEW",
+		`{"ev":"T"}`:   "T",
+	} {
+		ev, ok := eventType([]byte(msg))
+		assert.True(t, ok, msg)
+		assert.Equal(t, expected, ev, msg)
+	}
+
+	for _, msg := range []string{`{}`, `{"sym":"AAPL"}`, `{"ev":1}`, `[]`, `{"ev"}`} {
+		_, ok := eventType([]byte(msg))
+		assert.False(t, ok, msg)
+	}
+}
+
+//go:generate go run ./internal/capturegen stocks testdata/stocks_tq_burst.capture.gz
+
+// loadCapture reads the frames of a capture in testdata. The captures are synthetic,
+// generated by internal/capturegen in the format and units of the live feed.
+func loadCapture(tb testing.TB, name string) [][]byte {
+	f, err := os.Open("testdata/" + name)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	r := &captureReader{r: bufio.NewReader(gz)}
+	if _, _, err := r.next(); err != nil { // header
+		tb.Fatal(err)
+	}
+	var frames [][]byte
+	for {
+		_, frame, err := r.next()
+		if errors.Is(err, io.EOF) {
+			return frames
+		} else if err != nil {
+			tb.Fatal(err)
+		}
+		frames = append(frames, frame)
+	}
+}
+
+// routeThreePass is the previous decoder, which unmarshals every message three
+// times. It's kept to compare against.
+func (c *Client) routeThreePass(frame []byte) error {
+	var msgs []json.RawMessage
+	if err := json.Unmarshal(frame, &msgs); err != nil {
+		return err
+	}
+	for _, msg := range msgs {
+		var ev models.EventType
+		if err := json.Unmarshal(msg, &ev); err != nil {
+			continue
+		}
+		c.handleData(ev.EventType, msg)
+	}
+	return nil
+}
+
+func TestRouteCapture(t *testing.T) {
+	frames := loadCapture(t, "stocks_tq_burst.capture.gz")
+	c, err := New(Config{APIKey: "test", Feed: RealTime, Market: Stocks})
+	assert.Nil(t, err)
+	old, err := New(Config{APIKey: "test", Feed: RealTime, Market: Stocks})
+	assert.Nil(t, err)
+
+	// both decoders produce the same output
+	for _, frame := range frames {
+		assert.Nil(t, c.route(frame))
+		assert.Nil(t, old.routeThreePass(frame))
+	}
+	assert.Equal(t, len(old.output), len(c.output))
+	assert.Equal(t, 2000, len(c.output))
+	for len(c.output) > 0 {
+		assert.Equal(t, <-old.output, <-c.output)
+	}
+}
+
+func benchmarkRoute(b *testing.B, route func(c *Client, frame []byte) error) {
+	frames := loadCapture(b, "stocks_tq_burst.capture.gz")
+	c, err := New(Config{APIKey: "test", Feed: RealTime, Market: Stocks})
+	if err != nil {
+		b.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range c.output {
+		}
+	}()
+
+	var size int64
+	for _, frame := range frames {
+		size += int64(len(frame))
+	}
+	b.SetBytes(size)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, frame := range frames {
+			if err := route(c, frame); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	b.StopTimer()
+	close(c.output)
+	<-done
+}
+
+func BenchmarkRoute(b *testing.B) {
+	benchmarkRoute(b, (*Client).route)
+}
+
+func BenchmarkRouteThreePass(b *testing.B) {
+	benchmarkRoute(b, (*Client).routeThreePass)
+}
+
+func BenchmarkEventType(b *testing.B) {
+	msg := []byte(`{"ev":"Q","sym":"AAPL","bx":12,"bp":189.51,"bs":3,"ax":11,"ap":189.53,"as":2,"c":1,"t":1717425000000,"q":41200001,"z":3}`)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, ok := eventType(msg); !ok {
+			b.Fatal("no event type")
+		}
+	}
+}
@@ -0,0 +1,512 @@
+// Package fakeserver is an in-process implementation of the Massive WebSocket
+// protocol for testing clients offline. It handles authentication, subscriptions
+// with acknowledgements, wildcards, scripted and random data for every topic,
+// forced disconnects and slow consumers.
+package fakeserver
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/massive-com/client-go/v3/websocket/internal/spec"
+	"github.com/massive-com/client-go/v3/websocket/models"
+)
+
+// defaultSendBuffer is the number of frames that can be queued for a connection
+// before it's disconnected as a slow consumer.
+const defaultSendBuffer = 10000
+
+// defaultTickers are the tickers random data is generated for on wildcard subscriptions.
+var defaultTickers = map[string][]string{
+	"stocks":  {"AAPL", "MSFT"},
+	"options": {"O:AAPL250117C00150000"},
+	"forex":   {"EUR/USD"},
+	"crypto":  {"BTC-USD"},
+	"indices": {"I:SPX"},
+	"futures": {"ESZ5"},
+}
+
+// Config is a set of fake server options.
+type Config struct {
+	// APIKey is the API key that is accepted. Omitting this accepts any key.
+	APIKey string
+
+	// Authorize optionally decides whether a subscription param (e.g. "T.AAPL") is
+	// allowed. Rejected params get an error status instead of an acknowledgement.
+	Authorize func(param string) bool
+
+	// Unanswered optionally decides which control message params get no answer at
+	// all, e.g. an API key or a subscription param like "T.AAPL", to test clients
+	// that wait for acknowledgements.
+	Unanswered func(param string) bool
+
+	// RandomInterval is how often random data is sent for every subscription.
+	// Omitting this only sends data passed to Server.Send.
+	RandomInterval time.Duration
+
+	// Tickers are the tickers random data is generated for on wildcard subscriptions.
+	// Omitting this uses a couple of tickers per market.
+	Tickers []string
+
+	// Seed is the seed for random data.
+	Seed int64
+
+	// SendBuffer is the number of frames that can be waiting to be written to a
+	// connection. If a connection falls further behind, it's closed as a slow
+	// consumer. Omitting this uses 10000.
+	SendBuffer int
+
+	// WriteDelay is an optional delay before every frame is written, which can be
+	// used together with SendBuffer to simulate a slow consumer.
+	WriteDelay time.Duration
+}
+
+// Server is a fake Massive WebSocket server.
+type Server struct {
+	config Config
+	http   *httptest.Server
+
+	mtx   sync.Mutex
+	conns map[*conn]struct{}
+	rand  *rand.Rand
+	seq   map[string]int64
+
+	closeOnce sync.Once
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// New starts a fake server. Any path is accepted and the first path segment is used
+// as the market (e.g. ws://127.0.0.1:1234/stocks). Topics are checked against the
+// same topic matrix as the client, so business and exclusive Launchpad topics are
+// only served on the feeds returned by BusinessURL and LaunchpadURL.
+func New(config Config) *Server {
+	if config.SendBuffer <= 0 {
+		config.SendBuffer = defaultSendBuffer
+	}
+
+	s := &Server{
+		config: config,
+		conns:  make(map[*conn]struct{}),
+		rand:   rand.New(rand.NewSource(config.Seed)),
+		seq:    make(map[string]int64),
+		done:   make(chan struct{}),
+	}
+	s.http = httptest.NewServer(s)
+
+	if config.RandomInterval > 0 {
+		s.wg.Add(1)
+		go s.random()
+	}
+
+	return s
+}
+
+// URL returns the WebSocket URL of the server, which can be used as a client feed.
+func (s *Server) URL() string {
+	return "ws" + strings.TrimPrefix(s.http.URL, "http")
+}
+
+// BusinessURL returns the WebSocket URL of the server's business feed, which also
+// serves business topics such as FMV.
+func (s *Server) BusinessURL() string {
+	return s.URL() + "/business"
+}
+
+// LaunchpadURL returns the WebSocket URL of the server's Launchpad feed, which also
+// serves exclusive Launchpad topics such as LV.
+func (s *Server) LaunchpadURL() string {
+	return s.URL() + "/launchpad"
+}
+
+// Close disconnects every client and stops the server. Closing it again does
+// nothing.
+func (s *Server) Close() {
+	s.closeOnce.Do(func() {
+		close(s.done)
+		s.wg.Wait()
+		s.Disconnect()
+		s.http.Close()
+	})
+}
+
+// Connections returns the number of connected clients.
+func (s *Server) Connections() int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return len(s.conns)
+}
+
+// Subscriptions returns the sorted subscription params (e.g. "T.AAPL") of every
+// connected client.
+func (s *Server) Subscriptions() []string {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	seen := make(map[string]struct{})
+	for c := range s.conns {
+		for _, p := range c.params() {
+			seen[p] = struct{}{}
+		}
+	}
+	params := make([]string, 0, len(seen))
+	for p := range seen {
+		params = append(params, p)
+	}
+	sort.Strings(params)
+	return params
+}
+
+// Send sends messages (e.g. models.EquityTrade) to every authenticated client that
+// is subscribed to their event type and ticker. Each message is sent in its own
+// frame.
+func (s *Server) Send(msgs ...any) error {
+	for _, msg := range msgs {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		ev, ticker, err := route(data)
+		if err != nil {
+			return err
+		}
+		frame := append(append([]byte{'['}, data...), ']')
+
+		for _, c := range s.connections() {
+			if c.subscribed(ev, ticker) {
+				c.send(frame)
+			}
+		}
+	}
+	return nil
+}
+
+// SendRaw sends a raw frame to every connected client regardless of subscriptions.
+func (s *Server) SendRaw(frame []byte) {
+	for _, c := range s.connections() {
+		c.send(frame)
+	}
+}
+
+// Disconnect abruptly drops every connection without a close message, which
+// clients see as an unexpected disconnect.
+func (s *Server) Disconnect() {
+	for _, c := range s.connections() {
+		c.close()
+	}
+}
+
+func (s *Server) connections() []*conn {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	conns := make([]*conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+// ServeHTTP upgrades a request to a WebSocket connection and serves it. The server
+// already listens at URL, but it can also be mounted in another server, e.g. one
+// that inspects or delays the handshake.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	upgrader := websocket.Upgrader{}
+	ws, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	kind, market := feed(r.URL.Path)
+	c := &conn{
+		ws:     ws,
+		kind:   kind,
+		market: market,
+		out:    make(chan []byte, s.config.SendBuffer),
+		subs:   make(map[string]map[string]struct{}),
+		done:   make(chan struct{}),
+		delay:  s.config.WriteDelay,
+	}
+
+	s.mtx.Lock()
+	s.conns[c] = struct{}{}
+	s.mtx.Unlock()
+	defer func() {
+		s.mtx.Lock()
+		delete(s.conns, c)
+		s.mtx.Unlock()
+		c.close()
+	}()
+
+	go c.write()
+	c.status("connected", "Connected Successfully")
+
+	for {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		var cm models.ControlMessage
+		if err := json.Unmarshal(data, &cm); err != nil {
+			c.status("error", "invalid message")
+			continue
+		}
+		s.handle(c, cm)
+	}
+}
+
+func (s *Server) handle(c *conn, cm models.ControlMessage) {
+	switch cm.Action {
+	case models.Auth:
+		if s.unanswered(cm.Params) {
+			return
+		}
+		if cm.Params == "" || (s.config.APIKey != "" && cm.Params != s.config.APIKey) {
+			c.status("auth_failed", "authentication failed")
+			return
+		}
+		c.mtx.Lock()
+		c.authenticated = true
+		c.mtx.Unlock()
+		c.status("auth_success", "authenticated")
+	case models.Subscribe, models.Unsubscribe:
+		if !c.isAuthenticated() {
+			c.status("error", "not authorized")
+			return
+		}
+		var res []models.ControlMessage
+		for _, p := range strings.Split(cm.Params, ",") {
+			p = strings.TrimSpace(p)
+			if s.unanswered(p) {
+				continue
+			}
+			res = append(res, s.subscribe(c, cm.Action, p))
+		}
+		if len(res) > 0 {
+			c.json(res)
+		}
+	default:
+		c.status("error", "unknown action: "+string(cm.Action))
+	}
+}
+
+func (s *Server) subscribe(c *conn, action models.Action, param string) models.ControlMessage {
+	ev, ticker, ok := strings.Cut(param, ".")
+	if !ok || ticker == "" || !c.supports(ev) {
+		return status("error", "invalid subscription: "+param)
+	}
+	if s.config.Authorize != nil && !s.config.Authorize(param) {
+		return status("error", "not authorized: "+param)
+	}
+
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if action == models.Unsubscribe {
+		delete(c.subs[ev], ticker)
+		if len(c.subs[ev]) == 0 {
+			delete(c.subs, ev)
+		}
+		return status("success", "unsubscribed to: "+param)
+	}
+
+	if c.subs[ev] == nil {
+		c.subs[ev] = make(map[string]struct{})
+	}
+	c.subs[ev][ticker] = struct{}{}
+	return status("success", "subscribed to: "+param)
+}
+
+func (s *Server) unanswered(param string) bool {
+	return s.config.Unanswered != nil && s.config.Unanswered(param)
+}
+
+// random sends random data for every subscription until the server is closed.
+func (s *Server) random() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.config.RandomInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case now := <-ticker.C:
+			for _, c := range s.connections() {
+				var msgs []any
+				for _, p := range c.params() {
+					ev, sym, _ := strings.Cut(p, ".")
+					tickers := []string{sym}
+					if sym == "*" {
+						tickers = s.config.Tickers
+						if len(tickers) == 0 {
+							tickers = defaultTickers[c.market]
+						}
+					}
+					for _, t := range tickers {
+						if msg := s.generate(c.market, ev, t, now); msg != nil {
+							msgs = append(msgs, msg)
+						}
+					}
+				}
+				if len(msgs) > 0 {
+					c.json(msgs)
+				}
+			}
+		}
+	}
+}
+
+// feed returns the kind of feed and the market of a request path, e.g.
+// /business/stocks.
+func feed(path string) (spec.Kind, string) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	kind := spec.KindMarket
+	if len(segments) > 1 {
+		switch segments[0] {
+		case "business":
+			kind, segments = spec.KindBusiness, segments[1:]
+		case "launchpad":
+			kind, segments = spec.KindLaunchpad, segments[1:]
+		}
+	}
+	return kind, segments[0]
+}
+
+// route returns the event type and ticker of an encoded message.
+func route(data []byte) (string, string, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return "", "", err
+	}
+
+	var ev string
+	if err := json.Unmarshal(fields["ev"], &ev); err != nil || ev == "" {
+		return "", "", errors.New("message has no event type")
+	}
+	for _, key := range []string{"sym", "pair", "T", "p"} {
+		var ticker string
+		if json.Unmarshal(fields[key], &ticker) == nil && ticker != "" {
+			return ev, ticker, nil
+		}
+	}
+	return "", "", errors.New("message has no ticker")
+}
+
+func status(s, msg string) models.ControlMessage {
+	return models.ControlMessage{EventType: models.EventType{EventType: "status"}, Status: s, Message: msg}
+}
+
+// conn is a client connection.
+type conn struct {
+	ws     *websocket.Conn
+	kind   spec.Kind
+	market string
+	out    chan []byte
+	delay  time.Duration
+
+	mtx           sync.Mutex
+	authenticated bool
+	subs          map[string]map[string]struct{}
+
+	closeOnce sync.Once
+	done      chan struct{}
+}
+
+func (c *conn) write() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case frame := <-c.out:
+			if c.delay > 0 {
+				time.Sleep(c.delay)
+			}
+			if err := c.ws.WriteMessage(websocket.TextMessage, frame); err != nil {
+				c.close()
+				return
+			}
+		}
+	}
+}
+
+// send queues a frame, disconnecting the client if it isn't keeping up.
+func (c *conn) send(frame []byte) {
+	select {
+	case <-c.done:
+	case c.out <- frame:
+	default:
+		_ = c.ws.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "slow consumer"), time.Now().Add(time.Second))
+		c.close()
+	}
+}
+
+func (c *conn) json(v any) {
+	data, _ := json.Marshal(v)
+	c.send(data)
+}
+
+func (c *conn) status(s, msg string) {
+	c.json([]models.ControlMessage{status(s, msg)})
+}
+
+func (c *conn) close() {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		_ = c.ws.Close()
+	})
+}
+
+func (c *conn) isAuthenticated() bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.authenticated
+}
+
+func (c *conn) supports(ev string) bool {
+	if !spec.Known(c.market) {
+		return true // unknown markets accept any topic, like the client
+	}
+	for _, t := range spec.All {
+		if t.Market == c.market && t.EventType == ev && t.ServedOn(c.kind) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *conn) subscribed(ev, ticker string) bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if !c.authenticated {
+		return false
+	}
+	tickers := c.subs[ev]
+	_, all := tickers["*"]
+	_, one := tickers[ticker]
+	return all || one
+}
+
+func (c *conn) params() []string {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	var params []string
+	for ev, tickers := range c.subs {
+		for t := range tickers {
+			params = append(params, ev+"."+t)
+		}
+	}
+	return params
+}
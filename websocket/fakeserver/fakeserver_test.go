@@ -0,0 +1,312 @@
+package fakeserver_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	massivews "github.com/massive-com/client-go/v3/websocket"
+	"github.com/massive-com/client-go/v3/websocket/fakeserver"
+	"github.com/massive-com/client-go/v3/websocket/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func newClient(t *testing.T, s *fakeserver.Server, market massivews.Market, apiKey string) *massivews.Client {
+	return newFeedClient(t, s.URL(), market, apiKey)
+}
+
+func newFeedClient(t *testing.T, url string, market massivews.Market, apiKey string) *massivews.Client {
+	var retries uint64 = 1
+	c, err := massivews.New(massivews.Config{
+		APIKey:           apiKey,
+		Feed:             massivews.Feed(url),
+		Market:           market,
+		MaxRetries:       &retries,
+		AuthTimeout:      time.Second,
+		SubscribeTimeout: time.Second,
+	})
+	assert.Nil(t, err)
+	return c
+}
+
+func next(t *testing.T, c *massivews.Client) any {
+	select {
+	case out := <-c.Output():
+		return out
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for output")
+	}
+	return nil
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestAuth(t *testing.T) {
+	s := fakeserver.New(fakeserver.Config{APIKey: "good"})
+	defer s.Close()
+
+	c := newClient(t, s, massivews.Stocks, "bad")
+	err := c.Connect()
+	assert.NotNil(t, err)
+	assert.True(t, strings.Contains(err.Error(), "authentication failed"))
+
+	c = newClient(t, s, massivews.Stocks, "good")
+	assert.Nil(t, c.Connect())
+	defer c.Close()
+	assert.Equal(t, massivews.Authenticated, c.State())
+}
+
+func TestSubscribe(t *testing.T) {
+	s := fakeserver.New(fakeserver.Config{
+		Authorize: func(param string) bool { return !strings.HasSuffix(param, ".TSLA") },
+	})
+	defer s.Close()
+
+	c := newClient(t, s, massivews.Stocks, "key")
+	assert.Nil(t, c.Connect())
+	defer c.Close()
+
+	assert.Nil(t, c.Subscribe(massivews.StocksTrades, "AAPL", "MSFT"))
+	assert.Nil(t, c.Subscribe(massivews.StocksQuotes))
+	assert.NotNil(t, c.Subscribe(massivews.StocksTrades, "TSLA"))
+	assert.Equal(t, []string{"Q.*", "T.AAPL", "T.MSFT"}, s.Subscriptions())
+
+	assert.Nil(t, c.Unsubscribe(massivews.StocksTrades, "MSFT"))
+	waitFor(t, func() bool { return len(s.Subscriptions()) == 2 })
+
+	// only subscribed tickers get data, wildcards get everything
+	trade := models.EquityTrade{EventType: models.EventType{EventType: "T"}, Symbol: "MSFT", Price: 1}
+	quote := models.EquityQuote{EventType: models.EventType{EventType: "Q"}, Symbol: "MSFT", BidPrice: 1}
+	assert.Nil(t, s.Send(trade, quote))
+	assert.Equal(t, quote, next(t, c))
+	trade.Symbol = "AAPL"
+	assert.Nil(t, s.Send(trade))
+	assert.Equal(t, trade, next(t, c))
+}
+
+func TestRandomData(t *testing.T) {
+	s := fakeserver.New(fakeserver.Config{RandomInterval: 20 * time.Millisecond})
+	defer s.Close()
+
+	feeds := map[string]map[massivews.Market][]massivews.Topic{
+		s.URL(): {
+			massivews.Stocks:  {massivews.StocksSecAggs, massivews.StocksMinAggs, massivews.StocksTrades, massivews.StocksQuotes, massivews.StocksImbalances, massivews.StocksLULD},
+			massivews.Options: {massivews.OptionsSecAggs, massivews.OptionsMinAggs, massivews.OptionsTrades, massivews.OptionsQuotes},
+			massivews.Forex:   {massivews.ForexSecAggs, massivews.ForexMinAggs, massivews.ForexQuotes},
+			massivews.Crypto:  {massivews.CryptoSecAggs, massivews.CryptoMinAggs, massivews.CryptoTrades, massivews.CryptoQuotes, massivews.CryptoL2Book},
+			massivews.Indices: {massivews.IndexSecAggs, massivews.IndexMinAggs, massivews.IndexValue},
+			massivews.Futures: {massivews.FutureSecAggs, massivews.FutureMinAggs, massivews.FutureTrades, massivews.FutureQuotes},
+		},
+		s.BusinessURL(): {
+			massivews.Stocks: {massivews.StocksTrades, massivews.BusinessFairMarketValue},
+			massivews.Crypto: {massivews.BusinessFairMarketValue},
+		},
+		s.LaunchpadURL(): {
+			massivews.Stocks:  {massivews.StocksLaunchpadValue},
+			massivews.Options: {massivews.OptionsLaunchpadValue},
+			massivews.Forex:   {massivews.ForexLaunchpadValue},
+			massivews.Crypto:  {massivews.CryptoLaunchpadValue},
+		},
+	}
+
+	for url, markets := range feeds {
+		for market, topics := range markets {
+			c := newFeedClient(t, url, market, "key")
+			assert.Nil(t, c.Connect())
+			for _, topic := range topics {
+				assert.Nil(t, c.Subscribe(topic), "%v %v %v", url, market, topic)
+			}
+
+			// every topic produces data that the client can decode
+			types := make(map[string]struct{})
+			for len(types) < len(topics) {
+				out := next(t, c)
+				types[typeKey(out)] = struct{}{}
+			}
+			c.Close()
+		}
+	}
+}
+
+func TestFeedTopics(t *testing.T) {
+	s := fakeserver.New(fakeserver.Config{})
+	defer s.Close()
+
+	// business and exclusive launchpad topics are only served on their feeds
+	for url, want := range map[string][]string{
+		s.URL():          {"AM.AAPL", "T.AAPL"},
+		s.BusinessURL():  {"AM.AAPL", "FMV.AAPL", "T.AAPL"},
+		s.LaunchpadURL(): {"AM.AAPL", "LV.AAPL", "T.AAPL"},
+	} {
+		c := newFeedClient(t, url, massivews.Stocks, "key")
+		assert.Nil(t, c.Connect())
+		for _, topic := range []massivews.Topic{massivews.StocksTrades, massivews.BusinessFairMarketValue, massivews.StocksLaunchpadMinAggs, massivews.StocksLaunchpadValue} {
+			_ = c.Subscribe(topic, "AAPL")
+		}
+		assert.Equal(t, want, s.Subscriptions(), url)
+		c.Close()
+		waitFor(t, func() bool { return s.Connections() == 0 })
+	}
+}
+
+// typeKey identifies a decoded message by type and event type.
+func typeKey(out any) string {
+	switch m := out.(type) {
+	case models.FuturesAggregate:
+		return m.EventType
+	case models.EquityAgg:
+		return m.EventType.EventType
+	case models.CurrencyAgg:
+		return m.EventType.EventType
+	case models.EquityTrade:
+		return m.EventType.EventType
+	case models.EquityQuote:
+		return m.EventType.EventType
+	case models.Imbalance:
+		return m.EventType.EventType
+	case models.LimitUpLimitDown:
+		return m.EventType.EventType
+	case models.ForexQuote:
+		return m.EventType.EventType
+	case models.CryptoTrade:
+		return m.EventType.EventType
+	case models.CryptoQuote:
+		return m.EventType.EventType
+	case models.Level2Book:
+		return m.EventType.EventType
+	case models.IndexValue:
+		return m.EventType.EventType
+	case models.LaunchpadValue:
+		return m.EventType.EventType
+	case models.FairMarketValue:
+		return m.EventType.EventType
+	case models.FuturesTrade:
+		return m.EventType.EventType
+	case models.FuturesQuote:
+		return m.EventType.EventType
+	}
+	return ""
+}
+
+func TestDisconnect(t *testing.T) {
+	s := fakeserver.New(fakeserver.Config{})
+	defer s.Close()
+
+	reconnected := make(chan error, 1)
+	var retries uint64 = 3
+	c, err := massivews.New(massivews.Config{
+		APIKey:            "key",
+		Feed:              massivews.Feed(s.URL()),
+		Market:            massivews.Crypto,
+		MaxRetries:        &retries,
+		ReconnectCallback: func(err error) { reconnected <- err },
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, c.Subscribe(massivews.CryptoTrades, "BTC-USD"))
+	assert.Nil(t, c.Connect())
+	defer c.Close()
+	waitFor(t, func() bool { return len(s.Subscriptions()) == 1 })
+
+	// the client reconnects and resubscribes
+	s.Disconnect()
+	assert.Nil(t, <-reconnected)
+	waitFor(t, func() bool { return s.Connections() == 1 && len(s.Subscriptions()) == 1 })
+
+	trade := models.CryptoTrade{EventType: models.EventType{EventType: "XT"}, Pair: "BTC-USD", Price: 1}
+	assert.Nil(t, s.Send(trade))
+	assert.Equal(t, trade, next(t, c))
+}
+
+func TestSlowConsumer(t *testing.T) {
+	s := fakeserver.New(fakeserver.Config{SendBuffer: 1, WriteDelay: 50 * time.Millisecond})
+	defer s.Close()
+
+	c := newClient(t, s, massivews.Stocks, "key")
+	assert.Nil(t, c.Connect())
+	defer c.Close()
+	assert.Nil(t, c.Subscribe(massivews.StocksTrades))
+
+	// the send buffer overflows and the server drops the connection
+	trade := models.EquityTrade{EventType: models.EventType{EventType: "T"}, Symbol: "AAPL"}
+	for i := 0; i < 10; i++ {
+		assert.Nil(t, s.Send(trade))
+	}
+	for {
+		ev := <-c.StateChanges()
+		if ev.State == massivews.Disconnected {
+			assert.NotNil(t, ev.Reason)
+			break
+		}
+	}
+}
+
+func TestSendInvalid(t *testing.T) {
+	s := fakeserver.New(fakeserver.Config{})
+	defer s.Close()
+
+	assert.NotNil(t, s.Send(models.EquityTrade{Symbol: "AAPL"}))                              // no event type
+	assert.NotNil(t, s.Send(models.EquityTrade{EventType: models.EventType{EventType: "T"}})) // no ticker
+}
+
+func TestUnanswered(t *testing.T) {
+	s := fakeserver.New(fakeserver.Config{
+		Unanswered: func(param string) bool { return param == "silent" || param == "T.MSFT" },
+	})
+	defer s.Close()
+
+	c := newClient(t, s, massivews.Stocks, "silent")
+	c2 := newClient(t, s, massivews.Stocks, "key")
+	err := c.Connect()
+	assert.True(t, errors.Is(err, massivews.ErrAckTimeout))
+	c.Close()
+
+	assert.Nil(t, c2.Connect())
+	defer c2.Close()
+	err = c2.Subscribe(massivews.StocksTrades, "AAPL", "MSFT")
+	assert.True(t, errors.Is(err, massivews.ErrAckTimeout))
+	assert.Contains(t, err.Error(), "T.MSFT")
+}
+
+func TestServeHTTP(t *testing.T) {
+	s := fakeserver.New(fakeserver.Config{})
+	defer s.Close()
+
+	// the server can be mounted in another one
+	paths := make(chan string, 1)
+	outer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths <- r.URL.Path
+		s.ServeHTTP(w, r)
+	}))
+	defer outer.Close()
+
+	var retries uint64 = 0
+	c, err := massivews.New(massivews.Config{
+		APIKey:      "key",
+		Feed:        massivews.Feed("ws" + strings.TrimPrefix(outer.URL, "http")),
+		Market:      massivews.Stocks,
+		MaxRetries:  &retries,
+		AuthTimeout: time.Second,
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, c.Connect())
+	defer c.Close()
+	assert.Equal(t, "/stocks", <-paths)
+	assert.Equal(t, 1, s.Connections())
+}
+
+func TestCloseTwice(t *testing.T) {
+	s := fakeserver.New(fakeserver.Config{RandomInterval: time.Millisecond})
+	s.Close()
+	assert.NotPanics(t, s.Close)
+}
@@ -0,0 +1,94 @@
+package fakeserver
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/massive-com/client-go/v3/websocket/models"
+)
+
+// generate returns a random message for an event type and ticker in a market, or
+// nil if the event type isn't supported.
+func (s *Server) generate(market, ev, ticker string, now time.Time) any {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	key := ev + "." + ticker
+	s.seq[key]++
+	seq := s.seq[key]
+
+	ms := models.Millis(now)
+	price := math.Round((100+s.rand.Float64()*10)*100) / 100
+	spread := 0.01 * float64(1+s.rand.Intn(5))
+	size := int64(1 + s.rand.Intn(500))
+	event := models.EventType{EventType: ev}
+
+	switch ev {
+	case "A", "AM":
+		start := models.Millis(now.Truncate(time.Second))
+		end := start + 1000
+		if ev == "AM" {
+			start = models.Millis(now.Truncate(time.Minute))
+			end = start + 60000
+		}
+		if market == "futures" {
+			return models.FuturesAggregate{
+				EventType: ev, Symbol: ticker, Volume: float64(size), Open: price, Close: price + spread,
+				High: price + 2*spread, Low: price - spread, Transactions: seq, StartTimestamp: start, EndTimestamp: end,
+			}
+		}
+		return models.EquityAgg{
+			EventType: event, Symbol: ticker, Volume: float64(size), AccumulatedVolume: float64(seq * size),
+			Open: price, Close: price + spread, High: price + 2*spread, Low: price - spread,
+			VWAP: price, StartTimestamp: start, EndTimestamp: end,
+		}
+	case "CA", "CAS", "XA", "XAS":
+		start := models.Millis(now.Truncate(time.Minute))
+		end := start + 60000
+		if strings.HasSuffix(ev, "S") {
+			start = models.Millis(now.Truncate(time.Second))
+			end = start + 1000
+		}
+		return models.CurrencyAgg{
+			EventType: event, Pair: ticker, Open: price, Close: price + spread, High: price + 2*spread,
+			Low: price - spread, Volume: float64(size), VWAP: price, StartTimestamp: start, EndTimestamp: end,
+		}
+	case "T":
+		if market == "futures" {
+			return models.FuturesTrade{EventType: event, Symbol: ticker, Price: price, Size: size, Timestamp: ms, SequenceNumber: seq}
+		}
+		return models.EquityTrade{EventType: event, Symbol: ticker, Exchange: 4, ID: "fake", Price: price, Size: size, Timestamp: ms, SequenceNumber: seq}
+	case "Q":
+		if market == "futures" {
+			return models.FuturesQuote{EventType: event, Symbol: ticker, BidPrice: price, BidSize: size, AskPrice: price + spread, AskSize: size, Timestamp: ms}
+		}
+		return models.EquityQuote{
+			EventType: event, Symbol: ticker, BidExchangeID: 4, BidPrice: price, BidSize: int32(size),
+			AskExchangeID: 4, AskPrice: price + spread, AskSize: int32(size), Timestamp: ms, SequenceNumber: seq,
+		}
+	case "NOI":
+		return models.Imbalance{EventType: event, Symbol: ticker, Timestamp: ms, ImbalanceQuantity: int32(size), PairedQuantity: int32(size), BookClearingPrice: price}
+	case "LULD":
+		return models.LimitUpLimitDown{EventType: event, Symbol: ticker, HighPrice: price * 1.05, LowPrice: price * 0.95, Timestamp: ms, SequenceNumber: seq}
+	case "C":
+		return models.ForexQuote{EventType: event, Pair: ticker, ExchangeID: 48, AskPrice: price + spread, BidPrice: price, Timestamp: ms}
+	case "XT":
+		return models.CryptoTrade{EventType: event, Pair: ticker, Exchange: 1, ID: "fake", Price: price, Size: float64(size), Timestamp: ms}
+	case "XQ":
+		return models.CryptoQuote{EventType: event, Pair: ticker, BidPrice: price, BidSize: float64(size), AskPrice: price + spread, AskSize: float64(size), Timestamp: ms, ExchangeID: 1}
+	case "XL2":
+		return models.Level2Book{
+			EventType: event, Pair: ticker, ExchangeID: 1, Timestamp: ms,
+			BidPrices: [][]float64{{price, float64(size)}, {price - spread, float64(size)}},
+			AskPrices: [][]float64{{price + spread, float64(size)}, {price + 2*spread, float64(size)}},
+		}
+	case "V":
+		return models.IndexValue{EventType: event, Ticker: ticker, Value: price, Timestamp: ms}
+	case "LV":
+		return models.LaunchpadValue{EventType: event, Ticker: ticker, Value: price, Timestamp: models.Nanos(now)}
+	case "FMV":
+		return models.FairMarketValue{EventType: event, Ticker: ticker, FMV: price, Timestamp: models.Nanos(now)}
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+// Command capturegen generates the synthetic captures used by the package tests and
+// benchmarks. The captures have the format written by massivews.Recorder and the
+// messages follow the shapes and units of the live feed, but the data is made up
+// from a seeded random walk so that it can be regenerated exactly. It's run with go
+// generate from the packages whose tests use the captures.
+package main
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+)
+
+// generators are the captures that can be generated, by name.
+var generators = map[string]func(w *writer){
+	"stocks": stocksBurst,
+	"xl2":    cryptoL2,
+}
+
+func main() {
+	if len(os.Args) != 3 || generators[os.Args[1]] == nil {
+		log.Fatal("usage: capturegen stocks|xl2 <output>")
+	}
+
+	f, err := os.Create(os.Args[2])
+	if err != nil {
+		log.Fatal(err)
+	}
+	w := &writer{gz: gzip.NewWriter(f)}
+	generators[os.Args[1]](w)
+	if err := w.gz.Close(); err != nil {
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// writer writes the records of a capture.
+type writer struct {
+	gz *gzip.Writer
+}
+
+// header writes the capture header.
+func (w *writer) header(at time.Time, feed, market string) {
+	w.record(at, map[string]any{"version": 1, "feed": feed, "market": market})
+}
+
+// record writes a value as a JSON frame read at a given time.
+func (w *writer) record(at time.Time, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var prefix [12]byte
+	binary.BigEndian.PutUint64(prefix[:8], uint64(at.UnixNano()))
+	binary.BigEndian.PutUint32(prefix[8:], uint32(len(data)))
+	if _, err := w.gz.Write(prefix[:]); err != nil {
+		log.Fatal(err)
+	}
+	if _, err := w.gz.Write(data); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// round rounds a price to a number of decimals.
+func round(p float64, decimals int) float64 {
+	scale := math.Pow10(decimals)
+	return math.Round(p*scale) / scale
+}
+
+// stock is the state of the random walk of one stock.
+type stock struct {
+	symbol string
+	tape   int32
+	price  float64
+}
+
+// trade and quote have the fields of the T and Q events in the order the feed
+// sends them.
+type trade struct {
+	EventType  string  `json:"ev"`
+	Symbol     string  `json:"sym"`
+	ID         string  `json:"i"`
+	Exchange   int32   `json:"x"`
+	Price      float64 `json:"p"`
+	Size       int64   `json:"s"`
+	Timestamp  int64   `json:"t"`
+	Sequence   int64   `json:"q"`
+	Tape       int32   `json:"z"`
+	Conditions []int32 `json:"c,omitempty"`
+	TRFID      int64   `json:"trfi,omitempty"`
+	TRFTime    int64   `json:"trft,omitempty"`
+}
+
+type quote struct {
+	EventType   string  `json:"ev"`
+	Symbol      string  `json:"sym"`
+	BidExchange int32   `json:"bx"`
+	BidPrice    float64 `json:"bp"`
+	BidSize     int32   `json:"bs"`
+	AskExchange int32   `json:"ax"`
+	AskPrice    float64 `json:"ap"`
+	AskSize     int32   `json:"as"`
+	Condition   int32   `json:"c"`
+	Indicators  []int32 `json:"i,omitempty"`
+	Timestamp   int64   `json:"t"`
+	Sequence    int64   `json:"q"`
+	Tape        int32   `json:"z"`
+}
+
+// stocksBurst writes a burst of 2000 trades and quotes for a handful of busy
+// stocks right after the open, batched into frames the way the server sends them.
+func stocksBurst(w *writer) {
+	rnd := rand.New(rand.NewSource(38))
+	stocks := []*stock{
+		{"AAPL", 3, 194.12}, {"AMD", 3, 168.05}, {"AMZN", 3, 178.34}, {"MSFT", 3, 415.27},
+		{"NVDA", 3, 1124.18}, {"QQQ", 3, 451.92}, {"SPY", 2, 527.40}, {"TSLA", 3, 178.81},
+	}
+	exchanges := []int32{1, 4, 7, 8, 10, 11, 12, 15, 17, 19, 21}
+	sizes := []int64{1, 5, 10, 50, 100, 100, 100, 200, 300, 500}
+	conditions := [][]int32{nil, nil, nil, {12}, {14}, {37}, {14, 41}, {12, 37}, {37, 41}}
+
+	start := time.Date(2024, 6, 3, 13, 30, 0, 0, time.UTC) // 9:30 in New York
+	w.header(start, "wss://socket.massive.com", "stocks")
+
+	now := start
+	seq := int64(41200000)
+	for n := 0; n < 2000; {
+		now = now.Add(time.Duration(20+rnd.Intn(150)) * time.Millisecond)
+		size := min(2000-n, 10+rnd.Intn(60))
+		frame := make([]any, 0, size)
+		for i := 0; i < size; i++ {
+			s := stocks[rnd.Intn(len(stocks))]
+			s.price = round(s.price*(1+rnd.NormFloat64()*0.0002), 2)
+			// messages in a frame were published over the last few milliseconds
+			t := now.Add(-time.Duration(rnd.Intn(5)) * time.Millisecond).UnixMilli()
+			seq += 1 + int64(rnd.Intn(4))
+
+			if rnd.Intn(3) == 0 {
+				tr := trade{
+					EventType:  "T",
+					Symbol:     s.symbol,
+					ID:         strconv.FormatInt(1e13+rnd.Int63n(9e13), 10),
+					Exchange:   exchanges[rnd.Intn(len(exchanges))],
+					Price:      s.price,
+					Size:       sizes[rnd.Intn(len(sizes))],
+					Timestamp:  t,
+					Sequence:   seq,
+					Tape:       s.tape,
+					Conditions: conditions[rnd.Intn(len(conditions))],
+				}
+				if tr.Exchange == 4 {
+					// off-exchange trades carry the reporting facility and when it
+					// received the trade, in milliseconds like the trade timestamp
+					tr.TRFID = 201 + rnd.Int63n(3)
+					tr.TRFTime = t + rnd.Int63n(3)
+				}
+				frame = append(frame, tr)
+			} else {
+				q := quote{
+					EventType:   "Q",
+					Symbol:      s.symbol,
+					BidExchange: exchanges[rnd.Intn(len(exchanges))],
+					BidPrice:    s.price,
+					BidSize:     1 + rnd.Int31n(20),
+					AskExchange: exchanges[rnd.Intn(len(exchanges))],
+					AskPrice:    round(s.price+0.01*float64(1+rnd.Intn(3)), 2),
+					AskSize:     1 + rnd.Int31n(20),
+					Condition:   1,
+					Timestamp:   t,
+					Sequence:    seq,
+					Tape:        s.tape,
+				}
+				if rnd.Intn(20) == 0 {
+					q.Indicators = []int32{604}
+				}
+				frame = append(frame, q)
+			}
+		}
+		n += size
+		w.record(now.Add(time.Duration(rnd.Intn(2000))*time.Microsecond), frame)
+	}
+}
+
+// level2 has the fields of the XL2 event in the order the feed sends them.
+type level2 struct {
+	EventType string      `json:"ev"`
+	Pair      string      `json:"pair"`
+	Bids      [][]float64 `json:"b"`
+	Asks      [][]float64 `json:"a"`
+	Timestamp int64       `json:"t"`
+	Exchange  int32       `json:"x"`
+}
+
+// pairBook is the state of the random walk of one pair on one exchange.
+type pairBook struct {
+	pair     string
+	exchange int32
+	tick     float64
+	decimals int
+	mid      float64
+}
+
+// cryptoL2 writes 200 XL2 snapshots of the top 10 levels of BTC-USD and ETH-USD on
+// two exchanges. Spreads, gaps between levels and sizes vary from one snapshot to
+// the next, and every book has its own timestamps.
+func cryptoL2(w *writer) {
+	rnd := rand.New(rand.NewSource(41))
+	books := []*pairBook{
+		{"BTC-USD", 1, 0.01, 2, 68250.37}, {"BTC-USD", 2, 0.01, 2, 68251.12},
+		{"ETH-USD", 1, 0.01, 2, 3952.48}, {"ETH-USD", 2, 0.01, 2, 3952.61},
+	}
+
+	start := time.Date(2024, 3, 12, 14, 30, 0, 0, time.UTC)
+	w.header(start, "wss://socket.massive.com", "crypto")
+
+	// side builds levels away from a starting price, dir is -1 for bids and 1 for asks
+	side := func(b *pairBook, from float64, dir float64) [][]float64 {
+		levels := make([][]float64, 0, 10)
+		price := from
+		for i := 0; i < 10; i++ {
+			if i > 0 {
+				price += dir * b.tick * float64(1+rnd.Intn(40))
+			}
+			size := round(rnd.ExpFloat64()*0.8+0.0001, 8)
+			levels = append(levels, []float64{round(price, b.decimals), size})
+		}
+		return levels
+	}
+
+	now := start
+	for n := 0; n < 200; {
+		now = now.Add(time.Duration(50+rnd.Intn(250)) * time.Millisecond)
+		size := min(200-n, 1+rnd.Intn(7))
+		frame := make([]level2, 0, size)
+		for i := 0; i < size; i++ {
+			b := books[rnd.Intn(len(books))]
+			b.mid = b.mid * (1 + rnd.NormFloat64()*0.00005)
+			spread := b.tick * float64(1+rnd.Intn(60))
+			bid := math.Floor((b.mid-spread/2)/b.tick) * b.tick
+			ask := bid + spread
+			frame = append(frame, level2{
+				EventType: "XL2",
+				Pair:      b.pair,
+				Bids:      side(b, bid, -1),
+				Asks:      side(b, ask, 1),
+				Timestamp: now.Add(-time.Duration(rnd.Intn(40)) * time.Millisecond).UnixMilli(),
+				Exchange:  b.exchange,
+			})
+		}
+		n += size
+		w.record(now.Add(time.Duration(rnd.Intn(3000))*time.Microsecond), frame)
+	}
+}
@@ -0,0 +1,77 @@
+// Package spec is the topic matrix of the WebSocket API: which event types each
+// market serves and on which kinds of feed. It's generated from the WebSocket spec
+// and shared by the client and the fake server so that they agree on it.
+package spec
+
+//go:generate go run ../topicgen ../../../.massive/websocket.json topics.gen.go
+
+// Kind is the kind of feed a topic is served on.
+type Kind uint8
+
+const (
+	// KindMarket topics are served on the regular market data feeds.
+	KindMarket Kind = iota
+
+	// KindBusiness topics are served on the business feeds.
+	KindBusiness
+
+	// KindLaunchpad topics are served on the Launchpad feed.
+	KindLaunchpad
+)
+
+// Topic is an entry in the topic matrix: an event type served for a market (e.g.
+// "stocks") on a kind of feed. Exclusive topics are only served on feeds of that
+// kind.
+type Topic struct {
+	Market    string
+	EventType string
+	Kind      Kind
+	Exclusive bool
+}
+
+// ServedOn reports whether the topic is served on a kind of feed. Business topics
+// are only served on business feeds and exclusive Launchpad topics only on the
+// Launchpad feed.
+func (t Topic) ServedOn(kind Kind) bool {
+	switch t.Kind {
+	case KindBusiness:
+		return kind == KindBusiness
+	case KindLaunchpad:
+		return !t.Exclusive || kind == KindLaunchpad
+	}
+	return true
+}
+
+// Extra are topics the client supports that aren't in the spec yet. The spec has
+// no futures section, so every futures topic is listed here.
+var Extra = []Topic{
+	{Market: "futures", EventType: "AM", Kind: KindMarket},
+	{Market: "futures", EventType: "A", Kind: KindMarket},
+	{Market: "futures", EventType: "T", Kind: KindMarket},
+	{Market: "futures", EventType: "Q", Kind: KindMarket},
+}
+
+// Missing are combinations of market and event type that neither the spec nor
+// Extra have: indices and futures don't serve fair market values or Launchpad
+// values. Subscriptions to them are rejected and their messages aren't decoded.
+// They're listed so the tests can check that they stay that way; an entry moves
+// to Extra once the server serves it.
+var Missing = []Topic{
+	{Market: "indices", EventType: "FMV", Kind: KindBusiness},
+	{Market: "indices", EventType: "LV", Kind: KindLaunchpad, Exclusive: true},
+	{Market: "futures", EventType: "FMV", Kind: KindBusiness},
+	{Market: "futures", EventType: "LV", Kind: KindLaunchpad, Exclusive: true},
+}
+
+// All is every topic in the spec followed by the extra ones.
+var All = append(append([]Topic(nil), Topics...), Extra...)
+
+// Known reports whether a market is in the topic matrix.
+func Known(market string) bool {
+	for _, t := range All {
+		if t.Market == market {
+			return true
+		}
+	}
+	return false
+}
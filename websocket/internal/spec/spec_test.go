@@ -0,0 +1,53 @@
+package spec
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopicsGenerated(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go tool not available")
+	}
+
+	out := filepath.Join(t.TempDir(), "topics.gen.go")
+	cmd := exec.Command("go", "run", "../topicgen", "../../../.massive/websocket.json", out)
+	output, err := cmd.CombinedOutput()
+	assert.Nil(t, err, string(output))
+
+	generated, _ := os.ReadFile(out)
+	committed, _ := os.ReadFile("topics.gen.go")
+	assert.Equal(t, string(generated), string(committed), "topics.gen.go is out of date: run go generate")
+}
+
+func TestServedOn(t *testing.T) {
+	market := Topic{Market: "stocks", EventType: "T", Kind: KindMarket}
+	business := Topic{Market: "stocks", EventType: "FMV", Kind: KindBusiness}
+	exclusive := Topic{Market: "stocks", EventType: "LV", Kind: KindLaunchpad, Exclusive: true}
+	shared := Topic{Market: "stocks", EventType: "AM", Kind: KindLaunchpad}
+
+	for _, kind := range []Kind{KindMarket, KindBusiness, KindLaunchpad} {
+		assert.True(t, market.ServedOn(kind))
+		assert.True(t, shared.ServedOn(kind))
+		assert.Equal(t, kind == KindBusiness, business.ServedOn(kind))
+		assert.Equal(t, kind == KindLaunchpad, exclusive.ServedOn(kind))
+	}
+
+	assert.True(t, Known("stocks"))
+	assert.True(t, Known("futures"))
+	assert.False(t, Known("futures/cme"))
+	assert.False(t, Known("bonds"))
+}
+
+func TestMissing(t *testing.T) {
+	for _, missing := range Missing {
+		for _, topic := range All {
+			assert.False(t, topic.Market == missing.Market && topic.EventType == missing.EventType,
+				"%v %v is in the matrix", missing.Market, missing.EventType)
+		}
+	}
+}
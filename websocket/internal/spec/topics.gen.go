@@ -0,0 +1,40 @@
+// Code generated by topicgen from .massive/websocket.json. DO NOT EDIT.
+
+package spec
+
+// Topics are the topics in the WebSocket spec, in the spec's order per market.
+var Topics = []Topic{
+	{Market: "crypto", EventType: "XA", Kind: KindMarket, Exclusive: false},
+	{Market: "crypto", EventType: "XAS", Kind: KindMarket, Exclusive: false},
+	{Market: "crypto", EventType: "XT", Kind: KindMarket, Exclusive: false},
+	{Market: "crypto", EventType: "XQ", Kind: KindMarket, Exclusive: false},
+	{Market: "crypto", EventType: "XL2", Kind: KindMarket, Exclusive: false},
+	{Market: "crypto", EventType: "FMV", Kind: KindBusiness, Exclusive: false},
+	{Market: "crypto", EventType: "AM", Kind: KindLaunchpad, Exclusive: true},
+	{Market: "crypto", EventType: "LV", Kind: KindLaunchpad, Exclusive: true},
+	{Market: "forex", EventType: "CA", Kind: KindMarket, Exclusive: false},
+	{Market: "forex", EventType: "CAS", Kind: KindMarket, Exclusive: false},
+	{Market: "forex", EventType: "C", Kind: KindMarket, Exclusive: false},
+	{Market: "forex", EventType: "FMV", Kind: KindBusiness, Exclusive: false},
+	{Market: "forex", EventType: "AM", Kind: KindLaunchpad, Exclusive: true},
+	{Market: "forex", EventType: "LV", Kind: KindLaunchpad, Exclusive: true},
+	{Market: "indices", EventType: "AM", Kind: KindMarket, Exclusive: false},
+	{Market: "indices", EventType: "A", Kind: KindMarket, Exclusive: false},
+	{Market: "indices", EventType: "V", Kind: KindMarket, Exclusive: false},
+	{Market: "options", EventType: "AM", Kind: KindMarket, Exclusive: false},
+	{Market: "options", EventType: "A", Kind: KindMarket, Exclusive: false},
+	{Market: "options", EventType: "T", Kind: KindMarket, Exclusive: false},
+	{Market: "options", EventType: "Q", Kind: KindMarket, Exclusive: false},
+	{Market: "options", EventType: "FMV", Kind: KindBusiness, Exclusive: false},
+	{Market: "options", EventType: "AM", Kind: KindLaunchpad, Exclusive: true},
+	{Market: "options", EventType: "LV", Kind: KindLaunchpad, Exclusive: true},
+	{Market: "stocks", EventType: "AM", Kind: KindMarket, Exclusive: false},
+	{Market: "stocks", EventType: "A", Kind: KindMarket, Exclusive: false},
+	{Market: "stocks", EventType: "T", Kind: KindMarket, Exclusive: false},
+	{Market: "stocks", EventType: "Q", Kind: KindMarket, Exclusive: false},
+	{Market: "stocks", EventType: "NOI", Kind: KindMarket, Exclusive: false},
+	{Market: "stocks", EventType: "LULD", Kind: KindMarket, Exclusive: false},
+	{Market: "stocks", EventType: "FMV", Kind: KindBusiness, Exclusive: false},
+	{Market: "stocks", EventType: "AM", Kind: KindLaunchpad, Exclusive: true},
+	{Market: "stocks", EventType: "LV", Kind: KindLaunchpad, Exclusive: true},
+}
@@ -0,0 +1,111 @@
+// Command topicgen generates the topic matrix of the WebSocket API from the
+// WebSocket spec. It's run with go generate from the internal/spec directory.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/format"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// markets are the market segments of spec paths.
+var markets = map[string]bool{
+	"stocks":  true,
+	"options": true,
+	"forex":   true,
+	"crypto":  true,
+	"indices": true,
+}
+
+// kinds maps the feed segment of a spec path to its feed kind.
+var kinds = map[string]string{
+	"":          "KindMarket",
+	"business":  "KindBusiness",
+	"launchpad": "KindLaunchpad",
+}
+
+type spec struct {
+	Order map[string]struct {
+		Market []struct {
+			Paths     []string `json:"paths"`
+			Launchpad string   `json:"launchpad"`
+		} `json:"market"`
+	} `json:"x-polygon-order"`
+}
+
+func main() {
+	if len(os.Args) != 3 {
+		log.Fatal("usage: topicgen <spec> <output>")
+	}
+
+	data, err := os.ReadFile(os.Args[1])
+	if err != nil {
+		log.Fatal(err)
+	}
+	var s spec
+	if err := json.Unmarshal(data, &s); err != nil {
+		log.Fatalf("failed to parse spec: %v", err)
+	}
+
+	groups := make([]string, 0, len(s.Order))
+	for g := range s.Order {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+
+	var out bytes.Buffer
+	out.WriteString("// Code generated by topicgen from .massive/websocket.json. DO NOT EDIT.\n\n")
+	out.WriteString("package spec\n\n")
+	out.WriteString("// Topics are the topics in the WebSocket spec, in the spec's order per market.\n")
+	out.WriteString("var Topics = []Topic{\n")
+	for _, g := range groups {
+		for _, entry := range s.Order[g].Market {
+			for _, path := range entry.Paths {
+				line, err := topic(path, entry.Launchpad == "exclusive")
+				if err != nil {
+					log.Fatal(err)
+				}
+				out.WriteString(line)
+			}
+		}
+	}
+	out.WriteString("}\n")
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		log.Fatalf("failed to format output: %v", err)
+	}
+	if err := os.WriteFile(os.Args[2], src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// topic returns the Topic literal for a spec path such as /stocks/T,
+// /business/stocks/FMV or /launchpad/stocks/AM.
+func topic(path string, exclusive bool) (string, error) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	var feed string
+	switch len(parts) {
+	case 2:
+	case 3:
+		feed, parts = parts[0], parts[1:]
+	default:
+		return "", fmt.Errorf("unexpected path %q", path)
+	}
+
+	market := parts[0]
+	if !markets[market] {
+		return "", fmt.Errorf("unknown market in path %q", path)
+	}
+	kind, ok := kinds[feed]
+	if !ok {
+		return "", fmt.Errorf("unknown feed in path %q", path)
+	}
+
+	return fmt.Sprintf("\t{Market: %q, EventType: %q, Kind: %v, Exclusive: %v},\n", market, parts[1], kind, exclusive), nil
+}
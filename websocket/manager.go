@@ -0,0 +1,334 @@
+package massivews
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Message is a message received by a Manager, tagged with the feed and market of
+// the connection it was received on.
+type Message struct {
+	// Feed is the data feed of the connection that received the message.
+	Feed Feed
+
+	// Market is the market of the connection that received the message.
+	Market Market
+
+	// Data is the message as it would be returned by Client.Output.
+	Data any
+}
+
+// Manager owns a set of clients keyed by feed and market and merges their output
+// into a single stream. It can be used to consume several markets (e.g. Stocks and
+// Crypto) or several feeds without running one output loop per connection.
+type Manager struct {
+	config Config
+
+	mtx       sync.Mutex
+	clients   map[connKey]*Client
+	order     []connKey
+	connected bool
+	closed    bool
+
+	wg     sync.WaitGroup
+	done   chan struct{}
+	output chan Message
+	err    chan error
+}
+
+// errQueueSize is the number of errors a Manager holds for Error before it starts
+// dropping them.
+const errQueueSize = 100
+
+type connKey struct {
+	feed   Feed
+	market Market
+}
+
+// NewManager creates a connection manager. The config is used as a template for
+// every connection added with Add; its Feed and Market fields are ignored. A gap
+// detector or recorder keeps per-connection state, so the template can't have one;
+// set them per connection with AddConfig instead. The Backfiller is shared, which
+// is fine since every request carries the market of the connection.
+func NewManager(config Config) (*Manager, error) {
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("invalid manager options: %w", err)
+	}
+	if config.GapDetector != nil || config.Recorder != nil {
+		return nil, errors.New("invalid manager options: a gap detector or recorder can't be shared by every connection, use AddConfig to set one per connection")
+	}
+
+	return &Manager{
+		config:  config,
+		clients: make(map[connKey]*Client),
+		done:    make(chan struct{}),
+		output:  make(chan Message, 100000),
+		err:     make(chan error, errQueueSize),
+	}, nil
+}
+
+// Add registers a connection for the given feed and market using the template
+// config. If the manager is already connected, the new connection is dialed
+// immediately. Adding the same feed and market twice is a no-op.
+func (m *Manager) Add(feed Feed, market Market) error {
+	config := m.config
+	config.Feed = feed
+	config.Market = market
+	return m.add(config)
+}
+
+// AddConfig registers a connection with its own config, e.g. to give it a gap
+// detector or recorder. The connection is keyed by the config's Feed and Market
+// and otherwise behaves like one added with Add. A gap detector or recorder that's
+// already used by another connection of the manager is rejected.
+func (m *Manager) AddConfig(config Config) error {
+	return m.add(config)
+}
+
+func (m *Manager) add(config Config) error {
+	key := connKey{feed: config.Feed, market: config.Market}
+	c, err := New(config)
+	if err != nil {
+		return err
+	}
+
+	m.mtx.Lock()
+	if err := m.check(key, c); err != nil || m.clients[key] != nil {
+		m.mtx.Unlock()
+		return err
+	}
+	if !m.connected {
+		// Connect will dial it along with the others
+		m.register(key, c)
+		m.mtx.Unlock()
+		return nil
+	}
+	m.mtx.Unlock()
+
+	// dial without holding the lock so that a slow connection doesn't block the others
+	if err := c.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to %v/%v: %w", key.feed, key.market, err)
+	}
+
+	m.mtx.Lock()
+	err = m.check(key, c)
+	added := err == nil && m.clients[key] == nil
+	if added {
+		m.register(key, c)
+	}
+	m.mtx.Unlock()
+
+	if !added {
+		// the manager was closed or the same connection was added while dialing
+		c.Close()
+	}
+	return err
+}
+
+// check returns an error if a client can't be added because the manager is closed
+// or the client shares a gap detector or recorder with another connection.
+func (m *Manager) check(key connKey, c *Client) error {
+	if m.closed {
+		return errors.New("manager is closed")
+	}
+	for k, other := range m.clients {
+		if k == key {
+			continue
+		}
+		if c.gaps != nil && c.gaps == other.gaps {
+			return fmt.Errorf("gap detector is already used by %v/%v", k.feed, k.market)
+		}
+		if c.recorder != nil && c.recorder == other.recorder {
+			return fmt.Errorf("recorder is already used by %v/%v", k.feed, k.market)
+		}
+	}
+	return nil
+}
+
+// register adds a client and starts forwarding its output. It must be called with
+// the lock held.
+func (m *Manager) register(key connKey, c *Client) {
+	m.clients[key] = c
+	m.order = append(m.order, key)
+	m.wg.Add(1)
+	go m.forward(key, c)
+}
+
+// Client returns the client for a given feed and market, if one has been added.
+func (m *Manager) Client(feed Feed, market Market) (*Client, bool) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	c, ok := m.clients[connKey{feed: feed, market: market}]
+	return c, ok
+}
+
+// Connect dials every connection that has been added to the manager. The lock isn't
+// held while dialing, so connections can be added and subscribed to in the meantime.
+// If a connection fails, the ones dialed before it stay connected.
+func (m *Manager) Connect() error {
+	m.mtx.Lock()
+	if m.closed {
+		m.mtx.Unlock()
+		return errors.New("manager is closed")
+	}
+	// connections added from now on are dialed by Add
+	m.connected = true
+	keys := append([]connKey(nil), m.order...)
+	clients := make([]*Client, len(keys))
+	for i, key := range keys {
+		clients[i] = m.clients[key]
+	}
+	m.mtx.Unlock()
+
+	for i, c := range clients {
+		select {
+		case <-m.done:
+			return errors.New("manager is closed")
+		default:
+		}
+		if err := c.Connect(); err != nil {
+			return fmt.Errorf("failed to connect to %v/%v: %w", keys[i].feed, keys[i].market, err)
+		}
+	}
+
+	return nil
+}
+
+// Subscribe sends a subscription message to the first added connection whose market
+// supports the topic. Use SubscribeTo when more than one connection supports the
+// topic (e.g. BusinessFairMarketValue or the same market on several feeds).
+func (m *Manager) Subscribe(topic Topic, tickers ...string) error {
+	c, err := m.route(topic)
+	if err != nil {
+		return err
+	}
+	return c.Subscribe(topic, tickers...)
+}
+
+// Unsubscribe sends an unsubscribe message to the connection that Subscribe would
+// route the topic to.
+func (m *Manager) Unsubscribe(topic Topic, tickers ...string) error {
+	c, err := m.route(topic)
+	if err != nil {
+		return err
+	}
+	return c.Unsubscribe(topic, tickers...)
+}
+
+// SubscribeTo sends a subscription message on the connection for a given feed and market.
+func (m *Manager) SubscribeTo(feed Feed, market Market, topic Topic, tickers ...string) error {
+	c, ok := m.Client(feed, market)
+	if !ok {
+		return fmt.Errorf("no connection for feed '%v' and market '%v'", feed, market)
+	}
+	return c.Subscribe(topic, tickers...)
+}
+
+// UnsubscribeFrom sends an unsubscribe message on the connection for a given feed and market.
+func (m *Manager) UnsubscribeFrom(feed Feed, market Market, topic Topic, tickers ...string) error {
+	c, ok := m.Client(feed, market)
+	if !ok {
+		return fmt.Errorf("no connection for feed '%v' and market '%v'", feed, market)
+	}
+	return c.Unsubscribe(topic, tickers...)
+}
+
+// Output returns the merged output queue.
+func (m *Manager) Output() <-chan Message {
+	return m.output
+}
+
+// Error returns the merged error channel. Errors are wrapped with the feed and
+// market of the connection that failed. The channel is buffered; if nobody reads
+// it, errors that don't fit are logged and dropped so that they never hold up the
+// output of a connection.
+func (m *Manager) Error() <-chan error {
+	return m.err
+}
+
+// Close closes every connection and then closes the merged output channel.
+func (m *Manager) Close() {
+	m.mtx.Lock()
+	if m.closed {
+		m.mtx.Unlock()
+		return
+	}
+	m.closed = true
+	clients := make([]*Client, 0, len(m.order))
+	for _, key := range m.order {
+		clients = append(clients, m.clients[key])
+	}
+	m.mtx.Unlock()
+
+	// the clients are closed before forwarding stops so that errors raised while they
+	// shut down are still read
+	for _, c := range clients {
+		c.Close()
+	}
+	close(m.done)
+	m.wg.Wait()
+	close(m.output)
+}
+
+// route finds the first connection, in the order they were added, that supports a topic.
+func (m *Manager) route(topic Topic) (*Client, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+
+	for _, key := range m.order {
+		if key.market.supports(topic) && key.feed.serves(key.market, topic) {
+			return m.clients[key], nil
+		}
+	}
+
+	return nil, fmt.Errorf("no connection supports topic '%v'", topic.prefix())
+}
+
+// forward pushes everything from a client's output and error channels to the manager's.
+// The manager closes its clients before it stops forwarding, so the error that closed
+// a client is always read.
+func (m *Manager) forward(key connKey, c *Client) {
+	defer m.wg.Done()
+
+	for {
+		select {
+		case <-m.done:
+			m.drainErr(key, c)
+			return
+		case out, more := <-c.Output():
+			if !more {
+				m.drainErr(key, c)
+				return
+			}
+			select {
+			case m.output <- Message{Feed: key.feed, Market: key.market, Data: out}:
+			case <-m.done:
+			}
+		case err := <-c.Error():
+			m.pushErr(key, err)
+		}
+	}
+}
+
+// drainErr forwards the error a closed client pushed, if any. A client pushes it
+// before closing its output.
+func (m *Manager) drainErr(key connKey, c *Client) {
+	select {
+	case err := <-c.Error():
+		m.pushErr(key, err)
+	default:
+	}
+}
+
+// pushErr pushes an error of a connection to the merged error channel, or drops it if
+// the channel is full.
+func (m *Manager) pushErr(key connKey, err error) {
+	err = fmt.Errorf("%v/%v: %w", key.feed, key.market, err)
+	select {
+	case m.err <- err:
+	default:
+		m.config.Log.Errorf("error channel is full, dropping error: %v", err)
+	}
+}
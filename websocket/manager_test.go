@@ -0,0 +1,228 @@
+package massivews
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/massive-com/client-go/v3/websocket/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestManagerSubscribe(t *testing.T) {
+	m, err := NewManager(Config{APIKey: "test"})
+	assert.Nil(t, err)
+	assert.Nil(t, m.Add(RealTime, Stocks))
+	assert.Nil(t, m.Add(RealTime, Crypto))
+	assert.Nil(t, m.Add(RealTime, Stocks)) // adding twice is a no-op
+
+	// topics are routed to the connection that supports them
+	assert.Nil(t, m.Subscribe(StocksTrades, "AAPL"))
+	assert.Nil(t, m.Subscribe(CryptoTrades, "BTC-USD"))
+	stocks, _ := m.Client(RealTime, Stocks)
+	crypto, _ := m.Client(RealTime, Crypto)
+	_, aapl := stocks.subs[StocksTrades]["AAPL"]
+	assert.True(t, aapl)
+	_, btc := crypto.subs[CryptoTrades]["BTC-USD"]
+	assert.True(t, btc)
+
+	// no connection supports options topics, and FMV is only served on business feeds
+	assert.NotNil(t, m.Subscribe(OptionsTrades, "O:A230616C00070000"))
+	assert.NotNil(t, m.Subscribe(BusinessFairMarketValue, "MSFT"))
+	assert.NotNil(t, m.SubscribeTo(Delayed, Crypto, CryptoTrades))
+
+	assert.Nil(t, m.Unsubscribe(StocksTrades, "AAPL"))
+	_, aapl = stocks.subs[StocksTrades]["AAPL"]
+	assert.False(t, aapl)
+	assert.Nil(t, m.UnsubscribeFrom(RealTime, Crypto, CryptoTrades))
+	_, trades := crypto.subs[CryptoTrades]
+	assert.False(t, trades)
+
+	m.Close()
+	assert.NotNil(t, m.Add(RealTime, Options))
+}
+
+func TestManagerSubscribeBusiness(t *testing.T) {
+	m, err := NewManager(Config{APIKey: "test"})
+	assert.Nil(t, err)
+	defer m.Close()
+	assert.Nil(t, m.Add(BusinessFeed, Stocks))
+	assert.Nil(t, m.Add(BusinessFeed, Crypto))
+	stocks, _ := m.Client(BusinessFeed, Stocks)
+	crypto, _ := m.Client(BusinessFeed, Crypto)
+
+	// FMV is supported by both so it goes to the connection that was added first
+	// unless a connection is picked explicitly
+	assert.Nil(t, m.Subscribe(BusinessFairMarketValue, "MSFT"))
+	_, msft := stocks.subs[BusinessFairMarketValue]["MSFT"]
+	assert.True(t, msft)
+	assert.Nil(t, m.SubscribeTo(BusinessFeed, Crypto, BusinessFairMarketValue, "ETH-USD"))
+	_, eth := crypto.subs[BusinessFairMarketValue]["ETH-USD"]
+	assert.True(t, eth)
+
+	assert.Nil(t, m.UnsubscribeFrom(BusinessFeed, Crypto, BusinessFairMarketValue))
+	_, fmv := crypto.subs[BusinessFairMarketValue]
+	assert.False(t, fmv)
+}
+
+func TestManagerOutput(t *testing.T) {
+	s := newServer()
+	defer s.Close()
+
+	u := Feed(s.URL())
+	var retries uint64 = 0
+	m, err := NewManager(Config{APIKey: "good", MaxRetries: &retries})
+	assert.Nil(t, err)
+	assert.Nil(t, m.Add(u, Stocks))
+	assert.Nil(t, m.Connect())
+	assert.Nil(t, m.Add(u, Crypto)) // connects immediately
+
+	stocks, _ := m.Client(u, Stocks)
+	crypto, _ := m.Client(u, Crypto)
+	stocks.output <- models.EquityTrade{Symbol: "AAPL"}
+	crypto.output <- models.CryptoTrade{Pair: "BTC-USD"}
+
+	got := map[Market]any{}
+	for i := 0; i < 2; i++ {
+		msg := <-m.Output()
+		assert.Equal(t, u, msg.Feed)
+		got[msg.Market] = msg.Data
+	}
+	assert.Equal(t, models.EquityTrade{Symbol: "AAPL"}, got[Stocks])
+	assert.Equal(t, models.CryptoTrade{Pair: "BTC-USD"}, got[Crypto])
+
+	m.Close()
+	_, more := <-m.Output()
+	assert.False(t, more)
+}
+
+func TestManagerUnreadErrors(t *testing.T) {
+	m, err := NewManager(Config{APIKey: "test"})
+	assert.Nil(t, err)
+	defer m.Close()
+	assert.Nil(t, m.Add(RealTime, Stocks))
+	stocks, _ := m.Client(RealTime, Stocks)
+
+	// nobody reads Error, so errors past the buffer are dropped instead of blocking
+	// the connection's output
+	for i := 0; i < errQueueSize+10; i++ {
+		stocks.err <- errors.New("failed")
+	}
+	stocks.output <- models.EquityTrade{Symbol: "AAPL"}
+	select {
+	case msg := <-m.Output():
+		assert.Equal(t, models.EquityTrade{Symbol: "AAPL"}, msg.Data)
+	case <-time.After(5 * time.Second):
+		t.Fatal("unread errors blocked the output")
+	}
+	assert.Len(t, m.Error(), errQueueSize)
+}
+
+func TestManagerCloseWhileReconnecting(t *testing.T) {
+	s := newServer()
+	failed := make(chan struct{}, 10)
+	var retries uint64 = 2
+	m, err := NewManager(Config{
+		APIKey:     "good",
+		MaxRetries: &retries,
+		ReconnectCallback: func(err error) {
+			if err != nil {
+				failed <- struct{}{}
+			}
+		},
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, m.Add(Feed(s.URL()), Stocks))
+	assert.Nil(t, m.Connect())
+
+	// the server goes away, so every reconnect attempt fails
+	s.Close()
+	<-failed
+
+	closed := make(chan struct{})
+	go func() {
+		m.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(10 * time.Second):
+		t.Fatal("close blocked on a client that failed to reconnect")
+	}
+
+	// the error the client was closed with is still forwarded
+	select {
+	case err := <-m.Error():
+		assert.ErrorContains(t, err, "error reconnecting")
+	default:
+		t.Fatal("the reconnect error wasn't forwarded")
+	}
+	_, more := <-m.Output()
+	assert.False(t, more)
+}
+
+func TestManagerStatefulComponents(t *testing.T) {
+	gaps := NewGapDetector(GapDetectorConfig{})
+	_, err := NewManager(Config{APIKey: "test", GapDetector: gaps})
+	assert.NotNil(t, err)
+	_, err = NewManager(Config{APIKey: "test", Recorder: NewRecorder(io.Discard)})
+	assert.NotNil(t, err)
+
+	// each connection can have its own
+	m, err := NewManager(Config{APIKey: "test"})
+	assert.Nil(t, err)
+	defer m.Close()
+	assert.Nil(t, m.AddConfig(Config{APIKey: "test", Feed: RealTime, Market: Stocks, GapDetector: gaps}))
+	assert.Nil(t, m.AddConfig(Config{APIKey: "test", Feed: RealTime, Market: Crypto, GapDetector: NewGapDetector(GapDetectorConfig{})}))
+	assert.NotNil(t, m.AddConfig(Config{APIKey: "test", Feed: RealTime, Market: Options, GapDetector: gaps}))
+	stocks, ok := m.Client(RealTime, Stocks)
+	assert.True(t, ok)
+	assert.Equal(t, gaps, stocks.gaps)
+	_, ok = m.Client(RealTime, Options)
+	assert.False(t, ok)
+}
+
+func TestManagerConnectDoesNotBlock(t *testing.T) {
+	fake := newServer()
+	defer fake.Close()
+	dialing, release := make(chan struct{}), make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(dialing)
+		<-release
+		fake.ServeHTTP(w, r)
+	}))
+	defer slow.Close()
+
+	var retries uint64 = 0
+	m, err := NewManager(Config{APIKey: "good", MaxRetries: &retries})
+	assert.Nil(t, err)
+	defer m.Close()
+	assert.Nil(t, m.Add(Feed("ws"+strings.TrimPrefix(slow.URL, "http")), Stocks))
+
+	connected := make(chan error, 1)
+	go func() { connected <- m.Connect() }()
+	<-dialing
+
+	// another connection can be added and subscribed to while the first one dials
+	added := make(chan error, 1)
+	go func() {
+		if err := m.Add(Feed(fake.URL()), Crypto); err != nil {
+			added <- err
+			return
+		}
+		added <- m.Subscribe(CryptoTrades, "BTC-USD")
+	}()
+	select {
+	case err := <-added:
+		assert.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("add blocked while another connection was dialing")
+	}
+
+	close(release)
+	assert.Nil(t, <-connected)
+}
@@ -0,0 +1,101 @@
+// Package metrics exports websocket client statistics as Prometheus metrics.
+package metrics
+
+import (
+	massivews "github.com/massive-com/client-go/v3/websocket"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// StatsSource is anything that can take a statistics snapshot, e.g. a massivews.Client.
+type StatsSource interface {
+	Stats() massivews.Stats
+}
+
+// Opts is a set of collector options.
+type Opts struct {
+	// Namespace is the metric namespace. Omitting this uses "massive_ws".
+	Namespace string
+
+	// ConstLabels are labels added to every metric, e.g. to tell several clients apart
+	// by feed and market.
+	ConstLabels prometheus.Labels
+}
+
+// Collector is a prometheus.Collector that reports the statistics of a client every
+// time it is scraped.
+type Collector struct {
+	source StatsSource
+
+	messages          *prometheus.Desc
+	bytes             *prometheus.Desc
+	messageRate       *prometheus.Desc
+	byteRate          *prometheus.Desc
+	latency           *prometheus.Desc
+	lastMessage       *prometheus.Desc
+	queue             *prometheus.Desc
+	pingRTT           *prometheus.Desc
+	reconnects        *prometheus.Desc
+	reconnectFailures *prometheus.Desc
+}
+
+// NewCollector creates a collector for a client. Register it with a prometheus.Registerer.
+func NewCollector(source StatsSource, opts Opts) *Collector {
+	ns := opts.Namespace
+	if ns == "" {
+		ns = "massive_ws"
+	}
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(ns, "", name), help, labels, opts.ConstLabels)
+	}
+
+	return &Collector{
+		source:            source,
+		messages:          desc("messages_total", "Number of data messages received.", "event"),
+		bytes:             desc("bytes_total", "Size in bytes of the data messages received.", "event"),
+		messageRate:       desc("messages_per_second", "Number of data messages received during the last full second.", "event"),
+		byteRate:          desc("bytes_per_second", "Number of bytes received during the last full second.", "event"),
+		latency:           desc("latency_seconds", "Moving average of the time between a message timestamp and when it was received.", "event"),
+		lastMessage:       desc("last_message_timestamp_seconds", "Unix time the last data message was received.", "event"),
+		queue:             desc("queue_depth", "Number of items waiting in a client queue.", "queue"),
+		pingRTT:           desc("ping_rtt_seconds", "Round trip time of the last ping."),
+		reconnects:        desc("reconnects_total", "Number of successful reconnects."),
+		reconnectFailures: desc("reconnect_failures_total", "Number of failed reconnect attempts."),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.messages
+	ch <- c.bytes
+	ch <- c.messageRate
+	ch <- c.byteRate
+	ch <- c.latency
+	ch <- c.lastMessage
+	ch <- c.queue
+	ch <- c.pingRTT
+	ch <- c.reconnects
+	ch <- c.reconnectFailures
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	s := c.source.Stats()
+
+	for ev, t := range s.Topics {
+		ch <- prometheus.MustNewConstMetric(c.messages, prometheus.CounterValue, float64(t.Messages), ev)
+		ch <- prometheus.MustNewConstMetric(c.bytes, prometheus.CounterValue, float64(t.Bytes), ev)
+		ch <- prometheus.MustNewConstMetric(c.messageRate, prometheus.GaugeValue, float64(t.MessagesPerSecond), ev)
+		ch <- prometheus.MustNewConstMetric(c.byteRate, prometheus.GaugeValue, float64(t.BytesPerSecond), ev)
+		ch <- prometheus.MustNewConstMetric(c.latency, prometheus.GaugeValue, t.Latency.Seconds(), ev)
+		if !t.LastMessage.IsZero() {
+			ch <- prometheus.MustNewConstMetric(c.lastMessage, prometheus.GaugeValue, float64(t.LastMessage.UnixNano())/1e9, ev)
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.queue, prometheus.GaugeValue, float64(s.ReadQueue), "read")
+	ch <- prometheus.MustNewConstMetric(c.queue, prometheus.GaugeValue, float64(s.WriteQueue), "write")
+	ch <- prometheus.MustNewConstMetric(c.queue, prometheus.GaugeValue, float64(s.OutputQueue), "output")
+	ch <- prometheus.MustNewConstMetric(c.pingRTT, prometheus.GaugeValue, s.PingRTT.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.reconnects, prometheus.CounterValue, float64(s.Reconnects))
+	ch <- prometheus.MustNewConstMetric(c.reconnectFailures, prometheus.CounterValue, float64(s.ReconnectFailures))
+}
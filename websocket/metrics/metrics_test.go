@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	massivews "github.com/massive-com/client-go/v3/websocket"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSource massivews.Stats
+
+func (f fakeSource) Stats() massivews.Stats {
+	return massivews.Stats(f)
+}
+
+func TestCollector(t *testing.T) {
+	source := fakeSource{
+		Topics: map[string]massivews.TopicStats{
+			"T": {Messages: 10, Bytes: 1000, MessagesPerSecond: 2, BytesPerSecond: 200, Latency: 50 * time.Millisecond},
+		},
+		Messages:   10,
+		Bytes:      1000,
+		ReadQueue:  3,
+		PingRTT:    20 * time.Millisecond,
+		Reconnects: 1,
+	}
+	c := NewCollector(source, Opts{ConstLabels: prometheus.Labels{"market": "stocks"}})
+
+	expected := `
+# HELP massive_ws_messages_total Number of data messages received.
+# TYPE massive_ws_messages_total counter
+massive_ws_messages_total{event="T",market="stocks"} 10
+# HELP massive_ws_latency_seconds Moving average of the time between a message timestamp and when it was received.
+# TYPE massive_ws_latency_seconds gauge
+massive_ws_latency_seconds{event="T",market="stocks"} 0.05
+# HELP massive_ws_queue_depth Number of items waiting in a client queue.
+# TYPE massive_ws_queue_depth gauge
+massive_ws_queue_depth{market="stocks",queue="output"} 0
+massive_ws_queue_depth{market="stocks",queue="read"} 3
+massive_ws_queue_depth{market="stocks",queue="write"} 0
+# HELP massive_ws_reconnects_total Number of successful reconnects.
+# TYPE massive_ws_reconnects_total counter
+massive_ws_reconnects_total{market="stocks"} 1
+`
+	assert.Nil(t, testutil.CollectAndCompare(c, strings.NewReader(expected),
+		"massive_ws_messages_total", "massive_ws_latency_seconds", "massive_ws_queue_depth", "massive_ws_reconnects_total"))
+
+	// no last message time is reported for topics without messages
+	assert.Equal(t, 0, testutil.CollectAndCount(c, "massive_ws_last_message_timestamp_seconds"))
+}
@@ -0,0 +1,82 @@
+package models
+
+import "github.com/massive-com/client-go/v3/conditions"
+
+// The condition helpers look conditions up in conditions.Default(), which is empty
+// until conditions/restload.Refresh loads it. Stock trades and quotes use the stock
+// conditions; options trades share the EquityTrade model but have their own
+// conditions, so look those up with conditions.Options directly.
+
+// ConditionNames returns the names of the trade's conditions.
+func (t EquityTrade) ConditionNames() []string {
+	return conditions.Default().Names(conditions.Stocks, conditions.Trade, t.Conditions)
+}
+
+// UnknownConditions returns the trade's conditions that aren't in the table. The
+// Updates and EligibleForLast helpers are false for trades with unknown conditions.
+func (t EquityTrade) UnknownConditions() []int32 {
+	return conditions.Default().Unknown(conditions.Stocks, conditions.Trade, t.Conditions)
+}
+
+// UpdatesHighLow reports whether the trade updates the consolidated high and low.
+func (t EquityTrade) UpdatesHighLow() bool {
+	return conditions.Default().UpdatesHighLow(conditions.Stocks, t.Conditions)
+}
+
+// UpdatesOpenClose reports whether the trade updates the consolidated open and close.
+func (t EquityTrade) UpdatesOpenClose() bool {
+	return conditions.Default().UpdatesOpenClose(conditions.Stocks, t.Conditions)
+}
+
+// UpdatesVolume reports whether the trade updates the consolidated volume.
+func (t EquityTrade) UpdatesVolume() bool {
+	return conditions.Default().UpdatesVolume(conditions.Stocks, t.Conditions)
+}
+
+// EligibleForLast reports whether the trade can set the last sale price.
+func (t EquityTrade) EligibleForLast() bool {
+	return conditions.Default().EligibleForLast(conditions.Stocks, t.Conditions)
+}
+
+// ConditionNames returns the names of the trade's conditions.
+func (t CryptoTrade) ConditionNames() []string {
+	return conditions.Default().Names(conditions.Crypto, conditions.Trade, t.Conditions)
+}
+
+// UnknownConditions returns the trade's conditions that aren't in the table. The
+// Updates and EligibleForLast helpers are false for trades with unknown conditions.
+func (t CryptoTrade) UnknownConditions() []int32 {
+	return conditions.Default().Unknown(conditions.Crypto, conditions.Trade, t.Conditions)
+}
+
+// UpdatesHighLow reports whether the trade updates the high and low.
+func (t CryptoTrade) UpdatesHighLow() bool {
+	return conditions.Default().UpdatesHighLow(conditions.Crypto, t.Conditions)
+}
+
+// UpdatesOpenClose reports whether the trade updates the open and close.
+func (t CryptoTrade) UpdatesOpenClose() bool {
+	return conditions.Default().UpdatesOpenClose(conditions.Crypto, t.Conditions)
+}
+
+// UpdatesVolume reports whether the trade updates the volume.
+func (t CryptoTrade) UpdatesVolume() bool {
+	return conditions.Default().UpdatesVolume(conditions.Crypto, t.Conditions)
+}
+
+// EligibleForLast reports whether the trade can set the last sale price.
+func (t CryptoTrade) EligibleForLast() bool {
+	return conditions.Default().EligibleForLast(conditions.Crypto, t.Conditions)
+}
+
+// ConditionName returns the name of the quote's condition, or an empty string if it's
+// unknown.
+func (q EquityQuote) ConditionName() string {
+	c, _ := conditions.Default().Lookup(conditions.Stocks, conditions.NBBO, q.Condition)
+	return c.Name
+}
+
+// IndicatorNames returns the names of the quote's indicators.
+func (q EquityQuote) IndicatorNames() []string {
+	return conditions.Default().Names(conditions.Stocks, conditions.NBBO, q.Indicators)
+}
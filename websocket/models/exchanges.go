@@ -0,0 +1,48 @@
+package models
+
+import "github.com/massive-com/client-go/v3/exchanges"
+
+// The exchange helpers look exchanges up in exchanges.Default(), which is empty until
+// exchanges/restload.Refresh loads it. Stock trades and quotes use the stock
+// exchanges; options trades share the EquityTrade model but have their own exchanges,
+// so look those up with exchanges.Options directly.
+
+// ExchangeInfo returns the exchange the trade was reported by.
+func (t EquityTrade) ExchangeInfo() (exchanges.Exchange, bool) {
+	return exchanges.Default().Lookup(exchanges.Stocks, t.Exchange)
+}
+
+// BidExchange returns the exchange of the bid.
+func (q EquityQuote) BidExchange() (exchanges.Exchange, bool) {
+	return exchanges.Default().Lookup(exchanges.Stocks, q.BidExchangeID)
+}
+
+// AskExchange returns the exchange of the ask.
+func (q EquityQuote) AskExchange() (exchanges.Exchange, bool) {
+	return exchanges.Default().Lookup(exchanges.Stocks, q.AskExchangeID)
+}
+
+// ExchangeInfo returns the exchange of the imbalance.
+func (i Imbalance) ExchangeInfo() (exchanges.Exchange, bool) {
+	return exchanges.Default().Lookup(exchanges.Stocks, i.ExchangeID)
+}
+
+// ExchangeInfo returns the exchange of the quote.
+func (q ForexQuote) ExchangeInfo() (exchanges.Exchange, bool) {
+	return exchanges.Default().Lookup(exchanges.Forex, q.ExchangeID)
+}
+
+// ExchangeInfo returns the exchange the trade happened on.
+func (t CryptoTrade) ExchangeInfo() (exchanges.Exchange, bool) {
+	return exchanges.Default().Lookup(exchanges.Crypto, t.Exchange)
+}
+
+// ExchangeInfo returns the exchange of the quote.
+func (q CryptoQuote) ExchangeInfo() (exchanges.Exchange, bool) {
+	return exchanges.Default().Lookup(exchanges.Crypto, q.ExchangeID)
+}
+
+// ExchangeInfo returns the exchange of the book.
+func (l Level2Book) ExchangeInfo() (exchanges.Exchange, bool) {
+	return exchanges.Default().Lookup(exchanges.Crypto, l.ExchangeID)
+}
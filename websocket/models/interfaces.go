@@ -0,0 +1,195 @@
+package models
+
+import "time"
+
+// The interfaces below let code handle trades, quotes and aggregates of any asset
+// class the same way. The models already have fields named Symbol, Price, Size and so
+// on, so the accessors are prefixed with Get. Stock tickers, option contracts, futures
+// contracts, forex and crypto pairs are all returned by GetSymbol.
+
+// Trade is a trade of any asset class.
+type Trade interface {
+	GetSymbol() string
+	GetPrice() float64
+	GetSize() float64
+	Time() time.Time
+}
+
+// Quote is a top of book quote of any asset class. Forex quotes don't have sizes, so
+// their sizes are always zero.
+type Quote interface {
+	GetSymbol() string
+	GetBidPrice() float64
+	GetBidSize() float64
+	GetAskPrice() float64
+	GetAskSize() float64
+	Time() time.Time
+}
+
+// Bar is an aggregate of any asset class.
+type Bar interface {
+	GetSymbol() string
+	GetOpen() float64
+	GetHigh() float64
+	GetLow() float64
+	GetClose() float64
+	GetVolume() float64
+	StartTime() time.Time
+	EndTime() time.Time
+}
+
+var (
+	_ Trade = EquityTrade{}
+	_ Trade = CryptoTrade{}
+	_ Trade = FuturesTrade{}
+
+	_ Quote = EquityQuote{}
+	_ Quote = ForexQuote{}
+	_ Quote = CryptoQuote{}
+	_ Quote = FuturesQuote{}
+
+	_ Bar = EquityAgg{}
+	_ Bar = CurrencyAgg{}
+	_ Bar = FuturesAggregate{}
+)
+
+// GetSymbol returns the ticker or options contract of the trade.
+func (t EquityTrade) GetSymbol() string { return t.Symbol }
+
+// GetPrice returns the price of the trade.
+func (t EquityTrade) GetPrice() float64 { return t.Price }
+
+// GetSize returns the size of the trade.
+func (t EquityTrade) GetSize() float64 { return float64(t.Size) }
+
+// GetSymbol returns the crypto pair of the trade.
+func (t CryptoTrade) GetSymbol() string { return t.Pair }
+
+// GetPrice returns the price of the trade.
+func (t CryptoTrade) GetPrice() float64 { return t.Price }
+
+// GetSize returns the size of the trade.
+func (t CryptoTrade) GetSize() float64 { return t.Size }
+
+// GetSymbol returns the futures contract of the trade.
+func (t FuturesTrade) GetSymbol() string { return t.Symbol }
+
+// GetPrice returns the price of the trade.
+func (t FuturesTrade) GetPrice() float64 { return t.Price }
+
+// GetSize returns the size of the trade.
+func (t FuturesTrade) GetSize() float64 { return float64(t.Size) }
+
+// GetSymbol returns the ticker or options contract of the quote.
+func (q EquityQuote) GetSymbol() string { return q.Symbol }
+
+// GetBidPrice returns the bid price.
+func (q EquityQuote) GetBidPrice() float64 { return q.BidPrice }
+
+// GetBidSize returns the bid size.
+func (q EquityQuote) GetBidSize() float64 { return float64(q.BidSize) }
+
+// GetAskPrice returns the ask price.
+func (q EquityQuote) GetAskPrice() float64 { return q.AskPrice }
+
+// GetAskSize returns the ask size.
+func (q EquityQuote) GetAskSize() float64 { return float64(q.AskSize) }
+
+// GetSymbol returns the forex pair of the quote.
+func (q ForexQuote) GetSymbol() string { return q.Pair }
+
+// GetBidPrice returns the bid price.
+func (q ForexQuote) GetBidPrice() float64 { return q.BidPrice }
+
+// GetBidSize returns zero since forex quotes don't have sizes.
+func (q ForexQuote) GetBidSize() float64 { return 0 }
+
+// GetAskPrice returns the ask price.
+func (q ForexQuote) GetAskPrice() float64 { return q.AskPrice }
+
+// GetAskSize returns zero since forex quotes don't have sizes.
+func (q ForexQuote) GetAskSize() float64 { return 0 }
+
+// GetSymbol returns the crypto pair of the quote.
+func (q CryptoQuote) GetSymbol() string { return q.Pair }
+
+// GetBidPrice returns the bid price.
+func (q CryptoQuote) GetBidPrice() float64 { return q.BidPrice }
+
+// GetBidSize returns the bid size.
+func (q CryptoQuote) GetBidSize() float64 { return q.BidSize }
+
+// GetAskPrice returns the ask price.
+func (q CryptoQuote) GetAskPrice() float64 { return q.AskPrice }
+
+// GetAskSize returns the ask size.
+func (q CryptoQuote) GetAskSize() float64 { return q.AskSize }
+
+// GetSymbol returns the futures contract of the quote.
+func (q FuturesQuote) GetSymbol() string { return q.Symbol }
+
+// GetBidPrice returns the bid price.
+func (q FuturesQuote) GetBidPrice() float64 { return q.BidPrice }
+
+// GetBidSize returns the bid size.
+func (q FuturesQuote) GetBidSize() float64 { return float64(q.BidSize) }
+
+// GetAskPrice returns the ask price.
+func (q FuturesQuote) GetAskPrice() float64 { return q.AskPrice }
+
+// GetAskSize returns the ask size.
+func (q FuturesQuote) GetAskSize() float64 { return float64(q.AskSize) }
+
+// GetSymbol returns the ticker, options contract or index of the aggregate.
+func (a EquityAgg) GetSymbol() string { return a.Symbol }
+
+// GetOpen returns the open price.
+func (a EquityAgg) GetOpen() float64 { return a.Open }
+
+// GetHigh returns the high price.
+func (a EquityAgg) GetHigh() float64 { return a.High }
+
+// GetLow returns the low price.
+func (a EquityAgg) GetLow() float64 { return a.Low }
+
+// GetClose returns the close price.
+func (a EquityAgg) GetClose() float64 { return a.Close }
+
+// GetVolume returns the volume of the aggregate.
+func (a EquityAgg) GetVolume() float64 { return a.Volume }
+
+// GetSymbol returns the forex or crypto pair of the aggregate.
+func (a CurrencyAgg) GetSymbol() string { return a.Pair }
+
+// GetOpen returns the open price.
+func (a CurrencyAgg) GetOpen() float64 { return a.Open }
+
+// GetHigh returns the high price.
+func (a CurrencyAgg) GetHigh() float64 { return a.High }
+
+// GetLow returns the low price.
+func (a CurrencyAgg) GetLow() float64 { return a.Low }
+
+// GetClose returns the close price.
+func (a CurrencyAgg) GetClose() float64 { return a.Close }
+
+// GetVolume returns the volume of the aggregate.
+func (a CurrencyAgg) GetVolume() float64 { return a.Volume }
+
+// GetSymbol returns the futures contract of the aggregate.
+func (a FuturesAggregate) GetSymbol() string { return a.Symbol }
+
+// GetOpen returns the open price.
+func (a FuturesAggregate) GetOpen() float64 { return a.Open }
+
+// GetHigh returns the high price.
+func (a FuturesAggregate) GetHigh() float64 { return a.High }
+
+// GetLow returns the low price.
+func (a FuturesAggregate) GetLow() float64 { return a.Low }
+
+// GetClose returns the close price.
+func (a FuturesAggregate) GetClose() float64 { return a.Close }
+
+// GetVolume returns the volume of the aggregate.
+func (a FuturesAggregate) GetVolume() float64 { return a.Volume }
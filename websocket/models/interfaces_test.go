@@ -0,0 +1,69 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTradeAccessors(t *testing.T) {
+	ts := time.UnixMilli(1710253800000)
+	for _, tc := range []struct {
+		trade  Trade
+		symbol string
+	}{
+		{EquityTrade{Symbol: "AAPL", Price: 170.5, Size: 100, Timestamp: Millis(ts)}, "AAPL"},
+		{CryptoTrade{Pair: "BTC-USD", Price: 170.5, Size: 100, Timestamp: Millis(ts)}, "BTC-USD"},
+		{FuturesTrade{Symbol: "ESZ5", Price: 170.5, Size: 100, Timestamp: Millis(ts)}, "ESZ5"},
+	} {
+		assert.Equal(t, tc.symbol, tc.trade.GetSymbol())
+		assert.Equal(t, 170.5, tc.trade.GetPrice(), tc.symbol)
+		assert.Equal(t, 100.0, tc.trade.GetSize(), tc.symbol)
+		assert.Equal(t, ts, tc.trade.Time(), tc.symbol)
+	}
+}
+
+func TestQuoteAccessors(t *testing.T) {
+	ts := time.UnixMilli(1710253800000)
+	for _, tc := range []struct {
+		quote  Quote
+		symbol string
+		size   float64
+	}{
+		{EquityQuote{Symbol: "AAPL", BidPrice: 1, BidSize: 2, AskPrice: 3, AskSize: 4, Timestamp: Millis(ts)}, "AAPL", 2},
+		{CryptoQuote{Pair: "BTC-USD", BidPrice: 1, BidSize: 2, AskPrice: 3, AskSize: 4, Timestamp: Millis(ts)}, "BTC-USD", 2},
+		{FuturesQuote{Symbol: "ESZ5", BidPrice: 1, BidSize: 2, AskPrice: 3, AskSize: 4, Timestamp: Millis(ts)}, "ESZ5", 2},
+
+		// forex quotes don't have sizes
+		{ForexQuote{Pair: "EUR/USD", BidPrice: 1, AskPrice: 3, Timestamp: Millis(ts)}, "EUR/USD", 0},
+	} {
+		assert.Equal(t, tc.symbol, tc.quote.GetSymbol())
+		assert.Equal(t, 1.0, tc.quote.GetBidPrice(), tc.symbol)
+		assert.Equal(t, tc.size, tc.quote.GetBidSize(), tc.symbol)
+		assert.Equal(t, 3.0, tc.quote.GetAskPrice(), tc.symbol)
+		assert.Equal(t, 2*tc.size, tc.quote.GetAskSize(), tc.symbol)
+		assert.Equal(t, ts, tc.quote.Time(), tc.symbol)
+	}
+}
+
+func TestBarAccessors(t *testing.T) {
+	start, end := time.UnixMilli(1710253800000), time.UnixMilli(1710253860000)
+	for _, tc := range []struct {
+		bar    Bar
+		symbol string
+	}{
+		{EquityAgg{Symbol: "AAPL", Open: 1, High: 4, Low: 0.5, Close: 2, Volume: 10, StartTimestamp: Millis(start), EndTimestamp: Millis(end)}, "AAPL"},
+		{CurrencyAgg{Pair: "BTC-USD", Open: 1, High: 4, Low: 0.5, Close: 2, Volume: 10, StartTimestamp: Millis(start), EndTimestamp: Millis(end)}, "BTC-USD"},
+		{FuturesAggregate{Symbol: "ESZ5", Open: 1, High: 4, Low: 0.5, Close: 2, Volume: 10, StartTimestamp: Millis(start), EndTimestamp: Millis(end)}, "ESZ5"},
+	} {
+		assert.Equal(t, tc.symbol, tc.bar.GetSymbol())
+		assert.Equal(t, 1.0, tc.bar.GetOpen(), tc.symbol)
+		assert.Equal(t, 4.0, tc.bar.GetHigh(), tc.symbol)
+		assert.Equal(t, 0.5, tc.bar.GetLow(), tc.symbol)
+		assert.Equal(t, 2.0, tc.bar.GetClose(), tc.symbol)
+		assert.Equal(t, 10.0, tc.bar.GetVolume(), tc.symbol)
+		assert.Equal(t, start, tc.bar.StartTime(), tc.symbol)
+		assert.Equal(t, end, tc.bar.EndTime(), tc.symbol)
+	}
+}
@@ -62,10 +62,10 @@ type EquityAgg struct {
 	AverageSize float64 `json:"z,omitempty"`
 
 	// The timestamp of the starting tick for this aggregate window in Unix Milliseconds.
-	StartTimestamp int64 `json:"s,omitempty"`
+	StartTimestamp UnixMillis `json:"s,omitempty"`
 
 	// The timestamp of the ending tick for this aggregate window in Unix Milliseconds.
-	EndTimestamp int64 `json:"e,omitempty"`
+	EndTimestamp UnixMillis `json:"e,omitempty"`
 
 	// Whether or not this aggregate is for an OTC ticker. This field will be left off if false.
 	OTC bool `json:"otc,omitempty"`
@@ -98,10 +98,10 @@ type CurrencyAgg struct {
 	VWAP float64 `json:"vw,omitempty"`
 
 	// The start time for this aggregate window in Unix Milliseconds.
-	StartTimestamp int64 `json:"s,omitempty"`
+	StartTimestamp UnixMillis `json:"s,omitempty"`
 
 	// The end time for this aggregate window in Unix Milliseconds.
-	EndTimestamp int64 `json:"e,omitempty"`
+	EndTimestamp UnixMillis `json:"e,omitempty"`
 
 	// The average trade size for this aggregate window.
 	AVGTradeSize int32 `json:"z,omitempty"`
@@ -134,7 +134,7 @@ type EquityTrade struct {
 	Conditions []int32 `json:"c,omitempty"`
 
 	// The Timestamp in Unix MS.
-	Timestamp int64 `json:"t,omitempty"`
+	Timestamp UnixMillis `json:"t,omitempty"`
 
 	// The sequence number represents the sequence in which message events happened. These are increasing and unique per
 	// ticker symbol, but will not always be sequential (e.g., 1, 2, 6, 9, 10, 11).
@@ -145,7 +145,7 @@ type EquityTrade struct {
 
 	// The TRF (Trade Reporting Facility) Timestamp in Unix MS.
 	// This is the timestamp of when the trade reporting facility received this trade.
-	TradeReportingFacilityTimestamp int64 `json:"trft,omitempty"`
+	TradeReportingFacilityTimestamp UnixMillis `json:"trft,omitempty"`
 }
 
 // CryptoTrade is a trade for a crypto pair.
@@ -172,10 +172,10 @@ type CryptoTrade struct {
 	Conditions []int32 `json:"c,omitempty"`
 
 	// The Timestamp in Unix MS.
-	Timestamp int64 `json:"t,omitempty"`
+	Timestamp UnixMillis `json:"t,omitempty"`
 
 	// The timestamp that the tick was received by Massive.
-	ReceivedTimestamp int64 `json:"r,omitempty"`
+	ReceivedTimestamp UnixMillis `json:"r,omitempty"`
 }
 
 // EquityQuote is a quote for either stock tickers or option contracts.
@@ -213,7 +213,7 @@ type EquityQuote struct {
 	Indicators []int32 `json:"i,omitempty"`
 
 	// The Timestamp in Unix MS.
-	Timestamp int64 `json:"t,omitempty"`
+	Timestamp UnixMillis `json:"t,omitempty"`
 
 	// The tape. (1 = NYSE, 2 = AMEX, 3 = Nasdaq).
 	Tape int32 `json:"z,omitempty"`
@@ -241,7 +241,7 @@ type ForexQuote struct {
 	BidPrice float64 `json:"b,omitempty"`
 
 	// The Timestamp in Unix MS.
-	Timestamp int64 `json:"t,omitempty"`
+	Timestamp UnixMillis `json:"t,omitempty"`
 }
 
 // CryptoQuote is a quote for a crypto pair.
@@ -265,13 +265,13 @@ type CryptoQuote struct {
 	AskSize float64 `json:"as,omitempty"`
 
 	// The Timestamp in Unix MS.
-	Timestamp int64 `json:"t,omitempty"`
+	Timestamp UnixMillis `json:"t,omitempty"`
 
 	// The crypto exchange ID.
 	ExchangeID int32 `json:"x,omitempty"`
 
 	// The timestamp that the tick was received by Massive.
-	ReceivedTimestamp int64 `json:"r,omitempty"`
+	ReceivedTimestamp UnixMillis `json:"r,omitempty"`
 }
 
 // Imbalance is an imbalance event for a given stock ticker symbol.
@@ -283,7 +283,7 @@ type Imbalance struct {
 	Symbol string `json:"T,omitempty"`
 
 	// The Timestamp in Unix MS.
-	Timestamp int64 `json:"t,omitempty"`
+	Timestamp UnixMillis `json:"t,omitempty"`
 
 	// The time that the auction is planned to take place in the format (hour x 100) + minutes in Eastern Standard Time,
 	// for example 930 would be 9:30 am EST, and 1600 would be 4:00 pm EST.
@@ -331,7 +331,7 @@ type LimitUpLimitDown struct {
 	Tape int32 `json:"z,omitempty"`
 
 	// The Timestamp in Unix MS.
-	Timestamp int64 `json:"t,omitempty"`
+	Timestamp UnixMillis `json:"t,omitempty"`
 
 	// The sequence number represents the sequence in which message events happened. These are increasing and unique per ticker symbol, but will not always be sequential (e.g., 1, 2, 6, 9, 10, 11).
 	SequenceNumber int64 `json:"q,omitempty"`
@@ -352,13 +352,13 @@ type Level2Book struct {
 	AskPrices [][]float64 `json:"a,omitempty"`
 
 	// The Timestamp in Unix MS.
-	Timestamp int64 `json:"t,omitempty"`
+	Timestamp UnixMillis `json:"t,omitempty"`
 
 	// The crypto exchange ID.
 	ExchangeID int32 `json:"x,omitempty"`
 
 	// The timestamp that the tick was received by Massive.
-	ReceivedTimestamp int64 `json:"r,omitempty"`
+	ReceivedTimestamp UnixMillis `json:"r,omitempty"`
 }
 
 // IndexValue is value data for either indices.
@@ -373,7 +373,7 @@ type IndexValue struct {
 	Ticker string `json:"T"`
 
 	// The Timestamp in Unix MS.
-	Timestamp int64 `json:"t,omitempty"`
+	Timestamp UnixMillis `json:"t,omitempty"`
 }
 
 type LaunchpadValue struct {
@@ -387,7 +387,7 @@ type LaunchpadValue struct {
 	Ticker string `json:"sym"`
 
 	// The Timestamp in nanoseconds.
-	Timestamp int64 `json:"t,omitempty"`
+	Timestamp UnixNanos `json:"t,omitempty"`
 }
 
 type FairMarketValue struct {
@@ -401,43 +401,43 @@ type FairMarketValue struct {
 	Ticker string `json:"sym"`
 
 	// The Timestamp in nanoseconds.
-	Timestamp int64 `json:"t,omitempty"`
+	Timestamp UnixNanos `json:"t,omitempty"`
 }
 
 // FuturesTrade represents a futures trade event.
 type FuturesTrade struct {
 	EventType
-	Symbol         string  `json:"sym,omitempty"`
-	Price          float64 `json:"p,omitempty"`
-	Size           int64   `json:"s,omitempty"`
-	Timestamp      int64   `json:"t,omitempty"`
-	SequenceNumber int64   `json:"q,omitempty"`
+	Symbol         string     `json:"sym,omitempty"`
+	Price          float64    `json:"p,omitempty"`
+	Size           int64      `json:"s,omitempty"`
+	Timestamp      UnixMillis `json:"t,omitempty"`
+	SequenceNumber int64      `json:"q,omitempty"`
 }
 
 // FuturesQuote represents a futures quote event.
 type FuturesQuote struct {
 	EventType
-	Symbol       string  `json:"sym,omitempty"`
-	BidPrice     float64 `json:"bp,omitempty"`
-	BidSize      int64   `json:"bs,omitempty"`
-	BidTimestamp int64   `json:"bt,omitempty"`
-	AskPrice     float64 `json:"ap,omitempty"`
-	AskSize      int64   `json:"as,omitempty"`
-	AskTimestamp int64   `json:"at,omitempty"`
-	Timestamp    int64   `json:"t,omitempty"`
+	Symbol       string     `json:"sym,omitempty"`
+	BidPrice     float64    `json:"bp,omitempty"`
+	BidSize      int64      `json:"bs,omitempty"`
+	BidTimestamp UnixMillis `json:"bt,omitempty"`
+	AskPrice     float64    `json:"ap,omitempty"`
+	AskSize      int64      `json:"as,omitempty"`
+	AskTimestamp UnixMillis `json:"at,omitempty"`
+	Timestamp    UnixMillis `json:"t,omitempty"`
 }
 
 // FuturesAggregate represents an aggregate event (e.g., second or minute) for a futures contract.
 type FuturesAggregate struct {
-	EventType      string  `json:"ev,omitempty"`
-	Symbol         string  `json:"sym,omitempty"`
-	Volume         float64 `json:"v,omitempty"`
-	TotalValue     float64 `json:"dv,omitempty"`
-	Open           float64 `json:"o,omitempty"`
-	Close          float64 `json:"c,omitempty"`
-	High           float64 `json:"h,omitempty"`
-	Low            float64 `json:"l,omitempty"`
-	Transactions   int64   `json:"n,omitempty"`
-	StartTimestamp int64   `json:"s,omitempty"`
-	EndTimestamp   int64   `json:"e,omitempty"`
+	EventType      string     `json:"ev,omitempty"`
+	Symbol         string     `json:"sym,omitempty"`
+	Volume         float64    `json:"v,omitempty"`
+	TotalValue     float64    `json:"dv,omitempty"`
+	Open           float64    `json:"o,omitempty"`
+	Close          float64    `json:"c,omitempty"`
+	High           float64    `json:"h,omitempty"`
+	Low            float64    `json:"l,omitempty"`
+	Transactions   int64      `json:"n,omitempty"`
+	StartTimestamp UnixMillis `json:"s,omitempty"`
+	EndTimestamp   UnixMillis `json:"e,omitempty"`
 }
@@ -0,0 +1,175 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strconv"
+	"time"
+)
+
+// UnixMillis is a Unix timestamp in milliseconds. Zero means the timestamp isn't set.
+type UnixMillis int64
+
+// Millis returns t as a UnixMillis.
+func Millis(t time.Time) UnixMillis {
+	if t.IsZero() {
+		return 0
+	}
+	return UnixMillis(t.UnixMilli())
+}
+
+// Time returns the timestamp as a time.Time, or the zero time if it isn't set.
+func (ms UnixMillis) Time() time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(int64(ms))
+}
+
+// MarshalJSON encodes the timestamp as a JSON number.
+func (ms UnixMillis) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, int64(ms), 10), nil
+}
+
+// UnmarshalJSON decodes the timestamp from a JSON number or a string containing one.
+func (ms *UnixMillis) UnmarshalJSON(data []byte) error {
+	v, err := unmarshalTimestamp(data)
+	*ms = UnixMillis(v)
+	return err
+}
+
+// UnixNanos is a Unix timestamp in nanoseconds. Zero means the timestamp isn't set.
+type UnixNanos int64
+
+// Nanos returns t as a UnixNanos.
+func Nanos(t time.Time) UnixNanos {
+	if t.IsZero() {
+		return 0
+	}
+	return UnixNanos(t.UnixNano())
+}
+
+// Time returns the timestamp as a time.Time, or the zero time if it isn't set.
+func (ns UnixNanos) Time() time.Time {
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(ns))
+}
+
+// MarshalJSON encodes the timestamp as a JSON number.
+func (ns UnixNanos) MarshalJSON() ([]byte, error) {
+	return strconv.AppendInt(nil, int64(ns), 10), nil
+}
+
+// UnmarshalJSON decodes the timestamp from a JSON number or a string containing one.
+func (ns *UnixNanos) UnmarshalJSON(data []byte) error {
+	v, err := unmarshalTimestamp(data)
+	*ns = UnixNanos(v)
+	return err
+}
+
+// unmarshalTimestamp decodes an integer timestamp. Integers are parsed directly since
+// this is on the decode path of every message. Decimals and exponent notation are
+// parsed exactly rather than through a float64, which can't hold nanosecond
+// timestamps, and any fraction is truncated.
+func unmarshalTimestamp(data []byte) (int64, error) {
+	if bytes.Equal(data, []byte("null")) {
+		return 0, nil
+	}
+	if len(data) >= 2 && data[0] == '"' && data[len(data)-1] == '"' {
+		data = data[1 : len(data)-1]
+		if len(data) == 0 {
+			return 0, nil
+		}
+	}
+	if v, err := strconv.ParseInt(string(data), 10, 64); err == nil {
+		return v, nil
+	}
+
+	r, ok := new(big.Rat).SetString(string(data))
+	if !ok {
+		return 0, fmt.Errorf("invalid timestamp %s", data)
+	}
+	v := new(big.Int).Quo(r.Num(), r.Denom())
+	if !v.IsInt64() {
+		return 0, fmt.Errorf("timestamp %s is out of range", data)
+	}
+	return v.Int64(), nil
+}
+
+// StartTime returns the start of the aggregate window.
+func (a EquityAgg) StartTime() time.Time { return a.StartTimestamp.Time() }
+
+// EndTime returns the end of the aggregate window.
+func (a EquityAgg) EndTime() time.Time { return a.EndTimestamp.Time() }
+
+// StartTime returns the start of the aggregate window.
+func (a CurrencyAgg) StartTime() time.Time { return a.StartTimestamp.Time() }
+
+// EndTime returns the end of the aggregate window.
+func (a CurrencyAgg) EndTime() time.Time { return a.EndTimestamp.Time() }
+
+// StartTime returns the start of the aggregate window.
+func (a FuturesAggregate) StartTime() time.Time { return a.StartTimestamp.Time() }
+
+// EndTime returns the end of the aggregate window.
+func (a FuturesAggregate) EndTime() time.Time { return a.EndTimestamp.Time() }
+
+// Time returns the time of the trade.
+func (t EquityTrade) Time() time.Time { return t.Timestamp.Time() }
+
+// TRFTime returns the time the trade was reported to the TRF.
+func (t EquityTrade) TRFTime() time.Time { return t.TradeReportingFacilityTimestamp.Time() }
+
+// Time returns the time of the trade.
+func (t CryptoTrade) Time() time.Time { return t.Timestamp.Time() }
+
+// ReceivedTime returns the time the trade was received by Massive.
+func (t CryptoTrade) ReceivedTime() time.Time { return t.ReceivedTimestamp.Time() }
+
+// Time returns the time of the trade.
+func (t FuturesTrade) Time() time.Time { return t.Timestamp.Time() }
+
+// Time returns the time of the quote.
+func (q EquityQuote) Time() time.Time { return q.Timestamp.Time() }
+
+// Time returns the time of the quote.
+func (q ForexQuote) Time() time.Time { return q.Timestamp.Time() }
+
+// Time returns the time of the quote.
+func (q CryptoQuote) Time() time.Time { return q.Timestamp.Time() }
+
+// ReceivedTime returns the time the quote was received by Massive.
+func (q CryptoQuote) ReceivedTime() time.Time { return q.ReceivedTimestamp.Time() }
+
+// Time returns the time of the quote.
+func (q FuturesQuote) Time() time.Time { return q.Timestamp.Time() }
+
+// BidTime returns the time of the bid.
+func (q FuturesQuote) BidTime() time.Time { return q.BidTimestamp.Time() }
+
+// AskTime returns the time of the ask.
+func (q FuturesQuote) AskTime() time.Time { return q.AskTimestamp.Time() }
+
+// Time returns the time of the imbalance.
+func (i Imbalance) Time() time.Time { return i.Timestamp.Time() }
+
+// Time returns the time of the price bands.
+func (l LimitUpLimitDown) Time() time.Time { return l.Timestamp.Time() }
+
+// Time returns the time of the book.
+func (l Level2Book) Time() time.Time { return l.Timestamp.Time() }
+
+// ReceivedTime returns the time the book was received by Massive.
+func (l Level2Book) ReceivedTime() time.Time { return l.ReceivedTimestamp.Time() }
+
+// Time returns the time of the value.
+func (v IndexValue) Time() time.Time { return v.Timestamp.Time() }
+
+// Time returns the time of the value.
+func (v LaunchpadValue) Time() time.Time { return v.Timestamp.Time() }
+
+// Time returns the time of the value.
+func (v FairMarketValue) Time() time.Time { return v.Timestamp.Time() }
@@ -0,0 +1,82 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnmarshalTimestamp(t *testing.T) {
+	for _, tc := range []struct {
+		in    string
+		want  int64
+		error bool
+	}{
+		{`1710253800000`, 1710253800000, false},
+		{`-1`, -1, false},
+		{`0`, 0, false},
+		{`null`, 0, false},
+		{`""`, 0, false},
+		{`"1710253800000"`, 1710253800000, false},
+		{`1710253800000.9`, 1710253800000, false},
+		{`1.7102538e12`, 1710253800000, false},
+		{`"1.7102538e12"`, 1710253800000, false},
+
+		// nanosecond timestamps don't fit in a float64 without rounding
+		{`1710253800123456789`, 1710253800123456789, false},
+		{`1710253800123456789.0`, 1710253800123456789, false},
+		{`1.710253800123456789e18`, 1710253800123456789, false},
+		{`"1710253800123456789.5"`, 1710253800123456789, false},
+
+		{`1e19`, 0, true},
+		{`"abc"`, 0, true},
+		{`true`, 0, true},
+		{`{}`, 0, true},
+	} {
+		var ms UnixMillis
+		err := json.Unmarshal([]byte(tc.in), &ms)
+		var ns UnixNanos
+		nsErr := json.Unmarshal([]byte(tc.in), &ns)
+		if tc.error {
+			assert.NotNil(t, err, tc.in)
+			assert.NotNil(t, nsErr, tc.in)
+			continue
+		}
+		assert.Nil(t, err, tc.in)
+		assert.Nil(t, nsErr, tc.in)
+		assert.Equal(t, UnixMillis(tc.want), ms, tc.in)
+		assert.Equal(t, UnixNanos(tc.want), ns, tc.in)
+	}
+}
+
+func TestTimestamps(t *testing.T) {
+	now := time.Unix(1710253800, 123456789)
+
+	ms := Millis(now)
+	assert.Equal(t, UnixMillis(1710253800123), ms)
+	assert.Equal(t, time.UnixMilli(1710253800123), ms.Time())
+	ns := Nanos(now)
+	assert.Equal(t, now, ns.Time())
+
+	// zero is unset both ways
+	assert.Zero(t, Millis(time.Time{}))
+	assert.Zero(t, Nanos(time.Time{}))
+	assert.True(t, UnixMillis(0).Time().IsZero())
+	assert.True(t, UnixNanos(0).Time().IsZero())
+
+	// timestamps encode as numbers and round trip
+	data, err := json.Marshal(EquityTrade{Timestamp: ms})
+	assert.Nil(t, err)
+	assert.Contains(t, string(data), `"t":1710253800123`)
+	var trade EquityTrade
+	assert.Nil(t, json.Unmarshal(data, &trade))
+	assert.Equal(t, ms.Time(), trade.Time())
+
+	data, err = json.Marshal(IndexValue{Timestamp: ms})
+	assert.Nil(t, err)
+	var v IndexValue
+	assert.Nil(t, json.Unmarshal(data, &v))
+	assert.Equal(t, ms, v.Timestamp)
+}
@@ -0,0 +1,314 @@
+// Package nbbo tracks the best bid and offer per symbol from stock and crypto quotes.
+package nbbo
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/massive-com/client-go/v3/websocket/models"
+)
+
+// MarketState describes how the best bid relates to the best offer.
+type MarketState int
+
+const (
+	// Normal means the best bid is below the best offer, or a side is missing.
+	Normal MarketState = iota
+
+	// Locked means the best bid equals the best offer.
+	Locked
+
+	// Crossed means the best bid is above the best offer.
+	Crossed
+)
+
+func (s MarketState) String() string {
+	switch s {
+	case Locked:
+		return "locked"
+	case Crossed:
+		return "crossed"
+	default:
+		return "normal"
+	}
+}
+
+// Side is one side of a quote. A zero price means the side is empty.
+type Side struct {
+	Exchange int32
+	Price    float64
+	Size     float64
+}
+
+// BBO is the best bid and offer for a symbol.
+type BBO struct {
+	Symbol string
+	Bid    Side
+	Ask    Side
+	State  MarketState
+	Time   time.Time
+}
+
+// Spread returns the difference between the best offer and bid.
+func (b BBO) Spread() (float64, bool) {
+	if b.Bid.Price == 0 || b.Ask.Price == 0 {
+		return 0, false
+	}
+	return b.Ask.Price - b.Bid.Price, true
+}
+
+// Mid returns the price halfway between the best bid and offer.
+func (b BBO) Mid() (float64, bool) {
+	if b.Bid.Price == 0 || b.Ask.Price == 0 {
+		return 0, false
+	}
+	return (b.Bid.Price + b.Ask.Price) / 2, true
+}
+
+// Top is the last quoted top of book for a symbol on one exchange.
+type Top struct {
+	Exchange int32
+	Bid      Side
+	Ask      Side
+	Time     time.Time
+}
+
+// Snapshot is the state of a symbol: its best bid and offer and, for crypto pairs, the
+// top of book on every exchange with a recent quote, ordered by exchange.
+type Snapshot struct {
+	BBO       BBO
+	Exchanges []Top
+}
+
+// Event is a change in the market state of a symbol, e.g. when it becomes locked or
+// crossed, or returns to normal.
+type Event struct {
+	Symbol   string
+	Previous MarketState
+	BBO      BBO
+}
+
+// Config is a set of tracker options.
+type Config struct {
+	// EventBuffer is the size of the event channel. Events are dropped and counted
+	// when the channel is full. Omitting this uses a buffer of 1000 events.
+	EventBuffer int
+
+	// StaleAfter is how long a crypto exchange's top of book counts towards the best
+	// bid and offer without a new quote from that exchange, measured against the
+	// timestamp of the latest quote for the pair. Older tops are dropped. Omitting
+	// this uses 1 minute.
+	StaleAfter time.Duration
+}
+
+// Tracker maintains the best bid and offer per symbol. Feed it every message from
+// the client's output channel with Observe; anything other than stock and crypto
+// quotes is ignored.
+//
+// Stock quotes are already consolidated, so the latest quote is the NBBO. They only
+// name the exchanges at the best bid and ask, which says nothing about the other
+// exchanges' quotes, so stock snapshots have no per exchange tops. Crypto quotes are
+// per exchange, so the best bid and offer is computed across the latest quote from
+// every exchange that quoted within StaleAfter.
+type Tracker struct {
+	mtx        sync.RWMutex
+	symbols    map[string]*symbol
+	events     chan Event
+	dropped    uint64
+	staleAfter time.Duration
+}
+
+type symbol struct {
+	bbo       BBO
+	exchanges map[int32]*Top
+}
+
+// New creates an empty tracker.
+func New(config Config) *Tracker {
+	if config.EventBuffer < 1 {
+		config.EventBuffer = 1000
+	}
+	if config.StaleAfter <= 0 {
+		config.StaleAfter = time.Minute
+	}
+
+	return &Tracker{
+		symbols:    make(map[string]*symbol),
+		events:     make(chan Event, config.EventBuffer),
+		staleAfter: config.StaleAfter,
+	}
+}
+
+// Events returns the channel of market state changes.
+func (t *Tracker) Events() <-chan Event {
+	return t.events
+}
+
+// Dropped returns the number of events that were dropped because the event channel
+// was full.
+func (t *Tracker) Dropped() uint64 {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+	return t.dropped
+}
+
+// BBO returns the best bid and offer for a symbol.
+func (t *Tracker) BBO(sym string) (BBO, bool) {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	s, ok := t.symbols[sym]
+	if !ok {
+		return BBO{}, false
+	}
+	return s.bbo, true
+}
+
+// Snapshot returns the state of a symbol.
+func (t *Tracker) Snapshot(sym string) (Snapshot, bool) {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	s, ok := t.symbols[sym]
+	if !ok {
+		return Snapshot{}, false
+	}
+	return s.snapshot(), true
+}
+
+// Snapshots returns the state of every symbol.
+func (t *Tracker) Snapshots() map[string]Snapshot {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+
+	out := make(map[string]Snapshot, len(t.symbols))
+	for sym, s := range t.symbols {
+		out[sym] = s.snapshot()
+	}
+	return out
+}
+
+// Reset clears every symbol.
+func (t *Tracker) Reset() {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.symbols = make(map[string]*symbol)
+}
+
+// Observe applies a quote. It returns an event and true if the quote changed the
+// market state of its symbol; the event is also pushed to the event channel.
+func (t *Tracker) Observe(msg any) (Event, bool) {
+	switch m := msg.(type) {
+	case models.EquityQuote:
+		ts := m.Time()
+		bid := Side{Exchange: m.BidExchangeID, Price: m.BidPrice, Size: float64(m.BidSize)}
+		ask := Side{Exchange: m.AskExchangeID, Price: m.AskPrice, Size: float64(m.AskSize)}
+
+		t.mtx.Lock()
+		defer t.mtx.Unlock()
+
+		s := t.symbol(m.Symbol)
+		return t.update(s, BBO{Symbol: m.Symbol, Bid: bid, Ask: ask, Time: ts})
+	case models.CryptoQuote:
+		ts := m.Time()
+
+		t.mtx.Lock()
+		defer t.mtx.Unlock()
+
+		s := t.symbol(m.Pair)
+		top := s.exchange(m.ExchangeID, ts)
+		top.Bid = Side{Exchange: m.ExchangeID, Price: m.BidPrice, Size: m.BidSize}
+		top.Ask = Side{Exchange: m.ExchangeID, Price: m.AskPrice, Size: m.AskSize}
+
+		bbo := BBO{Symbol: m.Pair, Time: ts}
+		for id, top := range s.exchanges {
+			if ts.Sub(top.Time) > t.staleAfter {
+				delete(s.exchanges, id)
+				continue
+			}
+			if better(top.Bid, bbo.Bid, func(a, b float64) bool { return a > b }) {
+				bbo.Bid = top.Bid
+			}
+			if better(top.Ask, bbo.Ask, func(a, b float64) bool { return a < b }) {
+				bbo.Ask = top.Ask
+			}
+		}
+		return t.update(s, bbo)
+	}
+	return Event{}, false
+}
+
+func (t *Tracker) symbol(sym string) *symbol {
+	s, ok := t.symbols[sym]
+	if !ok {
+		s = &symbol{bbo: BBO{Symbol: sym}, exchanges: make(map[int32]*Top)}
+		t.symbols[sym] = s
+	}
+	return s
+}
+
+// update replaces the best bid and offer of a symbol and reports a market state change.
+func (t *Tracker) update(s *symbol, bbo BBO) (Event, bool) {
+	bbo.State = state(bbo.Bid.Price, bbo.Ask.Price)
+	prev := s.bbo.State
+	s.bbo = bbo
+	if bbo.State == prev {
+		return Event{}, false
+	}
+
+	ev := Event{Symbol: bbo.Symbol, Previous: prev, BBO: bbo}
+	select {
+	case t.events <- ev:
+	default:
+		t.dropped++
+	}
+	return ev, true
+}
+
+func (s *symbol) exchange(id int32, ts time.Time) *Top {
+	top, ok := s.exchanges[id]
+	if !ok {
+		top = &Top{Exchange: id}
+		s.exchanges[id] = top
+	}
+	top.Time = ts
+	return top
+}
+
+func (s *symbol) snapshot() Snapshot {
+	out := Snapshot{BBO: s.bbo, Exchanges: make([]Top, 0, len(s.exchanges))}
+	for _, top := range s.exchanges {
+		out.Exchanges = append(out.Exchanges, *top)
+	}
+	sort.Slice(out.Exchanges, func(i, j int) bool { return out.Exchanges[i].Exchange < out.Exchanges[j].Exchange })
+	return out
+}
+
+// better reports whether a side beats the current best. Empty sides never win, and
+// ties go to the larger size, then the lower exchange ID so the result is stable.
+func better(side, best Side, ahead func(a, b float64) bool) bool {
+	switch {
+	case side.Price == 0:
+		return false
+	case best.Price == 0 || ahead(side.Price, best.Price):
+		return true
+	case side.Price != best.Price:
+		return false
+	case side.Size != best.Size:
+		return side.Size > best.Size
+	default:
+		return side.Exchange < best.Exchange
+	}
+}
+
+func state(bid, ask float64) MarketState {
+	switch {
+	case bid == 0 || ask == 0 || bid < ask:
+		return Normal
+	case bid == ask:
+		return Locked
+	default:
+		return Crossed
+	}
+}
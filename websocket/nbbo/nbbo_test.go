@@ -0,0 +1,175 @@
+package nbbo
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/massive-com/client-go/v3/websocket/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func quote(sym string, bx int32, bp float64, ax int32, ap float64, ts models.UnixMillis) models.EquityQuote {
+	return models.EquityQuote{
+		EventType: models.EventType{EventType: "Q"}, Symbol: sym, BidExchangeID: bx, BidPrice: bp, BidSize: 1,
+		AskExchangeID: ax, AskPrice: ap, AskSize: 2, Timestamp: ts,
+	}
+}
+
+func cryptoQuote(pair string, x int32, bp, bs, ap, as float64, ts models.UnixMillis) models.CryptoQuote {
+	return models.CryptoQuote{
+		EventType: models.EventType{EventType: "XQ"}, Pair: pair, ExchangeID: x,
+		BidPrice: bp, BidSize: bs, AskPrice: ap, AskSize: as, Timestamp: ts,
+	}
+}
+
+func TestTrackerEquity(t *testing.T) {
+	tr := New(Config{})
+
+	_, ok := tr.Observe(quote("AAPL", 11, 100, 12, 100.05, 1000))
+	assert.False(t, ok)
+
+	bbo, ok := tr.BBO("AAPL")
+	assert.True(t, ok)
+	assert.Equal(t, BBO{
+		Symbol: "AAPL", Bid: Side{Exchange: 11, Price: 100, Size: 1}, Ask: Side{Exchange: 12, Price: 100.05, Size: 2},
+		State: Normal, Time: time.UnixMilli(1000),
+	}, bbo)
+	spread, _ := bbo.Spread()
+	assert.InDelta(t, 0.05, spread, 1e-9)
+	mid, _ := bbo.Mid()
+	assert.InDelta(t, 100.025, mid, 1e-9)
+
+	// the latest consolidated quote is the NBBO, even if an exchange's old quote was better
+	tr.Observe(quote("AAPL", 4, 99.98, 12, 100.05, 2000))
+	bbo, _ = tr.BBO("AAPL")
+	assert.Equal(t, 99.98, bbo.Bid.Price)
+
+	// consolidated quotes don't track per exchange tops, which would go stale
+	snap, ok := tr.Snapshot("AAPL")
+	assert.True(t, ok)
+	assert.Equal(t, bbo, snap.BBO)
+	assert.Empty(t, snap.Exchanges)
+
+	ev, ok := tr.Observe(quote("AAPL", 4, 100.05, 12, 100.05, 3000))
+	assert.True(t, ok)
+	assert.Equal(t, Normal, ev.Previous)
+	assert.Equal(t, Locked, ev.BBO.State)
+
+	ev, ok = tr.Observe(quote("AAPL", 4, 100.10, 12, 100.05, 4000))
+	assert.True(t, ok)
+	assert.Equal(t, Locked, ev.Previous)
+	assert.Equal(t, Crossed, ev.BBO.State)
+
+	// staying crossed isn't a change
+	_, ok = tr.Observe(quote("AAPL", 4, 100.11, 12, 100.05, 5000))
+	assert.False(t, ok)
+
+	ev, ok = tr.Observe(quote("AAPL", 4, 100, 12, 100.05, 6000))
+	assert.True(t, ok)
+	assert.Equal(t, Crossed, ev.Previous)
+	assert.Equal(t, Normal, ev.BBO.State)
+	assert.Len(t, tr.Events(), 3)
+
+	// a missing side is never locked or crossed
+	_, ok = tr.Observe(quote("MSFT", 4, 0, 12, 100.05, 1000))
+	assert.False(t, ok)
+	bbo, _ = tr.BBO("MSFT")
+	_, ok = bbo.Spread()
+	assert.False(t, ok)
+}
+
+func TestTrackerCrypto(t *testing.T) {
+	tr := New(Config{})
+
+	tr.Observe(cryptoQuote("BTC-USD", 1, 100, 1, 101, 1, 1000))
+	tr.Observe(cryptoQuote("BTC-USD", 2, 100.5, 1, 101.5, 1, 2000))
+	bbo, _ := tr.BBO("BTC-USD")
+	assert.Equal(t, Side{Exchange: 2, Price: 100.5, Size: 1}, bbo.Bid)
+	assert.Equal(t, Side{Exchange: 1, Price: 101, Size: 1}, bbo.Ask)
+	assert.Equal(t, time.UnixMilli(2000), bbo.Time)
+
+	// ties go to the larger size
+	tr.Observe(cryptoQuote("BTC-USD", 3, 100.5, 5, 102, 1, 3000))
+	bbo, _ = tr.BBO("BTC-USD")
+	assert.Equal(t, int32(3), bbo.Bid.Exchange)
+
+	// exchanges quoting through each other cross the market
+	ev, ok := tr.Observe(cryptoQuote("BTC-USD", 2, 101.5, 1, 102.5, 1, 4000))
+	assert.True(t, ok)
+	assert.Equal(t, Crossed, ev.BBO.State)
+	assert.Equal(t, int32(2), ev.BBO.Bid.Exchange)
+	assert.Equal(t, int32(1), ev.BBO.Ask.Exchange)
+
+	ev, ok = tr.Observe(cryptoQuote("BTC-USD", 2, 100.5, 1, 101.5, 1, 5000))
+	assert.True(t, ok)
+	assert.Equal(t, Normal, ev.BBO.State)
+
+	snap, _ := tr.Snapshot("BTC-USD")
+	assert.Len(t, snap.Exchanges, 3)
+	assert.Len(t, tr.Snapshots(), 1)
+
+	// other messages are ignored
+	_, ok = tr.Observe(models.CryptoTrade{Pair: "BTC-USD"})
+	assert.False(t, ok)
+
+	tr.Reset()
+	_, ok = tr.BBO("BTC-USD")
+	assert.False(t, ok)
+}
+
+func TestTrackerStale(t *testing.T) {
+	tr := New(Config{StaleAfter: time.Second})
+
+	tr.Observe(cryptoQuote("BTC-USD", 1, 100, 1, 101, 1, 1000))
+	tr.Observe(cryptoQuote("BTC-USD", 2, 99, 1, 102, 1, 1500))
+	bbo, _ := tr.BBO("BTC-USD")
+	assert.Equal(t, int32(1), bbo.Bid.Exchange)
+
+	// exchange 1 hasn't quoted for more than a second, so it no longer counts
+	tr.Observe(cryptoQuote("BTC-USD", 2, 99, 1, 102, 1, 2500))
+	bbo, _ = tr.BBO("BTC-USD")
+	assert.Equal(t, Side{Exchange: 2, Price: 99, Size: 1}, bbo.Bid)
+	assert.Equal(t, Side{Exchange: 2, Price: 102, Size: 1}, bbo.Ask)
+	snap, _ := tr.Snapshot("BTC-USD")
+	assert.Len(t, snap.Exchanges, 1)
+
+	// a new quote brings it back
+	tr.Observe(cryptoQuote("BTC-USD", 1, 100, 1, 101, 1, 3000))
+	snap, _ = tr.Snapshot("BTC-USD")
+	assert.Len(t, snap.Exchanges, 2)
+	assert.Equal(t, int32(1), snap.BBO.Bid.Exchange)
+}
+
+func TestTrackerDropped(t *testing.T) {
+	tr := New(Config{EventBuffer: 1})
+	tr.Observe(quote("AAPL", 4, 100, 12, 100, 1000))
+	tr.Observe(quote("AAPL", 4, 100, 12, 101, 2000))
+	assert.Equal(t, uint64(1), tr.Dropped())
+}
+
+func TestTrackerConcurrent(t *testing.T) {
+	tr := New(Config{})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(x int32) {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				tr.Observe(cryptoQuote("ETH-USD", x, 10, 1, 11, 1, models.UnixMillis(j)))
+			}
+		}(int32(i))
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				tr.Snapshot("ETH-USD")
+				tr.Snapshots()
+			}
+		}()
+	}
+	wg.Wait()
+
+	snap, _ := tr.Snapshot("ETH-USD")
+	assert.Len(t, snap.Exchanges, 4)
+}
@@ -0,0 +1,349 @@
+package massivews
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// captureVersion is the version of the capture file format.
+const captureVersion = 1
+
+// recorderFlushInterval is how often a recorder flushes buffered frames so that a
+// capture is usable even if the process dies.
+const recorderFlushInterval = time.Second
+
+// A capture is a gzip stream of records. Each record is the Unix time in nanoseconds
+// the frame was read (8 bytes, big endian), the length of the frame (4 bytes, big
+// endian) and the frame as it was read from the server. The first record is a JSON
+// captureHeader, written when the recorder is bound to a client so that a capture
+// without any frames can still be replayed.
+type captureHeader struct {
+	Version int    `json:"version"`
+	Feed    Feed   `json:"feed"`
+	Market  Market `json:"market"`
+}
+
+// Recorder writes the raw frames read by a client to a compressed, timestamped
+// capture that can be played back with a Replayer. Since a capture is of a single
+// feed and market, a recorder can only be shared by clients of the same feed and
+// market, whose frames are interleaved. A ShardedClient can't have one.
+type Recorder struct {
+	mtx       sync.Mutex
+	w         io.Closer
+	gz        *gzip.Writer
+	feed      Feed
+	market    Market
+	used      bool
+	lastFlush time.Time
+	err       error
+}
+
+// NewRecorder creates a recorder that writes a capture to w. Closing the recorder
+// doesn't close w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{gz: gzip.NewWriter(w)}
+}
+
+// CreateRecorder creates a recorder that writes a capture to a new file at path.
+func CreateRecorder(path string) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create capture file: %w", err)
+	}
+	r := NewRecorder(f)
+	r.w = f
+	return r, nil
+}
+
+// Close flushes any buffered frames and closes the capture. It should be called
+// after the client using the recorder is closed.
+func (r *Recorder) Close() error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	err := r.gz.Close()
+	if r.w != nil {
+		if cerr := r.w.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// use binds the recorder to the feed and market of a client and writes the capture
+// header the first time. It fails if the recorder is already used by a client of
+// another feed or market.
+func (r *Recorder) use(feed Feed, market Market) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.used {
+		if r.feed != feed || r.market != market {
+			return fmt.Errorf("recorder is already used for %v %v", r.feed, r.market)
+		}
+		return nil
+	}
+
+	now := time.Now()
+	header, _ := json.Marshal(captureHeader{Version: captureVersion, Feed: feed, Market: market})
+	if r.err = r.write(now, header); r.err != nil {
+		return fmt.Errorf("failed to record capture header: %w", r.err)
+	}
+	r.feed, r.market, r.used = feed, market, true
+	r.lastFlush = now
+	return nil
+}
+
+// record writes a frame to the capture. Once a write fails, the error is returned
+// and every later frame is dropped.
+func (r *Recorder) record(now time.Time, frame []byte) error {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	if r.err != nil {
+		return nil
+	}
+	if r.err = r.write(now, frame); r.err != nil {
+		return fmt.Errorf("failed to record frame: %w", r.err)
+	}
+	if now.Sub(r.lastFlush) >= recorderFlushInterval {
+		r.lastFlush = now
+		if r.err = r.gz.Flush(); r.err != nil {
+			return fmt.Errorf("failed to flush capture: %w", r.err)
+		}
+	}
+	return nil
+}
+
+func (r *Recorder) write(now time.Time, data []byte) error {
+	var prefix [12]byte
+	binary.BigEndian.PutUint64(prefix[:8], uint64(now.UnixNano()))
+	binary.BigEndian.PutUint32(prefix[8:], uint32(len(data)))
+	if _, err := r.gz.Write(prefix[:]); err != nil {
+		return err
+	}
+	_, err := r.gz.Write(data)
+	return err
+}
+
+// captureReader reads the records of a capture.
+type captureReader struct {
+	r *bufio.Reader
+}
+
+func (cr *captureReader) next() (time.Time, []byte, error) {
+	var prefix [12]byte
+	if _, err := io.ReadFull(cr.r, prefix[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return time.Time{}, nil, fmt.Errorf("truncated capture: %w", err)
+		}
+		return time.Time{}, nil, err
+	}
+	ts := time.Unix(0, int64(binary.BigEndian.Uint64(prefix[:8])))
+	data := make([]byte, binary.BigEndian.Uint32(prefix[8:]))
+	if _, err := io.ReadFull(cr.r, data); err != nil {
+		return time.Time{}, nil, fmt.Errorf("truncated capture: %w", err)
+	}
+	return ts, data, nil
+}
+
+// ReplayConfig is a set of replayer options.
+type ReplayConfig struct {
+	// Speed is the playback speed relative to the recording (e.g. 1 replays at the
+	// original speed and 10 replays ten times faster). Omitting this replays the
+	// capture as fast as possible.
+	Speed float64
+
+	// RawData and BypassRawDataRouting work the same as in Config.
+	RawData              bool
+	BypassRawDataRouting bool
+
+	// GapDetector is an optional sequence gap detector that replayed messages are
+	// checked against.
+	GapDetector *GapDetector
+
+	// Log is an optional logger.
+	Log Logger
+}
+
+// Replayer plays back a capture written by a Recorder. Frames go through the same
+// routing as a live client so the output has the same types.
+type Replayer struct {
+	client *Client
+	speed  float64
+	r      *captureReader
+	closer io.Closer
+	header captureHeader
+
+	mtx     sync.Mutex
+	started bool
+}
+
+// NewReplayer creates a replayer for a capture read from r.
+func NewReplayer(r io.Reader, config ReplayConfig) (*Replayer, error) {
+	if config.Speed < 0 {
+		return nil, errors.New("invalid replay options: speed can't be negative")
+	}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read capture: %w", err)
+	}
+	cr := &captureReader{r: bufio.NewReader(gz)}
+
+	_, data, err := cr.next()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read capture header: %w", err)
+	}
+	var header captureHeader
+	if err := json.Unmarshal(data, &header); err != nil {
+		return nil, fmt.Errorf("failed to read capture header: %w", err)
+	}
+	if header.Version != captureVersion {
+		return nil, fmt.Errorf("unsupported capture version %d", header.Version)
+	}
+
+	client, err := New(Config{
+		APIKey:               "replay",
+		Feed:                 header.Feed,
+		Market:               header.Market,
+		RawData:              config.RawData,
+		BypassRawDataRouting: config.BypassRawDataRouting,
+		GapDetector:          config.GapDetector,
+		Log:                  config.Log,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Replayer{
+		client: client,
+		speed:  config.Speed,
+		r:      cr,
+		header: header,
+	}, nil
+}
+
+// OpenReplayer creates a replayer for the capture file at path.
+func OpenReplayer(path string, config ReplayConfig) (*Replayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open capture file: %w", err)
+	}
+	r, err := NewReplayer(f, config)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	r.closer = f
+	return r, nil
+}
+
+// Feed returns the data feed the capture was recorded from.
+func (r *Replayer) Feed() Feed {
+	return r.header.Feed
+}
+
+// Market returns the market the capture was recorded from.
+func (r *Replayer) Market() Market {
+	return r.header.Market
+}
+
+// Start starts playing back the capture. The output channel is closed once the end
+// of the capture is reached. Calling it again does nothing.
+func (r *Replayer) Start() {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.started {
+		return
+	}
+	r.started = true
+	r.client.ptomb.Go(r.run)
+}
+
+// Output returns the output queue.
+func (r *Replayer) Output() <-chan any {
+	return r.client.output
+}
+
+// Wait blocks until the playback ends and returns the error that stopped it, if any.
+// It returns an error straight away if the playback hasn't been started.
+func (r *Replayer) Wait() error {
+	if !r.isStarted() {
+		return errors.New("replay hasn't been started")
+	}
+	return r.client.ptomb.Wait()
+}
+
+// Close stops the playback.
+func (r *Replayer) Close() error {
+	var err error
+	if r.isStarted() {
+		r.client.ptomb.Kill(nil)
+		err = r.client.ptomb.Wait()
+	}
+	if r.closer != nil {
+		_ = r.closer.Close()
+	}
+	return err
+}
+
+func (r *Replayer) isStarted() bool {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return r.started
+}
+
+func (r *Replayer) run() error {
+	c := r.client
+	defer c.closeOutput()
+
+	var first, start time.Time
+	for {
+		ts, data, err := r.r.next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if r.speed > 0 {
+			if first.IsZero() {
+				first, start = ts, time.Now()
+			}
+			at := start.Add(time.Duration(float64(ts.Sub(first)) / r.speed))
+			if wait := time.Until(at); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-c.ptomb.Dying():
+					timer.Stop()
+					return nil
+				case <-timer.C:
+				}
+			}
+		}
+
+		select {
+		case <-c.ptomb.Dying():
+			return nil
+		default:
+		}
+
+		if c.rawData && c.bypassRawDataRouting {
+			c.send(json.RawMessage(data))
+			continue
+		}
+
+		if err := c.route(data); err != nil {
+			return err
+		}
+	}
+}
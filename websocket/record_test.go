@@ -0,0 +1,185 @@
+package massivews
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/massive-com/client-go/v3/websocket/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func recordFrames(t *testing.T, market Market, start time.Time, step time.Duration, frames ...any) *bytes.Buffer {
+	var buf bytes.Buffer
+	r := NewRecorder(&buf)
+	assert.Nil(t, r.use(RealTime, market))
+	for i, f := range frames {
+		data, err := json.Marshal(f)
+		assert.Nil(t, err)
+		assert.Nil(t, r.record(start.Add(time.Duration(i)*step), data))
+	}
+	assert.Nil(t, r.Close())
+	return &buf
+}
+
+func TestReplay(t *testing.T) {
+	trade := models.EquityTrade{EventType: models.EventType{EventType: "T"}, Symbol: "AAPL", Price: 1.5, Size: 10}
+	agg := models.EquityAgg{EventType: models.EventType{EventType: "AM"}, Symbol: "AAPL", Open: 1.5}
+	status := models.ControlMessage{EventType: models.EventType{EventType: "status"}, Status: "auth_success"}
+	buf := recordFrames(t, Stocks, time.Now(), time.Hour, []any{status}, []any{trade, agg})
+
+	r, err := NewReplayer(buf, ReplayConfig{})
+	assert.Nil(t, err)
+	assert.Equal(t, RealTime, r.Feed())
+	assert.Equal(t, Stocks, r.Market())
+
+	// as fast as possible, with the same output types as a live client; starting
+	// twice doesn't replay the capture twice
+	r.Start()
+	r.Start()
+	var out []any
+	for msg := range r.Output() {
+		out = append(out, msg)
+	}
+	assert.Nil(t, r.Wait())
+	assert.Equal(t, []any{trade, agg}, out)
+}
+
+func TestReplaySpeed(t *testing.T) {
+	trade := models.CryptoTrade{EventType: models.EventType{EventType: "XT"}, Pair: "BTC-USD", Price: 100}
+	buf := recordFrames(t, Crypto, time.Now(), 400*time.Millisecond, []any{trade}, []any{trade})
+
+	r, err := NewReplayer(buf, ReplayConfig{Speed: 4})
+	assert.Nil(t, err)
+	start := time.Now()
+	r.Start()
+	assert.Equal(t, trade, <-r.Output())
+	assert.Equal(t, trade, <-r.Output())
+	elapsed := time.Since(start)
+	assert.GreaterOrEqual(t, elapsed, 100*time.Millisecond)
+	assert.Less(t, elapsed, 300*time.Millisecond)
+	assert.Nil(t, r.Wait())
+}
+
+func TestReplayClose(t *testing.T) {
+	trade := models.CryptoTrade{EventType: models.EventType{EventType: "XT"}, Pair: "BTC-USD"}
+	buf := recordFrames(t, Crypto, time.Now(), time.Hour, []any{trade}, []any{trade})
+
+	r, err := NewReplayer(buf, ReplayConfig{Speed: 1})
+	assert.Nil(t, err)
+	r.Start()
+	assert.Equal(t, trade, <-r.Output())
+	assert.Nil(t, r.Close())
+	_, more := <-r.Output()
+	assert.False(t, more)
+}
+
+func TestReplayInvalid(t *testing.T) {
+	_, err := NewReplayer(strings.NewReader("not a capture"), ReplayConfig{})
+	assert.NotNil(t, err)
+
+	// a recorder that was never used by a client has no header
+	var buf bytes.Buffer
+	assert.Nil(t, NewRecorder(&buf).Close())
+	_, err = NewReplayer(&buf, ReplayConfig{})
+	assert.NotNil(t, err)
+
+	_, err = NewReplayer(&bytes.Buffer{}, ReplayConfig{Speed: -1})
+	assert.NotNil(t, err)
+}
+
+func TestReplayEmpty(t *testing.T) {
+	// a capture without frames still has a header
+	r, err := NewReplayer(recordFrames(t, Crypto, time.Now(), 0), ReplayConfig{})
+	assert.Nil(t, err)
+	assert.Equal(t, Crypto, r.Market())
+
+	// waiting before starting doesn't block
+	assert.NotNil(t, r.Wait())
+
+	r.Start()
+	_, more := <-r.Output()
+	assert.False(t, more)
+	assert.Nil(t, r.Wait())
+}
+
+func TestRecordClient(t *testing.T) {
+	s := newServer()
+	defer s.Close()
+
+	path := filepath.Join(t.TempDir(), "capture.gz")
+	rec, err := CreateRecorder(path)
+	assert.Nil(t, err)
+
+	var retries uint64 = 0
+	c, err := New(Config{
+		APIKey:     "good",
+		Feed:       Feed(s.URL()),
+		Market:     Stocks,
+		MaxRetries: &retries,
+		Recorder:   rec,
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, c.Connect())
+	assert.Equal(t, Connecting, nextState(t, c).State)
+	assert.Equal(t, Authenticated, nextState(t, c).State)
+	c.Close()
+	assert.Nil(t, rec.Close())
+
+	// the auth response was recorded
+	r, err := OpenReplayer(path, ReplayConfig{RawData: true, BypassRawDataRouting: true})
+	assert.Nil(t, err)
+	assert.Equal(t, Stocks, r.Market())
+	r.Start()
+	var frames []string
+	for out := range r.Output() {
+		frames = append(frames, string(out.(json.RawMessage)))
+	}
+	assert.Contains(t, strings.Join(frames, ""), "auth_success")
+	assert.Nil(t, r.Wait())
+	assert.Nil(t, r.Close())
+}
+
+func TestReplayCloseFullOutput(t *testing.T) {
+	trade := models.EquityTrade{EventType: models.EventType{EventType: "T"}, Symbol: "AAPL"}
+	for _, config := range []ReplayConfig{{}, {RawData: true}, {RawData: true, BypassRawDataRouting: true}} {
+		r, err := NewReplayer(recordFrames(t, Stocks, time.Now(), 0, []any{trade}), config)
+		assert.Nil(t, err)
+
+		// closing doesn't wait for a full output channel to be read
+		for len(r.client.output) < cap(r.client.output) {
+			r.client.output <- nil
+		}
+		r.Start()
+		done := make(chan struct{})
+		go func() {
+			assert.Nil(t, r.Close())
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("timed out closing the replayer")
+		}
+	}
+}
+
+func TestRecorderSharing(t *testing.T) {
+	rec := NewRecorder(&bytes.Buffer{})
+	_, err := New(Config{APIKey: "test", Feed: RealTime, Market: Stocks, Recorder: rec})
+	assert.Nil(t, err)
+	_, err = New(Config{APIKey: "test", Feed: RealTime, Market: Stocks, Recorder: rec})
+	assert.Nil(t, err)
+
+	_, err = New(Config{APIKey: "test", Feed: RealTime, Market: Crypto, Recorder: rec})
+	assert.ErrorContains(t, err, "recorder is already used")
+	_, err = New(Config{APIKey: "test", Feed: Delayed, Market: Stocks, Recorder: rec})
+	assert.NotNil(t, err)
+
+	// the shards of a sharded client can't interleave their frames in one capture
+	_, err = NewSharded(Config{APIKey: "test", Feed: RealTime, Market: Stocks, Recorder: rec}, ShardConfig{Shards: 2})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,148 @@
+package massivews
+
+import (
+	"encoding/json"
+	"sync"
+	"sync/atomic"
+
+	"github.com/massive-com/client-go/v3/websocket/internal/spec"
+	"github.com/massive-com/client-go/v3/websocket/models"
+)
+
+// Decoder decodes a data message of a given event type into the value that is
+// pushed to the output channel.
+type Decoder func(msg []byte) (any, error)
+
+// JSONDecoder returns a decoder that unmarshals messages into a value of type T.
+func JSONDecoder[T any]() Decoder {
+	return func(msg []byte) (any, error) {
+		var out T
+		if err := json.Unmarshal(msg, &out); err != nil {
+			return nil, err
+		}
+		return out, nil
+	}
+}
+
+// registry maps a market and event type to its decoder. It's copied on every
+// registration so lookups don't need a lock.
+type registry map[Market]map[string]Decoder
+
+var (
+	registryMtx sync.Mutex
+	events      atomic.Pointer[registry]
+)
+
+func init() {
+	r := make(registry)
+	for _, t := range spec.All {
+		market := Market(t.Market)
+		markets := []Market{market}
+		if market == Futures {
+			markets = append(markets, FuturesCME, FuturesCBOT, FuturesNYMEX, FuturesCOMEX)
+		}
+		for _, m := range markets {
+			if r[m] == nil {
+				r[m] = make(map[string]Decoder)
+			}
+			r[m][t.EventType] = defaultDecoder(market, t.EventType)
+		}
+	}
+	events.Store(&r)
+}
+
+// defaultDecoder returns the decoder for an event type in the topic matrix.
+func defaultDecoder(market Market, eventType string) Decoder {
+	switch eventType {
+	case "A", "AM":
+		switch market {
+		case Futures:
+			return JSONDecoder[models.FuturesAggregate]()
+		case Forex, Crypto:
+			return decodeLaunchpadCurrencyAgg
+		}
+		return JSONDecoder[models.EquityAgg]()
+	case "T":
+		if market == Futures {
+			return JSONDecoder[models.FuturesTrade]()
+		}
+		return JSONDecoder[models.EquityTrade]()
+	case "Q":
+		if market == Futures {
+			return JSONDecoder[models.FuturesQuote]()
+		}
+		return JSONDecoder[models.EquityQuote]()
+	case "NOI":
+		return JSONDecoder[models.Imbalance]()
+	case "LULD":
+		return JSONDecoder[models.LimitUpLimitDown]()
+	case "CA", "CAS", "XA", "XAS":
+		return JSONDecoder[models.CurrencyAgg]()
+	case "C":
+		return JSONDecoder[models.ForexQuote]()
+	case "XT":
+		return JSONDecoder[models.CryptoTrade]()
+	case "XQ":
+		return JSONDecoder[models.CryptoQuote]()
+	case "XL2":
+		return JSONDecoder[models.Level2Book]()
+	case "V":
+		return JSONDecoder[models.IndexValue]()
+	case "LV":
+		return JSONDecoder[models.LaunchpadValue]()
+	case "FMV":
+		return JSONDecoder[models.FairMarketValue]()
+	}
+	return JSONDecoder[json.RawMessage]()
+}
+
+// decodeLaunchpadCurrencyAgg decodes a Launchpad aggregate of a forex or crypto pair
+// into a CurrencyAgg like the other aggregates of those markets. Launchpad sends the
+// pair in the sym field that stock aggregates use.
+func decodeLaunchpadCurrencyAgg(msg []byte) (any, error) {
+	var out struct {
+		models.CurrencyAgg
+		Symbol string `json:"sym"`
+	}
+	if err := json.Unmarshal(msg, &out); err != nil {
+		return nil, err
+	}
+	if out.Pair == "" {
+		out.Pair = out.Symbol
+	}
+	return out.CurrencyAgg, nil
+}
+
+// RegisterEvent registers the decoder for an event type (e.g. "T") in a market,
+// replacing the existing one if there is one. It can be used to decode event types
+// the client doesn't know about yet or to decode known ones into custom types.
+// Decoders apply to every client, including ones that are already connected.
+func RegisterEvent(market Market, eventType string, decoder Decoder) {
+	registryMtx.Lock()
+	defer registryMtx.Unlock()
+
+	old := *events.Load()
+	r := make(registry, len(old)+1)
+	for m, decoders := range old {
+		r[m] = decoders
+	}
+
+	decoders := make(map[string]Decoder, len(r[market])+1)
+	for ev, d := range r[market] {
+		decoders[ev] = d
+	}
+	decoders[eventType] = decoder
+	r[market] = decoders
+
+	events.Store(&r)
+}
+
+// lookupEvent returns the decoder for an event type in a market. It also reports
+// whether the market has any decoders at all.
+func lookupEvent(market Market, eventType string) (decoder Decoder, knownMarket bool) {
+	decoders, ok := (*events.Load())[market]
+	if !ok {
+		return nil, false
+	}
+	return decoders[eventType], true
+}
@@ -0,0 +1,87 @@
+package massivews
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/massive-com/client-go/v3/websocket/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type customTrade struct {
+	Symbol string  `json:"sym"`
+	Price  float64 `json:"p"`
+}
+
+// restoreEvents undoes any registrations made by a test.
+func restoreEvents(t *testing.T) {
+	r := events.Load()
+	t.Cleanup(func() { events.Store(r) })
+}
+
+func TestRegisterEvent(t *testing.T) {
+	restoreEvents(t)
+	c, err := New(Config{APIKey: "test", Feed: RealTime, Market: Stocks})
+	assert.Nil(t, err)
+
+	// unknown event types are dropped
+	assert.Nil(t, c.route([]byte(`[{"ev":"NEW","sym":"AAPL"}]`)))
+	assert.Len(t, c.Output(), 0)
+
+	// new event types
+	RegisterEvent(Stocks, "NEW", JSONDecoder[customTrade]())
+	assert.Nil(t, c.route([]byte(`[{"ev":"NEW","sym":"AAPL","p":1.5}]`)))
+	assert.Equal(t, customTrade{Symbol: "AAPL", Price: 1.5}, <-c.Output())
+
+	// overriding existing ones
+	RegisterEvent(Stocks, "T", JSONDecoder[customTrade]())
+	assert.Nil(t, c.route([]byte(`[{"ev":"T","sym":"AAPL","p":2}]`)))
+	assert.Equal(t, customTrade{Symbol: "AAPL", Price: 2}, <-c.Output())
+
+	// other markets are unaffected
+	decode, _ := lookupEvent(Options, "T")
+	out, err := decode([]byte(`{"ev":"T","sym":"O:A230616C00070000","p":2}`))
+	assert.Nil(t, err)
+	assert.IsType(t, models.EquityTrade{}, out)
+}
+
+func TestRegisterEventMarket(t *testing.T) {
+	restoreEvents(t)
+	market := Market("custom")
+	c, err := New(Config{APIKey: "test", Feed: RealTime, Market: market})
+	assert.Nil(t, err)
+
+	_, known := lookupEvent(market, "T")
+	assert.False(t, known)
+
+	RegisterEvent(market, "T", func(msg []byte) (any, error) {
+		var m map[string]any
+		err := json.Unmarshal(msg, &m)
+		return m, err
+	})
+	assert.Nil(t, c.route([]byte(`[{"ev":"T","x":1}]`)))
+	assert.Equal(t, map[string]any{"ev": "T", "x": float64(1)}, <-c.Output())
+
+	// decode errors are dropped
+	assert.Nil(t, c.route([]byte(`[{"ev":"T","x":}]`)))
+	assert.Len(t, c.Output(), 0)
+}
+
+func TestDefaultEvents(t *testing.T) {
+	for market, types := range map[Market]map[string]any{
+		Stocks:     {"A": models.EquityAgg{}, "AM": models.EquityAgg{}, "T": models.EquityTrade{}, "Q": models.EquityQuote{}, "LULD": models.LimitUpLimitDown{}, "NOI": models.Imbalance{}, "FMV": models.FairMarketValue{}, "LV": models.LaunchpadValue{}},
+		Options:    {"A": models.EquityAgg{}, "AM": models.EquityAgg{}, "T": models.EquityTrade{}, "Q": models.EquityQuote{}, "FMV": models.FairMarketValue{}, "LV": models.LaunchpadValue{}},
+		Forex:      {"CA": models.CurrencyAgg{}, "CAS": models.CurrencyAgg{}, "C": models.ForexQuote{}, "FMV": models.FairMarketValue{}, "LV": models.LaunchpadValue{}},
+		Crypto:     {"XA": models.CurrencyAgg{}, "XAS": models.CurrencyAgg{}, "XT": models.CryptoTrade{}, "XQ": models.CryptoQuote{}, "XL2": models.Level2Book{}, "FMV": models.FairMarketValue{}, "LV": models.LaunchpadValue{}},
+		Indices:    {"A": models.EquityAgg{}, "AM": models.EquityAgg{}, "V": models.IndexValue{}},
+		FuturesCME: {"A": models.FuturesAggregate{}, "AM": models.FuturesAggregate{}, "T": models.FuturesTrade{}, "Q": models.FuturesQuote{}},
+	} {
+		for ev, typ := range types {
+			decode, known := lookupEvent(market, ev)
+			assert.True(t, known)
+			out, err := decode([]byte(`{"ev":"` + ev + `"}`))
+			assert.Nil(t, err)
+			assert.IsType(t, typ, out, "%v %v", market, ev)
+		}
+	}
+}
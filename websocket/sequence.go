@@ -0,0 +1,222 @@
+package massivews
+
+import (
+	"sync"
+
+	"github.com/massive-com/client-go/v3/websocket/models"
+)
+
+// SequenceEventKind is the kind of sequence anomaly reported by a GapDetector.
+type SequenceEventKind int
+
+const (
+	// SequenceGap means the sequence number jumped by more than the gap threshold.
+	// It's only reported if GapDetectorConfig.GapThreshold is set.
+	SequenceGap SequenceEventKind = iota + 1
+
+	// SequenceOutOfOrder means the sequence number is lower than the last one seen.
+	SequenceOutOfOrder
+
+	// SequenceDuplicate means the sequence number is equal to the last one seen.
+	SequenceDuplicate
+)
+
+func (k SequenceEventKind) String() string {
+	switch k {
+	case SequenceGap:
+		return "gap"
+	case SequenceOutOfOrder:
+		return "out_of_order"
+	case SequenceDuplicate:
+		return "duplicate"
+	}
+	return "unknown"
+}
+
+// SequenceEvent is a suspected gap, out of order or duplicate message.
+type SequenceEvent struct {
+	// Kind is the kind of anomaly.
+	Kind SequenceEventKind
+
+	// EventType is the event type of the message (e.g. T, Q).
+	EventType string
+
+	// Symbol is the ticker symbol of the message.
+	Symbol string
+
+	// Last is the last sequence number seen for the symbol.
+	Last int64
+
+	// Received is the sequence number of the message that triggered the event.
+	Received int64
+
+	// AfterReconnect is true if this is the first message for the symbol since the
+	// client reconnected.
+	AfterReconnect bool
+}
+
+// SequenceStats is a snapshot of the counters kept by a GapDetector.
+type SequenceStats struct {
+	// Messages is the number of messages with a sequence number that were observed.
+	Messages uint64
+
+	// Gaps is the number of suspected gaps. It stays zero unless a gap threshold is set.
+	Gaps uint64
+
+	// Missing is the total number of sequence numbers skipped by suspected gaps.
+	Missing uint64
+
+	// OutOfOrder is the number of messages that arrived with a lower sequence number.
+	OutOfOrder uint64
+
+	// Duplicates is the number of messages that repeated the last sequence number.
+	Duplicates uint64
+
+	// Dropped is the number of events that were dropped because the event channel was full.
+	Dropped uint64
+}
+
+// GapDetectorConfig is a set of gap detector options.
+type GapDetectorConfig struct {
+	// GapThreshold is the largest increase in sequence number that is not reported as
+	// a gap. Sequence numbers are increasing and unique per symbol but they are not
+	// consecutive: the feed skips numbers in normal operation, so how far apart two
+	// messages can be depends on the feed and symbol. Omitting this disables gap
+	// reporting and the detector only reports out of order and duplicate messages.
+	// Setting it to 1 reports every non-consecutive sequence number.
+	GapThreshold int64
+
+	// EventBuffer is the size of the event channel. Events are dropped and counted
+	// when the channel is full. Omitting this uses a buffer of 1000 events.
+	EventBuffer int
+}
+
+// GapDetector tracks the last sequence number per event type and symbol for trades
+// and quotes and reports suspected gaps, out of order and duplicate messages. Set
+// it on Config.GapDetector to have the client feed it every decoded message.
+type GapDetector struct {
+	threshold int64
+
+	mtx    sync.Mutex
+	epoch  uint64
+	last   map[sequenceKey]sequenceState
+	stats  SequenceStats
+	events chan SequenceEvent
+}
+
+type sequenceKey struct {
+	eventType string
+	symbol    string
+}
+
+type sequenceState struct {
+	seq   int64
+	epoch uint64
+}
+
+// NewGapDetector creates a gap detector.
+func NewGapDetector(config GapDetectorConfig) *GapDetector {
+	if config.EventBuffer < 1 {
+		config.EventBuffer = 1000
+	}
+
+	return &GapDetector{
+		threshold: config.GapThreshold,
+		last:      make(map[sequenceKey]sequenceState),
+		events:    make(chan SequenceEvent, config.EventBuffer),
+	}
+}
+
+// Events returns the event channel.
+func (d *GapDetector) Events() <-chan SequenceEvent {
+	return d.events
+}
+
+// Stats returns a snapshot of the detector's counters.
+func (d *GapDetector) Stats() SequenceStats {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	return d.stats
+}
+
+// Reset forgets every sequence number seen so far. Counters are kept.
+func (d *GapDetector) Reset() {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.last = make(map[sequenceKey]sequenceState)
+}
+
+// Observe checks the sequence number of a message. Messages without a sequence
+// number are ignored. It returns the event that was reported, if any.
+func (d *GapDetector) Observe(msg any) (SequenceEvent, bool) {
+	var key sequenceKey
+	var seq int64
+	switch m := msg.(type) {
+	case models.EquityTrade:
+		key, seq = sequenceKey{m.EventType.EventType, m.Symbol}, m.SequenceNumber
+	case models.EquityQuote:
+		key, seq = sequenceKey{m.EventType.EventType, m.Symbol}, m.SequenceNumber
+	case models.LimitUpLimitDown:
+		key, seq = sequenceKey{m.EventType.EventType, m.Symbol}, m.SequenceNumber
+	case models.FuturesTrade:
+		key, seq = sequenceKey{m.EventType.EventType, m.Symbol}, m.SequenceNumber
+	default:
+		return SequenceEvent{}, false
+	}
+	if seq == 0 {
+		return SequenceEvent{}, false
+	}
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	d.stats.Messages++
+	prev, ok := d.last[key]
+	if !ok {
+		d.last[key] = sequenceState{seq: seq, epoch: d.epoch}
+		return SequenceEvent{}, false
+	}
+
+	ev := SequenceEvent{
+		EventType:      key.eventType,
+		Symbol:         key.symbol,
+		Last:           prev.seq,
+		Received:       seq,
+		AfterReconnect: prev.epoch != d.epoch,
+	}
+	switch {
+	case seq == prev.seq:
+		ev.Kind = SequenceDuplicate
+		d.stats.Duplicates++
+	case seq < prev.seq:
+		ev.Kind = SequenceOutOfOrder
+		d.stats.OutOfOrder++
+	case d.threshold > 0 && seq-prev.seq > d.threshold:
+		ev.Kind = SequenceGap
+		d.stats.Gaps++
+		d.stats.Missing += uint64(seq - prev.seq - 1)
+	}
+
+	// keep the highest sequence number so a late message doesn't hide a later gap
+	if seq > prev.seq || prev.epoch != d.epoch {
+		d.last[key] = sequenceState{seq: max(seq, prev.seq), epoch: d.epoch}
+	}
+
+	if ev.Kind == 0 {
+		return SequenceEvent{}, false
+	}
+	select {
+	case d.events <- ev:
+	default:
+		d.stats.Dropped++
+	}
+	return ev, true
+}
+
+// reconnected marks the start of a new connection so that the next message for
+// every symbol is flagged as arriving after a reconnect.
+func (d *GapDetector) reconnected() {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	d.epoch++
+}
@@ -0,0 +1,129 @@
+package massivews
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/massive-com/client-go/v3/websocket/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func seqTrade(sym string, seq int64) models.EquityTrade {
+	return models.EquityTrade{EventType: models.EventType{EventType: "T"}, Symbol: sym, SequenceNumber: seq}
+}
+
+func TestGapDetector(t *testing.T) {
+	d := NewGapDetector(GapDetectorConfig{GapThreshold: 1})
+
+	// the first message for a symbol is never an anomaly
+	_, ok := d.Observe(seqTrade("AAPL", 10))
+	assert.False(t, ok)
+	_, ok = d.Observe(seqTrade("AAPL", 11))
+	assert.False(t, ok)
+
+	ev, ok := d.Observe(seqTrade("AAPL", 15))
+	assert.True(t, ok)
+	assert.Equal(t, SequenceEvent{Kind: SequenceGap, EventType: "T", Symbol: "AAPL", Last: 11, Received: 15}, ev)
+
+	ev, ok = d.Observe(seqTrade("AAPL", 15))
+	assert.True(t, ok)
+	assert.Equal(t, SequenceDuplicate, ev.Kind)
+
+	ev, ok = d.Observe(seqTrade("AAPL", 13))
+	assert.True(t, ok)
+	assert.Equal(t, SequenceOutOfOrder, ev.Kind)
+	assert.Equal(t, int64(15), ev.Last)
+
+	// a late message doesn't move the last sequence number backwards
+	_, ok = d.Observe(seqTrade("AAPL", 16))
+	assert.False(t, ok)
+
+	// symbols and event types are tracked separately
+	_, ok = d.Observe(seqTrade("TSLA", 1))
+	assert.False(t, ok)
+	_, ok = d.Observe(models.EquityQuote{EventType: models.EventType{EventType: "Q"}, Symbol: "AAPL", SequenceNumber: 100})
+	assert.False(t, ok)
+
+	// messages without sequence numbers are ignored
+	_, ok = d.Observe(models.EquityTrade{Symbol: "AAPL"})
+	assert.False(t, ok)
+	_, ok = d.Observe(models.CryptoTrade{Pair: "BTC-USD"})
+	assert.False(t, ok)
+
+	assert.Equal(t, SequenceStats{Messages: 8, Gaps: 1, Missing: 3, OutOfOrder: 1, Duplicates: 1}, d.Stats())
+	assert.Len(t, d.Events(), 3)
+}
+
+func TestGapDetectorNoThreshold(t *testing.T) {
+	d := NewGapDetector(GapDetectorConfig{})
+
+	// without a threshold, jumps in sequence numbers are normal
+	d.Observe(seqTrade("AAPL", 1))
+	_, ok := d.Observe(seqTrade("AAPL", 1000))
+	assert.False(t, ok)
+
+	// but late and repeated messages are still reported
+	ev, ok := d.Observe(seqTrade("AAPL", 500))
+	assert.True(t, ok)
+	assert.Equal(t, SequenceOutOfOrder, ev.Kind)
+	ev, ok = d.Observe(seqTrade("AAPL", 1000))
+	assert.True(t, ok)
+	assert.Equal(t, SequenceDuplicate, ev.Kind)
+
+	assert.Equal(t, SequenceStats{Messages: 4, OutOfOrder: 1, Duplicates: 1}, d.Stats())
+}
+
+func TestGapDetectorThreshold(t *testing.T) {
+	d := NewGapDetector(GapDetectorConfig{GapThreshold: 5, EventBuffer: 1})
+
+	d.Observe(seqTrade("AAPL", 1))
+	_, ok := d.Observe(seqTrade("AAPL", 6))
+	assert.False(t, ok)
+	_, ok = d.Observe(seqTrade("AAPL", 12))
+	assert.True(t, ok)
+
+	// the event buffer is full so this one is dropped but still returned
+	_, ok = d.Observe(seqTrade("AAPL", 12))
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), d.Stats().Dropped)
+}
+
+func TestGapDetectorReconnect(t *testing.T) {
+	d := NewGapDetector(GapDetectorConfig{GapThreshold: 1})
+	d.Observe(seqTrade("AAPL", 1))
+	d.Observe(seqTrade("TSLA", 1))
+	d.reconnected()
+
+	ev, ok := d.Observe(seqTrade("AAPL", 5))
+	assert.True(t, ok)
+	assert.True(t, ev.AfterReconnect)
+
+	// only the first message after a reconnect is flagged
+	ev, ok = d.Observe(seqTrade("AAPL", 7))
+	assert.True(t, ok)
+	assert.False(t, ev.AfterReconnect)
+
+	ev, ok = d.Observe(seqTrade("TSLA", 1))
+	assert.True(t, ok)
+	assert.Equal(t, SequenceDuplicate, ev.Kind)
+	assert.True(t, ev.AfterReconnect)
+
+	d.Reset()
+	_, ok = d.Observe(seqTrade("AAPL", 1))
+	assert.False(t, ok)
+}
+
+func TestGapDetectorClient(t *testing.T) {
+	d := NewGapDetector(GapDetectorConfig{GapThreshold: 1})
+	c, err := New(Config{APIKey: "test", Feed: RealTime, Market: Stocks, GapDetector: d})
+	assert.Nil(t, err)
+
+	var msgs []models.EquityTrade
+	for _, seq := range []int64{1, 2, 4} {
+		msgs = append(msgs, seqTrade("AAPL", seq))
+	}
+	frame, _ := json.Marshal(msgs)
+	assert.Nil(t, c.route(frame))
+	assert.Len(t, c.Output(), 3)
+	assert.Equal(t, uint64(1), d.Stats().Gaps)
+}
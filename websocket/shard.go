@@ -0,0 +1,370 @@
+package massivews
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/exp/maps"
+	"golang.org/x/exp/slices"
+)
+
+// ShardConfig is a set of options for spreading subscriptions across connections.
+type ShardConfig struct {
+	// Shards is the number of connections to open. It must be at least 1.
+	Shards int
+
+	// Assign optionally maps a topic and ticker to a shard index in the range
+	// [0, Shards). Returning an out of range index is an error. Omitting this will
+	// assign tickers to shards by hashing the ticker symbol.
+	Assign func(topic Topic, ticker string) int
+}
+
+// ShardStats is a snapshot of the throughput of a single shard.
+type ShardStats struct {
+	// Shard is the index of the shard.
+	Shard int
+
+	// Messages is the total number of messages received on the shard.
+	Messages uint64
+
+	// MessagesPerSecond is the number of messages received during the last second.
+	MessagesPerSecond uint64
+
+	// Subscriptions is the number of tickers currently subscribed on the shard.
+	Subscriptions int
+}
+
+// ShardedClient spreads a large set of tickers for a single feed and market across
+// several connections. Each connection reconnects independently and their output is
+// merged into a single stream, which keeps the message rate of each socket below
+// what a single read thread can keep up with. Only explicit tickers are spread; a
+// wildcard subscription always lands on a single shard.
+type ShardedClient struct {
+	assign func(topic Topic, ticker string) int
+	shards []*shard
+
+	mtx     sync.Mutex
+	started bool
+	closed  bool
+
+	wg     sync.WaitGroup
+	done   chan struct{}
+	output chan any
+	err    chan error
+}
+
+type shard struct {
+	client   *Client
+	messages atomic.Uint64
+	last     uint64
+	rate     atomic.Uint64
+}
+
+// NewSharded creates a sharded client. Every shard uses the same config, so it
+// can't have a gap detector or recorder since those keep per-connection state.
+func NewSharded(config Config, shards ShardConfig) (*ShardedClient, error) {
+	if shards.Shards < 1 {
+		return nil, errors.New("invalid shard options: at least one shard is required")
+	}
+	if config.GapDetector != nil || config.Recorder != nil {
+		return nil, errors.New("invalid shard options: a gap detector or recorder can't be shared by every shard")
+	}
+
+	s := &ShardedClient{
+		assign: shards.Assign,
+		done:   make(chan struct{}),
+		output: make(chan any, 100000),
+		err:    make(chan error, errQueueSize),
+	}
+	if s.assign == nil {
+		n := uint32(shards.Shards)
+		s.assign = func(_ Topic, ticker string) int {
+			h := fnv.New32a()
+			_, _ = h.Write([]byte(ticker))
+			return int(h.Sum32() % n)
+		}
+	}
+
+	for i := 0; i < shards.Shards; i++ {
+		c, err := New(config)
+		if err != nil {
+			return nil, err
+		}
+		s.shards = append(s.shards, &shard{client: c})
+	}
+
+	return s, nil
+}
+
+// Connect starts merging the output of the shards and dials every shard.
+func (s *ShardedClient) Connect() error {
+	if err := s.start(); err != nil {
+		return err
+	}
+	for i, sh := range s.shards {
+		if err := sh.client.Connect(); err != nil {
+			return fmt.Errorf("failed to connect shard %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// start starts forwarding the output of every shard and sampling their message rates.
+// Calling it again does nothing.
+func (s *ShardedClient) start() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.closed {
+		return errors.New("sharded client is closed")
+	}
+	if s.started {
+		return nil
+	}
+	s.started = true
+
+	for i, sh := range s.shards {
+		s.wg.Add(1)
+		go s.forward(i, sh)
+	}
+	go s.sample()
+	return nil
+}
+
+// Subscribe groups the tickers by shard and subscribes each group on its shard, in
+// shard order. If a shard fails, the tickers this call added to the shards before
+// it (and to the failing one) are unsubscribed again so that a failed call leaves
+// the subscriptions as they were; the error says if that rollback failed too.
+//
+// A wildcard subscription can't be split, so it's sent to the single shard the
+// wildcard is assigned to and every message of the topic arrives on that one
+// connection. To spread a busy topic, subscribe to its tickers explicitly.
+func (s *ShardedClient) Subscribe(topic Topic, tickers ...string) error {
+	if len(tickers) == 0 || slices.Contains(tickers, "*") {
+		tickers = []string{"*"}
+	}
+
+	groups, err := s.group(topic, tickers)
+	if err != nil {
+		return err
+	}
+
+	added := make(map[int][]string)
+	for _, i := range sortedShards(groups) {
+		existing := s.shards[i].subscriptions()[topic]
+		for _, t := range groups[i] {
+			if _, ok := existing[t]; !ok {
+				added[i] = append(added[i], t)
+			}
+		}
+
+		if err := s.shards[i].client.Subscribe(topic, groups[i]...); err != nil {
+			err = fmt.Errorf("shard %d: %w", i, err)
+			if rerr := s.rollback(topic, added); rerr != nil {
+				err = errors.Join(err, fmt.Errorf("failed to roll back subscriptions: %w", rerr))
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rollback unsubscribes the tickers that a failed Subscribe call added to each shard.
+func (s *ShardedClient) rollback(topic Topic, added map[int][]string) error {
+	var errs []error
+	for _, i := range sortedShards(added) {
+		subs := s.shards[i].subscriptions()[topic]
+		var tickers []string
+		for _, t := range added[i] {
+			if _, ok := subs[t]; ok {
+				tickers = append(tickers, t)
+			}
+		}
+		if len(tickers) == 0 {
+			continue
+		}
+		if err := s.shards[i].client.Unsubscribe(topic, tickers...); err != nil {
+			errs = append(errs, fmt.Errorf("shard %d: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Unsubscribe groups the tickers by shard and unsubscribes each group on its shard.
+// If no tickers are passed, every shard unsubscribes from all tickers for the topic.
+func (s *ShardedClient) Unsubscribe(topic Topic, tickers ...string) error {
+	if len(tickers) == 0 || slices.Contains(tickers, "*") {
+		for i, sh := range s.shards {
+			if _, ok := sh.subscriptions()[topic]; !ok {
+				continue
+			}
+			if err := sh.client.Unsubscribe(topic); err != nil {
+				return fmt.Errorf("shard %d: %w", i, err)
+			}
+		}
+		return nil
+	}
+
+	groups, err := s.group(topic, tickers)
+	if err != nil {
+		return err
+	}
+	for i, group := range groups {
+		if err := s.shards[i].client.Unsubscribe(topic, group...); err != nil {
+			return fmt.Errorf("shard %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// Stats returns a throughput snapshot for every shard.
+func (s *ShardedClient) Stats() []ShardStats {
+	stats := make([]ShardStats, 0, len(s.shards))
+	for i, sh := range s.shards {
+		var subs int
+		for _, tickers := range sh.subscriptions() {
+			subs += len(tickers)
+		}
+		stats = append(stats, ShardStats{
+			Shard:             i,
+			Messages:          sh.messages.Load(),
+			MessagesPerSecond: sh.rate.Load(),
+			Subscriptions:     subs,
+		})
+	}
+	return stats
+}
+
+// Output returns the merged output queue.
+func (s *ShardedClient) Output() <-chan any {
+	return s.output
+}
+
+// Error returns the merged error channel. Errors are wrapped with the index of the
+// shard that failed. Like Manager.Error, errors that don't fit in the buffer are
+// logged and dropped.
+func (s *ShardedClient) Error() <-chan error {
+	return s.err
+}
+
+// Close closes every shard and then closes the merged output channel.
+func (s *ShardedClient) Close() {
+	s.mtx.Lock()
+	if s.closed {
+		s.mtx.Unlock()
+		return
+	}
+	s.closed = true
+	s.mtx.Unlock()
+
+	// the shards are closed before forwarding stops so that errors raised while they
+	// shut down are still read
+	for _, sh := range s.shards {
+		sh.client.Close()
+	}
+	close(s.done)
+	s.wg.Wait()
+	close(s.output)
+}
+
+// sortedShards returns the shard indexes of a grouping in order.
+func sortedShards(groups map[int][]string) []int {
+	shards := maps.Keys(groups)
+	slices.Sort(shards)
+	return shards
+}
+
+// group splits a set of tickers by the shard they are assigned to.
+func (s *ShardedClient) group(topic Topic, tickers []string) (map[int][]string, error) {
+	groups := make(map[int][]string)
+	for _, t := range tickers {
+		i := s.assign(topic, t)
+		if i < 0 || i >= len(s.shards) {
+			return nil, fmt.Errorf("ticker '%v' assigned to invalid shard %d", t, i)
+		}
+		groups[i] = append(groups[i], t)
+	}
+	return groups, nil
+}
+
+// forward pushes everything from a shard's output and error channels to the merged ones.
+func (s *ShardedClient) forward(i int, sh *shard) {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.done:
+			s.drainErr(i, sh)
+			return
+		case out, more := <-sh.client.Output():
+			if !more {
+				s.drainErr(i, sh)
+				return
+			}
+			sh.messages.Add(1)
+			select {
+			case s.output <- out:
+			case <-s.done:
+			}
+		case err := <-sh.client.Error():
+			s.pushErr(i, sh, err)
+		}
+	}
+}
+
+// drainErr forwards the error a closed shard pushed, if any.
+func (s *ShardedClient) drainErr(i int, sh *shard) {
+	select {
+	case err := <-sh.client.Error():
+		s.pushErr(i, sh, err)
+	default:
+	}
+}
+
+// pushErr pushes an error of a shard to the merged error channel, or drops it if the
+// channel is full.
+func (s *ShardedClient) pushErr(i int, sh *shard, err error) {
+	err = fmt.Errorf("shard %d: %w", i, err)
+	select {
+	case s.err <- err:
+	default:
+		sh.client.log.Errorf("error channel is full, dropping error: %v", err)
+	}
+}
+
+// sample updates the per-second message rate of every shard.
+func (s *ShardedClient) sample() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+			for _, sh := range s.shards {
+				n := sh.messages.Load()
+				sh.rate.Store(n - sh.last)
+				sh.last = n
+			}
+		}
+	}
+}
+
+// subscriptions returns a copy of the shard's subscriptions.
+func (sh *shard) subscriptions() subscriptions {
+	sh.client.mtx.Lock()
+	defer sh.client.mtx.Unlock()
+
+	subs := make(subscriptions, len(sh.client.subs))
+	for topic, tickers := range sh.client.subs {
+		subs.add(topic, maps.Keys(tickers)...)
+	}
+	return subs
+}
@@ -0,0 +1,169 @@
+package massivews
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/massive-com/client-go/v3/websocket/fakeserver"
+	"github.com/massive-com/client-go/v3/websocket/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSharded(t *testing.T) {
+	s, err := NewSharded(Config{APIKey: "test", Feed: RealTime, Market: Options}, ShardConfig{})
+	assert.Nil(t, s)
+	assert.NotNil(t, err)
+
+	s, err = NewSharded(Config{}, ShardConfig{Shards: 2})
+	assert.Nil(t, s)
+	assert.NotNil(t, err)
+
+	// stateful components can't be shared by every shard
+	s, err = NewSharded(Config{APIKey: "test", Feed: RealTime, Market: Stocks, GapDetector: NewGapDetector(GapDetectorConfig{})}, ShardConfig{Shards: 2})
+	assert.Nil(t, s)
+	assert.NotNil(t, err)
+	s, err = NewSharded(Config{APIKey: "test", Feed: RealTime, Market: Stocks, Recorder: NewRecorder(io.Discard)}, ShardConfig{Shards: 2})
+	assert.Nil(t, s)
+	assert.NotNil(t, err)
+}
+
+func TestShardedSubscribe(t *testing.T) {
+	s, err := NewSharded(Config{APIKey: "test", Feed: RealTime, Market: Stocks}, ShardConfig{
+		Shards: 2,
+		Assign: func(_ Topic, ticker string) int {
+			if ticker == "AAPL" || ticker == "*" {
+				return 0
+			}
+			return 1
+		},
+	})
+	assert.Nil(t, err)
+	defer s.Close()
+
+	err = s.Subscribe(StocksTrades, "AAPL", "TSLA", "NFLX")
+	assert.Nil(t, err)
+	assert.Equal(t, set{"AAPL": {}}, s.shards[0].client.subs[StocksTrades])
+	assert.Equal(t, set{"TSLA": {}, "NFLX": {}}, s.shards[1].client.subs[StocksTrades])
+
+	stats := s.Stats()
+	assert.Equal(t, 1, stats[0].Subscriptions)
+	assert.Equal(t, 2, stats[1].Subscriptions)
+
+	err = s.Unsubscribe(StocksTrades, "TSLA")
+	assert.Nil(t, err)
+	assert.Equal(t, set{"NFLX": {}}, s.shards[1].client.subs[StocksTrades])
+
+	// unsubscribing from everything clears every shard
+	err = s.Unsubscribe(StocksTrades)
+	assert.Nil(t, err)
+	assert.Empty(t, s.shards[0].client.subs)
+	assert.Empty(t, s.shards[1].client.subs)
+
+	// wildcards go to a single shard
+	err = s.Subscribe(StocksQuotes)
+	assert.Nil(t, err)
+	assert.Equal(t, set{"*": {}}, s.shards[0].client.subs[StocksQuotes])
+	assert.Empty(t, s.shards[1].client.subs)
+}
+
+func TestShardedSubscribeRollback(t *testing.T) {
+	server := fakeserver.New(fakeserver.Config{
+		Authorize: func(param string) bool { return param != "T.BAD" },
+	})
+	defer server.Close()
+
+	s, err := NewSharded(Config{APIKey: "test", Feed: Feed(server.URL()), Market: Stocks, SubscribeTimeout: 5 * time.Second}, ShardConfig{
+		Shards: 2,
+		Assign: func(_ Topic, ticker string) int {
+			if ticker == "BAD" {
+				return 1
+			}
+			return 0
+		},
+	})
+	assert.Nil(t, err)
+	defer s.Close()
+	assert.Nil(t, s.Connect())
+	assert.Nil(t, s.Subscribe(StocksTrades, "MSFT"))
+
+	// shard 0 succeeds and shard 1 is rejected, so only the new ticker on shard 0 is
+	// rolled back
+	err = s.Subscribe(StocksTrades, "AAPL", "MSFT", "BAD")
+	assert.ErrorContains(t, err, "shard 1")
+	assert.Equal(t, set{"MSFT": {}}, s.shards[0].subscriptions()[StocksTrades])
+	assert.Empty(t, s.shards[1].subscriptions())
+	assert.Eventually(t, func() bool {
+		subs := server.Subscriptions()
+		return len(subs) == 1 && subs[0] == "T.MSFT"
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestShardedAssignHash(t *testing.T) {
+	s, err := NewSharded(Config{APIKey: "test", Feed: RealTime, Market: Options}, ShardConfig{Shards: 4})
+	assert.Nil(t, err)
+	defer s.Close()
+
+	// the same ticker always lands on the same shard
+	for _, ticker := range []string{"O:SPY241220C00600000", "O:A230616C00070000", "O:TSLA250117P00100000"} {
+		i := s.assign(OptionsQuotes, ticker)
+		assert.True(t, i >= 0 && i < 4)
+		assert.Equal(t, i, s.assign(OptionsTrades, ticker))
+	}
+
+	err = s.Subscribe(OptionsQuotes, "O:SPY241220C00600000", "O:A230616C00070000", "O:TSLA250117P00100000")
+	assert.Nil(t, err)
+	var total int
+	for _, st := range s.Stats() {
+		total += st.Subscriptions
+	}
+	assert.Equal(t, 3, total)
+
+	// invalid assignments are rejected
+	s.assign = func(Topic, string) int { return 4 }
+	assert.NotNil(t, s.Subscribe(OptionsQuotes, "O:SPY241220C00600000"))
+}
+
+func TestShardedOutput(t *testing.T) {
+	server := fakeserver.New(fakeserver.Config{APIKey: "good"})
+	defer server.Close()
+
+	var retries uint64 = 0
+	s, err := NewSharded(Config{APIKey: "good", Feed: Feed(server.URL()), Market: Stocks, MaxRetries: &retries}, ShardConfig{Shards: 2})
+	assert.Nil(t, err)
+	assert.Nil(t, s.Connect())
+	assert.Nil(t, s.Connect()) // connecting twice doesn't forward twice
+
+	s.shards[0].client.output <- models.EquityTrade{Symbol: "AAPL"}
+	s.shards[1].client.output <- models.EquityTrade{Symbol: "TSLA"}
+	s.shards[1].client.output <- models.EquityTrade{Symbol: "NFLX"}
+
+	var got []string
+	for i := 0; i < 3; i++ {
+		got = append(got, (<-s.Output()).(models.EquityTrade).Symbol)
+	}
+	assert.ElementsMatch(t, []string{"AAPL", "TSLA", "NFLX"}, got)
+
+	stats := s.Stats()
+	assert.Equal(t, uint64(1), stats[0].Messages)
+	assert.Equal(t, uint64(2), stats[1].Messages)
+
+	s.Close()
+	_, more := <-s.Output()
+	assert.False(t, more)
+	assert.NotNil(t, s.Connect())
+}
+
+func TestShardedNotConnected(t *testing.T) {
+	s, err := NewSharded(Config{APIKey: "test", Feed: RealTime, Market: Stocks}, ShardConfig{Shards: 2})
+	assert.Nil(t, err)
+
+	// nothing is forwarded until the shards are connected
+	s.shards[0].client.output <- models.EquityTrade{Symbol: "AAPL"}
+	assert.Never(t, func() bool { return len(s.Output()) > 0 }, 100*time.Millisecond, 10*time.Millisecond)
+
+	// closing a client that never connected doesn't wait for anything
+	s.Close()
+	_, more := <-s.Output()
+	assert.False(t, more)
+}
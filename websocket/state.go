@@ -0,0 +1,108 @@
+package massivews
+
+import (
+	"time"
+)
+
+// State is the state of a client's connection to the server.
+type State int
+
+const (
+	// Disconnected means the client isn't connected. This is the initial state and
+	// the state after an unexpected disconnect, before reconnecting starts.
+	Disconnected State = iota
+
+	// Connecting means the client is dialing the server or waiting to authenticate.
+	Connecting
+
+	// Authenticated means the server accepted the API key.
+	Authenticated
+
+	// Subscribed means the server acknowledged at least one subscription.
+	Subscribed
+
+	// Reconnecting means the client is dialing the server after an unexpected disconnect.
+	Reconnecting
+
+	// Closed means the client was closed, either by the user or after a fatal error.
+	Closed
+)
+
+func (s State) String() string {
+	switch s {
+	case Disconnected:
+		return "disconnected"
+	case Connecting:
+		return "connecting"
+	case Authenticated:
+		return "authenticated"
+	case Subscribed:
+		return "subscribed"
+	case Reconnecting:
+		return "reconnecting"
+	case Closed:
+		return "closed"
+	}
+	return "unknown"
+}
+
+// StateEvent is a change of connection state.
+type StateEvent struct {
+	// State is the new state.
+	State State
+
+	// Previous is the state before the change.
+	Previous State
+
+	// Reason is the error that caused the change, if any (e.g. the read error that
+	// caused a disconnect or the error that closed the client).
+	Reason error
+
+	// Attempt is the dial attempt number for the Connecting and Reconnecting states,
+	// starting at 1.
+	Attempt int
+
+	// Time is when the change happened.
+	Time time.Time
+}
+
+// State returns the current connection state.
+func (c *Client) State() State {
+	c.smtx.Lock()
+	defer c.smtx.Unlock()
+	return c.state
+}
+
+// StateChanges returns a channel of connection state changes. Events are dropped if
+// the channel is full, so it should be drained by the user if it's being used.
+func (c *Client) StateChanges() <-chan StateEvent {
+	return c.states
+}
+
+// setState moves the client to a new state and pushes an event for the change.
+func (c *Client) setState(state State, reason error, attempt int) {
+	c.smtx.Lock()
+	defer c.smtx.Unlock()
+
+	if c.state == state && reason == nil && attempt == 0 {
+		return
+	}
+	if c.state == Closed {
+		return // closed is final
+	}
+
+	ev := StateEvent{
+		State:    state,
+		Previous: c.state,
+		Reason:   reason,
+		Attempt:  attempt,
+		Time:     time.Now(),
+	}
+	c.state = state
+
+	select {
+	case c.states <- ev:
+	default:
+		c.log.Debugf("state event dropped: %v -> %v", ev.Previous, ev.State)
+	}
+}
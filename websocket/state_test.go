@@ -0,0 +1,93 @@
+package massivews
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func nextState(t *testing.T, c *Client) StateEvent {
+	select {
+	case ev := <-c.StateChanges():
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for a state change")
+	}
+	return StateEvent{}
+}
+
+func TestStateChanges(t *testing.T) {
+	s := newServer()
+	defer s.Close()
+
+	u := s.URL()
+	var retries uint64 = 0
+	c, err := New(Config{
+		APIKey:     "good",
+		Feed:       Feed(u),
+		Market:     Market(""),
+		MaxRetries: &retries,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, Disconnected, c.State())
+
+	assert.Nil(t, c.Connect())
+	ev := nextState(t, c)
+	assert.Equal(t, Connecting, ev.State)
+	assert.Equal(t, Disconnected, ev.Previous)
+	assert.Equal(t, 1, ev.Attempt)
+	assert.Equal(t, Authenticated, nextState(t, c).State)
+
+	c.Close()
+	ev = nextState(t, c)
+	assert.Equal(t, Closed, ev.State)
+	assert.Nil(t, ev.Reason)
+	assert.Equal(t, Closed, c.State())
+}
+
+func TestStateAuthFailure(t *testing.T) {
+	s := newServer()
+	defer s.Close()
+
+	u := s.URL()
+	var retries uint64 = 0
+	c, err := New(Config{
+		APIKey:     "bad",
+		Feed:       Feed(u),
+		Market:     Market(""),
+		MaxRetries: &retries,
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, c.Connect())
+	assert.NotNil(t, <-c.Error())
+
+	assert.Equal(t, Connecting, nextState(t, c).State)
+	ev := nextState(t, c)
+	assert.Equal(t, Closed, ev.State)
+	assert.ErrorContains(t, ev.Reason, "authentication failed")
+
+	// closed is only published once
+	c.Close()
+	assert.Len(t, c.StateChanges(), 0)
+}
+
+func TestStateStrings(t *testing.T) {
+	assert.Equal(t, "disconnected", Disconnected.String())
+	assert.Equal(t, "subscribed", Subscribed.String())
+	assert.Equal(t, "reconnecting", Reconnecting.String())
+	assert.Equal(t, "unknown", State(100).String())
+}
+
+func TestSetState(t *testing.T) {
+	c, err := New(Config{APIKey: "test", Feed: RealTime, Market: Stocks})
+	assert.Nil(t, err)
+
+	c.setState(Authenticated, nil, 0)
+	c.setState(Authenticated, nil, 0) // no change
+	c.setState(Subscribed, nil, 0)
+	c.setState(Closed, nil, 0)
+	c.setState(Reconnecting, nil, 1) // closed is final
+	assert.Len(t, c.StateChanges(), 3)
+	assert.Equal(t, Closed, c.State())
+}
@@ -0,0 +1,210 @@
+package massivews
+
+import (
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencyWeight is the weight of the newest sample in the moving average latency.
+const latencyWeight = 0.05
+
+// Stats is a snapshot of a client's throughput, queues and latency.
+type Stats struct {
+	// Topics are the per event type (e.g. T, Q, AM) statistics.
+	Topics map[string]TopicStats
+
+	// Messages is the total number of data messages received.
+	Messages uint64
+
+	// Bytes is the total size in bytes of the data messages received.
+	Bytes uint64
+
+	// ReadQueue is the number of frames waiting to be processed.
+	ReadQueue int
+
+	// WriteQueue is the number of messages waiting to be sent to the server.
+	WriteQueue int
+
+	// OutputQueue is the number of messages waiting to be read from Output.
+	OutputQueue int
+
+	// PingRTT is the round trip time of the last ping.
+	PingRTT time.Duration
+
+	// Reconnects is the number of successful reconnects.
+	Reconnects uint64
+
+	// ReconnectFailures is the number of failed reconnect attempts.
+	ReconnectFailures uint64
+}
+
+// TopicStats is a snapshot of the statistics for a single event type.
+type TopicStats struct {
+	// Messages is the number of messages received.
+	Messages uint64
+
+	// Bytes is the size in bytes of the messages received.
+	Bytes uint64
+
+	// MessagesPerSecond is the number of messages received during the last full second.
+	MessagesPerSecond uint64
+
+	// BytesPerSecond is the number of bytes received during the last full second.
+	BytesPerSecond uint64
+
+	// Latency is the moving average of the time between the message timestamp and
+	// the time it was received by the client. It's zero for messages without a
+	// timestamp.
+	Latency time.Duration
+
+	// LastLatency is the latency of the last message with a timestamp.
+	LastLatency time.Duration
+
+	// LastMessage is the time the last message was received.
+	LastMessage time.Time
+}
+
+// stats collects the statistics for a client.
+type stats struct {
+	mtx    sync.Mutex
+	topics map[string]*topicStats
+
+	pingRTT           atomic.Int64
+	reconnects        atomic.Uint64
+	reconnectFailures atomic.Uint64
+}
+
+type topicStats struct {
+	TopicStats
+	second      int64
+	secMessages uint64
+	secBytes    uint64
+}
+
+func newStats() *stats {
+	return &stats{topics: make(map[string]*topicStats)}
+}
+
+// record counts a message of a given event type and size.
+func (s *stats) record(eventType string, size int, now time.Time) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	t := s.topic(eventType)
+	t.Messages++
+	t.Bytes += uint64(size)
+	t.LastMessage = now
+
+	// roll the one second window, the rate is the count of the last full second
+	sec := now.Unix()
+	if sec != t.second {
+		if sec == t.second+1 {
+			t.MessagesPerSecond, t.BytesPerSecond = t.secMessages, t.secBytes
+		} else {
+			t.MessagesPerSecond, t.BytesPerSecond = 0, 0
+		}
+		t.second, t.secMessages, t.secBytes = sec, 0, 0
+	}
+	t.secMessages++
+	t.secBytes += uint64(size)
+}
+
+// latency records the latency of a message with a timestamp.
+func (s *stats) latency(eventType string, latency time.Duration) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	t := s.topic(eventType)
+	t.LastLatency = latency
+	if t.Latency == 0 {
+		t.Latency = latency
+	} else {
+		t.Latency = time.Duration(latencyWeight*float64(latency) + (1-latencyWeight)*float64(t.Latency))
+	}
+}
+
+func (s *stats) topic(eventType string) *topicStats {
+	t, ok := s.topics[eventType]
+	if !ok {
+		t = &topicStats{}
+		s.topics[eventType] = t
+	}
+	return t
+}
+
+// lastMessage returns the time the last message of an event type was received.
+func (s *stats) lastMessage(eventType string) time.Time {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if t, ok := s.topics[eventType]; ok {
+		return t.LastMessage
+	}
+	return time.Time{}
+}
+
+// snapshot copies the statistics. Rates are zeroed if no message was received
+// during the last full second.
+func (s *stats) snapshot(now time.Time) Stats {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	out := Stats{
+		Topics:            make(map[string]TopicStats, len(s.topics)),
+		PingRTT:           time.Duration(s.pingRTT.Load()),
+		Reconnects:        s.reconnects.Load(),
+		ReconnectFailures: s.reconnectFailures.Load(),
+	}
+	for ev, t := range s.topics {
+		ts := t.TopicStats
+		switch now.Unix() - t.second {
+		case 0:
+		case 1:
+			ts.MessagesPerSecond, ts.BytesPerSecond = t.secMessages, t.secBytes
+		default:
+			ts.MessagesPerSecond, ts.BytesPerSecond = 0, 0
+		}
+		out.Topics[ev] = ts
+		out.Messages += ts.Messages
+		out.Bytes += ts.Bytes
+	}
+	return out
+}
+
+// Stats returns a snapshot of the client's statistics.
+func (c *Client) Stats() Stats {
+	out := c.stats.snapshot(time.Now())
+	out.ReadQueue = len(c.rQueue)
+	out.WriteQueue = len(c.wQueue)
+	out.OutputQueue = len(c.output)
+	return out
+}
+
+// pingPayload returns the payload of a ping, which is the time it was sent.
+func pingPayload(now time.Time) []byte {
+	return strconv.AppendInt(nil, now.UnixNano(), 10)
+}
+
+// pong records the round trip time of a ping from the payload echoed by the server.
+func (c *Client) pong(payload string, now time.Time) {
+	sent, err := strconv.ParseInt(payload, 10, 64)
+	if err != nil || sent <= 0 {
+		return
+	}
+	c.stats.pingRTT.Store(now.UnixNano() - sent)
+}
+
+// messageTime returns the time of a decoded message. Aggregates use the end of
+// their window.
+func messageTime(msg any) (time.Time, bool) {
+	var t time.Time
+	switch m := msg.(type) {
+	case interface{ EndTime() time.Time }:
+		t = m.EndTime()
+	case interface{ Time() time.Time }:
+		t = m.Time()
+	}
+	return t, !t.IsZero()
+}
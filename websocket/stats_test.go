@@ -0,0 +1,85 @@
+package massivews
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/massive-com/client-go/v3/websocket/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsRates(t *testing.T) {
+	s := newStats()
+	start := time.Unix(100, 0)
+
+	s.record("T", 10, start)
+	s.record("T", 20, start.Add(500*time.Millisecond))
+	s.record("Q", 5, start)
+
+	// rates are for the last full second
+	snap := s.snapshot(start.Add(900 * time.Millisecond))
+	assert.Equal(t, uint64(3), snap.Messages)
+	assert.Equal(t, uint64(35), snap.Bytes)
+	assert.Equal(t, uint64(0), snap.Topics["T"].MessagesPerSecond)
+
+	snap = s.snapshot(start.Add(time.Second))
+	assert.Equal(t, uint64(2), snap.Topics["T"].MessagesPerSecond)
+	assert.Equal(t, uint64(30), snap.Topics["T"].BytesPerSecond)
+	assert.Equal(t, start.Add(500*time.Millisecond), snap.Topics["T"].LastMessage)
+
+	s.record("T", 10, start.Add(time.Second))
+	snap = s.snapshot(start.Add(1500 * time.Millisecond))
+	assert.Equal(t, uint64(2), snap.Topics["T"].MessagesPerSecond)
+
+	// a quiet topic has no rate
+	snap = s.snapshot(start.Add(5 * time.Second))
+	assert.Equal(t, uint64(0), snap.Topics["T"].MessagesPerSecond)
+	assert.Equal(t, uint64(0), snap.Topics["Q"].BytesPerSecond)
+}
+
+func TestStatsLatency(t *testing.T) {
+	s := newStats()
+	s.latency("T", 100*time.Millisecond)
+	s.latency("T", 200*time.Millisecond)
+
+	snap := s.snapshot(time.Now())
+	assert.Equal(t, 200*time.Millisecond, snap.Topics["T"].LastLatency)
+	assert.Equal(t, 105*time.Millisecond, snap.Topics["T"].Latency)
+}
+
+func TestClientStats(t *testing.T) {
+	c, err := New(Config{APIKey: "test", Feed: RealTime, Market: Stocks})
+	assert.Nil(t, err)
+
+	trade := models.EquityTrade{EventType: models.EventType{EventType: "T"}, Symbol: "AAPL", Timestamp: models.Millis(time.Now().Add(-time.Second))}
+	data, _ := json.Marshal(trade)
+	status := `{"ev":"status","status":"connected","message":"Connected Successfully"}`
+	assert.Nil(t, c.route([]byte("["+string(data)+","+status+"]")))
+
+	stats := c.Stats()
+	assert.Equal(t, uint64(1), stats.Messages) // status messages aren't counted
+	assert.Equal(t, uint64(len(data)), stats.Topics["T"].Bytes)
+	assert.GreaterOrEqual(t, stats.Topics["T"].Latency, time.Second)
+	assert.Equal(t, 1, stats.OutputQueue)
+
+	c.pong(string(pingPayload(time.Unix(0, 1000))), time.Unix(0, 5000))
+	assert.Equal(t, 4000*time.Nanosecond, c.Stats().PingRTT)
+
+	// invalid payloads are ignored
+	c.pong("nope", time.Now())
+	assert.Equal(t, 4000*time.Nanosecond, c.Stats().PingRTT)
+}
+
+func TestMessageTime(t *testing.T) {
+	ts, ok := messageTime(models.EquityQuote{Timestamp: 1500})
+	assert.True(t, ok)
+	assert.Equal(t, time.UnixMilli(1500), ts)
+
+	ts, ok = messageTime(models.FairMarketValue{Timestamp: 1500})
+	assert.True(t, ok)
+	assert.Equal(t, time.Unix(0, 1500), ts)
+
+	_, ok = messageTime(models.EquityTrade{})
+	assert.False(t, ok)
+}
@@ -0,0 +1,104 @@
+package massivews
+
+import "github.com/massive-com/client-go/v3/websocket/internal/spec"
+
+// feedKind is the kind of feed a topic is served on.
+type feedKind = spec.Kind
+
+const (
+	kindMarket    = spec.KindMarket
+	kindBusiness  = spec.KindBusiness
+	kindLaunchpad = spec.KindLaunchpad
+)
+
+// lookupTopic returns the topic matrix entry for a topic in a market.
+func lookupTopic(market Market, topic Topic) (spec.Topic, bool) {
+	if m := topic.market(); m != "" && m != market.base() {
+		return spec.Topic{}, false
+	}
+	for _, t := range spec.All {
+		if Market(t.Market) == market.base() && t.EventType == topic.prefix() && t.Kind == topic.kind() {
+			return t, true
+		}
+	}
+	return spec.Topic{}, false
+}
+
+// base returns the market whose topics a market shares, e.g. Futures for FuturesCME.
+func (m Market) base() Market {
+	switch m {
+	case FuturesCME, FuturesCBOT, FuturesNYMEX, FuturesCOMEX:
+		return Futures
+	}
+	return m
+}
+
+// known reports whether the market is in the topic matrix.
+func (m Market) known() bool {
+	return spec.Known(string(m.base()))
+}
+
+// market returns the market a topic belongs to, or an empty market for topics that
+// are shared by several markets.
+func (t Topic) market() Market {
+	switch {
+	case t > stocksMin && t < stocksMax:
+		return Stocks
+	case t > optionsMin && t < optionsMax:
+		return Options
+	case t > forexMin && t < forexMax:
+		return Forex
+	case t > cryptoMin && t < cryptoMax:
+		return Crypto
+	case t == IndexSecAggs || t == IndexMinAggs || t == IndexValue:
+		return Indices
+	case t > futuresMin && t < futuresMax:
+		return Futures
+	}
+	return ""
+}
+
+// kind returns the kind of feed a topic is served on.
+func (t Topic) kind() feedKind {
+	switch t {
+	case StocksLaunchpadMinAggs, StocksLaunchpadValue,
+		OptionsLaunchpadMinAggs, OptionsLaunchpadValue,
+		ForexLaunchpadMinAggs, ForexLaunchpadValue,
+		CryptoLaunchpadMinAggs, CryptoLaunchpadValue:
+		return kindLaunchpad
+	case BusinessFairMarketValue:
+		return kindBusiness
+	}
+	return kindMarket
+}
+
+// kind returns the kind of a feed. Custom feeds (e.g. a proxy) aren't known.
+func (f Feed) kind() (feedKind, bool) {
+	switch f {
+	case Delayed, RealTime, Nasdaq, PolyFeed, PolyFeedPlus, StarterFeed:
+		return kindMarket, true
+	case LaunchpadFeed:
+		return kindLaunchpad, true
+	case BusinessFeed, EdgxBusinessFeed, IEXBusiness, DelayedBusinessFeed, DelayedEdgxBusinessFeed,
+		DelayedNasdaqLastSaleBusinessFeed, DelayedNasdaqBasicFeed, DelayedFullMarketBusinessFeed,
+		FullMarketBusinessFeed, NasdaqLastSaleBusinessFeed, NasdaqBasicBusinessFeed:
+		return kindBusiness, true
+	}
+	return 0, false
+}
+
+// serves reports whether a feed serves a topic in a market. Business topics are only
+// served on business feeds and exclusive Launchpad topics only on the Launchpad feed.
+// Custom feeds and unknown markets are assumed to serve everything.
+func (f Feed) serves(market Market, topic Topic) bool {
+	kind, ok := f.kind()
+	if !ok {
+		return true
+	}
+	t, ok := lookupTopic(market, topic)
+	if !ok {
+		return true // it's up to the market to reject it
+	}
+
+	return t.ServedOn(kind)
+}
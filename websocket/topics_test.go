@@ -0,0 +1,121 @@
+package massivews
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/massive-com/client-go/v3/websocket/internal/spec"
+	"github.com/massive-com/client-go/v3/websocket/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTopicMatrix(t *testing.T) {
+	// every topic constant is in the matrix for its market
+	for market, topics := range map[Market][]Topic{
+		Stocks:     {StocksSecAggs, StocksMinAggs, StocksTrades, StocksQuotes, StocksImbalances, StocksLULD, StocksLaunchpadMinAggs, StocksLaunchpadValue, BusinessFairMarketValue},
+		Options:    {OptionsSecAggs, OptionsMinAggs, OptionsTrades, OptionsQuotes, OptionsLaunchpadMinAggs, OptionsLaunchpadValue, BusinessFairMarketValue},
+		Forex:      {ForexSecAggs, ForexMinAggs, ForexQuotes, ForexLaunchpadMinAggs, ForexLaunchpadValue, BusinessFairMarketValue},
+		Crypto:     {CryptoSecAggs, CryptoMinAggs, CryptoTrades, CryptoQuotes, CryptoL2Book, CryptoLaunchpadMinAggs, CryptoLaunchpadValue, BusinessFairMarketValue},
+		Indices:    {IndexSecAggs, IndexMinAggs, IndexValue},
+		FuturesCME: {FutureSecAggs, FutureMinAggs, FutureTrades, FutureQuotes},
+	} {
+		for _, topic := range topics {
+			assert.True(t, market.supports(topic), "%v %v", market, topic.prefix())
+		}
+	}
+
+	// event types are shared between markets but topics aren't
+	assert.False(t, Options.supports(StocksTrades))
+	assert.False(t, Futures.supports(StocksTrades))
+	assert.False(t, Stocks.supports(FutureTrades))
+	assert.False(t, Indices.supports(BusinessFairMarketValue))
+	assert.False(t, Futures.supports(BusinessFairMarketValue))
+	assert.False(t, Stocks.supports(Topic(200)))
+}
+
+func TestFeedServes(t *testing.T) {
+	// business topics need a business feed
+	assert.True(t, BusinessFeed.serves(Stocks, BusinessFairMarketValue))
+	assert.True(t, DelayedNasdaqBasicFeed.serves(Crypto, BusinessFairMarketValue))
+	assert.False(t, RealTime.serves(Stocks, BusinessFairMarketValue))
+	assert.False(t, LaunchpadFeed.serves(Stocks, BusinessFairMarketValue))
+
+	// exclusive launchpad topics need the launchpad feed
+	assert.True(t, LaunchpadFeed.serves(Forex, ForexLaunchpadMinAggs))
+	assert.False(t, RealTime.serves(Forex, ForexLaunchpadMinAggs))
+	assert.False(t, BusinessFeed.serves(Stocks, StocksLaunchpadValue))
+
+	// market topics are served everywhere
+	assert.True(t, BusinessFeed.serves(Stocks, StocksTrades))
+	assert.True(t, Delayed.serves(Indices, IndexValue))
+
+	// custom feeds are assumed to serve everything
+	assert.True(t, Feed("ws://localhost:8080").serves(Stocks, BusinessFairMarketValue))
+
+	c, err := New(Config{APIKey: "test", Feed: RealTime, Market: Stocks})
+	assert.Nil(t, err)
+	assert.NotNil(t, c.Subscribe(BusinessFairMarketValue))
+	assert.NotNil(t, c.Subscribe(StocksLaunchpadValue))
+	assert.Nil(t, c.Subscribe(StocksTrades))
+}
+
+func TestDecodeEveryFeed(t *testing.T) {
+	// launchpad aggregates decode for every market that has them, into the
+	// aggregate type of the market
+	for market, agg := range map[Market]any{
+		Stocks:  models.EquityAgg{EventType: models.EventType{EventType: "AM"}, Symbol: "X", Open: 1, AverageSize: 3},
+		Options: models.EquityAgg{EventType: models.EventType{EventType: "AM"}, Symbol: "X", Open: 1, AverageSize: 3},
+		Forex:   models.CurrencyAgg{EventType: models.EventType{EventType: "AM"}, Pair: "X", Open: 1, AVGTradeSize: 3},
+		Crypto:  models.CurrencyAgg{EventType: models.EventType{EventType: "AM"}, Pair: "X", Open: 1, AVGTradeSize: 3},
+	} {
+		c, err := New(Config{APIKey: "test", Feed: LaunchpadFeed, Market: market})
+		assert.Nil(t, err)
+		assert.Nil(t, c.route([]byte(`[{"ev":"AM","sym":"X","o":1,"z":3},{"ev":"LV","sym":"X","val":2}]`)))
+		assert.Equal(t, agg, <-c.Output(), market)
+		assert.Equal(t, models.LaunchpadValue{EventType: models.EventType{EventType: "LV"}, Ticker: "X", Value: 2}, <-c.Output())
+	}
+
+	// futures decode on every futures market
+	for _, market := range []Market{Futures, FuturesCME, FuturesCBOT, FuturesNYMEX, FuturesCOMEX} {
+		c, err := New(Config{APIKey: "test", Feed: RealTime, Market: market})
+		assert.Nil(t, err)
+		assert.Nil(t, c.route([]byte(`[{"ev":"T","sym":"ESZ5","p":1}]`)))
+		assert.Equal(t, models.FuturesTrade{EventType: models.EventType{EventType: "T"}, Symbol: "ESZ5", Price: 1}, <-c.Output())
+	}
+
+	// indices and futures don't serve FMV or LV, so they're neither subscribable
+	// nor decoded
+	for _, missing := range spec.Missing {
+		market := Market(missing.Market)
+		decode, known := lookupEvent(market, missing.EventType)
+		assert.True(t, known)
+		assert.Nil(t, decode, "%v %v", market, missing.EventType)
+
+		c, err := New(Config{APIKey: "test", Feed: BusinessFeed, Market: market})
+		assert.Nil(t, err)
+		assert.Nil(t, c.route([]byte(`[{"ev":"`+missing.EventType+`","sym":"X","fmv":1,"val":1}]`)))
+		assert.Len(t, c.Output(), 0)
+	}
+	assert.NotNil(t, mustClient(t, BusinessFeed, Indices).Subscribe(BusinessFairMarketValue))
+	assert.NotNil(t, mustClient(t, BusinessFeed, FuturesCME).Subscribe(BusinessFairMarketValue))
+
+}
+
+func TestUnknownMarket(t *testing.T) {
+	// unknown markets accept any subscription and deliver their data undecoded, with
+	// or without RawData
+	for _, raw := range []bool{false, true} {
+		c, err := New(Config{APIKey: "test", Feed: RealTime, Market: Market("bonds"), RawData: raw})
+		assert.Nil(t, err)
+		assert.Nil(t, c.Subscribe(StocksTrades))
+		assert.Nil(t, c.route([]byte(`[{"ev":"T","sym":"X"},{"ev":"Q","sym":"Y"}]`)))
+		assert.Equal(t, json.RawMessage(`{"ev":"T","sym":"X"}`), <-c.Output(), raw)
+		assert.Equal(t, json.RawMessage(`{"ev":"Q","sym":"Y"}`), <-c.Output(), raw)
+	}
+}
+
+func mustClient(t *testing.T, feed Feed, market Market) *Client {
+	c, err := New(Config{APIKey: "test", Feed: feed, Market: market})
+	assert.Nil(t, err)
+	return c
+}
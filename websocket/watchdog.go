@@ -0,0 +1,161 @@
+package massivews
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrStaleFeed is the reason for a reconnect forced by the stale-feed watchdog.
+var ErrStaleFeed = errors.New("stale feed")
+
+// watchdog tracks since when data is expected for each subscribed topic.
+type watchdog struct {
+	threshold   time.Duration
+	marketHours func(time.Time) bool
+
+	mtx    sync.Mutex
+	topics map[Topic]time.Time
+}
+
+func newWatchdog(config Config) *watchdog {
+	if config.StaleThreshold <= 0 {
+		return nil
+	}
+	return &watchdog{
+		threshold:   config.StaleThreshold,
+		marketHours: config.MarketHours,
+		topics:      make(map[Topic]time.Time),
+	}
+}
+
+// add starts watching a topic if it isn't watched already.
+func (w *watchdog) add(topic Topic, now time.Time) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	if _, ok := w.topics[topic]; !ok {
+		w.topics[topic] = now
+	}
+}
+
+// remove stops watching a topic.
+func (w *watchdog) remove(topic Topic) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	delete(w.topics, topic)
+}
+
+// reset restarts the clock for every topic, e.g. after connecting or while the
+// market is closed.
+func (w *watchdog) reset(now time.Time) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	for topic := range w.topics {
+		w.topics[topic] = now
+	}
+}
+
+// check returns a topic that hasn't received data within the threshold and the
+// time it was last seen or started being watched.
+func (w *watchdog) check(now time.Time, lastMessage func(eventType string) time.Time) (Topic, time.Time, bool) {
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+
+	for topic, since := range w.topics {
+		if last := lastMessage(topic.prefix()); last.After(since) {
+			since = last
+		}
+		if now.Sub(since) > w.threshold {
+			return topic, since, true
+		}
+	}
+	return 0, time.Time{}, false
+}
+
+// interval is how often the topics are checked.
+func (w *watchdog) interval() time.Duration {
+	return min(w.threshold/4, time.Second)
+}
+
+// watch runs alongside the read/write threads and kills them with ErrStaleFeed if a
+// subscribed topic stops receiving data, which triggers a reconnect.
+func (c *Client) watch() error {
+	ticker := time.NewTicker(c.watchdog.interval())
+	defer func() {
+		c.log.Debugf("watchdog closed")
+		ticker.Stop()
+	}()
+
+	c.watchdog.reset(time.Now())
+	for {
+		select {
+		case <-c.rwtomb.Dying():
+			return nil
+		case now := <-ticker.C:
+			if c.watchdog.marketHours != nil && !c.watchdog.marketHours(now) {
+				c.watchdog.reset(now)
+				continue
+			}
+			if topic, since, stale := c.watchdog.check(now, c.stats.lastMessage); stale {
+				c.log.Errorf("no '%v' messages since %v: reconnecting", topic.prefix(), since)
+				return fmt.Errorf("%w: no '%v' messages since %v", ErrStaleFeed, topic.prefix(), since.Format(time.RFC3339))
+			}
+		}
+	}
+}
+
+var (
+	newYorkOnce sync.Once
+	newYork     *time.Location
+)
+
+// eastern returns a time in US Eastern time. The America/New_York zone is loaded
+// the first time it's needed; if there's no time zone database to load it from, the
+// current US daylight saving rules are used instead.
+func eastern(t time.Time) time.Time {
+	newYorkOnce.Do(func() {
+		newYork, _ = time.LoadLocation("America/New_York")
+	})
+	if newYork != nil {
+		return t.In(newYork)
+	}
+	return t.In(easternRules(t))
+}
+
+// easternRules returns the US Eastern offset in effect at a given time, following
+// the daylight saving rules in place since 2007: from 2am on the second Sunday of
+// March to 2am on the first Sunday of November.
+func easternRules(t time.Time) *time.Location {
+	year := t.UTC().Year()
+	start := nthSunday(year, time.March, 2).Add(7 * time.Hour)  // 2am EST
+	end := nthSunday(year, time.November, 1).Add(6 * time.Hour) // 2am EDT
+	if !t.Before(start) && t.Before(end) {
+		return time.FixedZone("EDT", -4*60*60)
+	}
+	return time.FixedZone("EST", -5*60*60)
+}
+
+// nthSunday returns midnight UTC of the nth Sunday of a month.
+func nthSunday(year int, month time.Month, n int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	offset := (7 - int(first.Weekday())) % 7
+	return first.AddDate(0, 0, offset+7*(n-1))
+}
+
+// USMarketHours reports whether the US stock market's regular session (9:30am to
+// 4pm Eastern, Monday to Friday) is open at a given time. Holidays aren't taken
+// into account. It can be used as Config.MarketHours for stocks and options.
+//
+// The America/New_York zone is loaded from the system's time zone database. If the
+// system has none, it falls back to the current US daylight saving rules; import
+// time/tzdata in the application to use the embedded database instead.
+func USMarketHours(t time.Time) bool {
+	t = eastern(t)
+	switch t.Weekday() {
+	case time.Saturday, time.Sunday:
+		return false
+	}
+	minute := t.Hour()*60 + t.Minute()
+	return minute >= 9*60+30 && minute < 16*60
+}
@@ -0,0 +1,145 @@
+package massivews
+
+import (
+	"errors"
+	"testing"
+	"time"
+	_ "time/tzdata" // the tests compare against America/New_York wherever they run
+
+	"github.com/massive-com/client-go/v3/websocket/fakeserver"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatchdogCheck(t *testing.T) {
+	w := newWatchdog(Config{StaleThreshold: time.Minute})
+	start := time.Unix(1000, 0)
+	var last time.Time
+	lastMessage := func(ev string) time.Time {
+		if ev == "T" {
+			return last
+		}
+		return time.Time{}
+	}
+
+	// nothing is watched
+	_, _, stale := w.check(start.Add(time.Hour), lastMessage)
+	assert.False(t, stale)
+
+	w.add(StocksTrades, start)
+	_, _, stale = w.check(start.Add(time.Minute), lastMessage)
+	assert.False(t, stale)
+	topic, since, stale := w.check(start.Add(time.Minute+time.Second), lastMessage)
+	assert.True(t, stale)
+	assert.Equal(t, StocksTrades, topic)
+	assert.Equal(t, start, since)
+
+	// data keeps the topic alive
+	last = start.Add(30 * time.Second)
+	_, _, stale = w.check(start.Add(time.Minute+time.Second), lastMessage)
+	assert.False(t, stale)
+
+	// adding a topic again doesn't restart its clock, but a reset does
+	w.add(StocksTrades, start.Add(time.Hour))
+	_, _, stale = w.check(start.Add(2*time.Minute), lastMessage)
+	assert.True(t, stale)
+	w.reset(start.Add(2 * time.Minute))
+	_, _, stale = w.check(start.Add(2*time.Minute), lastMessage)
+	assert.False(t, stale)
+
+	w.remove(StocksTrades)
+	_, _, stale = w.check(start.Add(time.Hour), lastMessage)
+	assert.False(t, stale)
+
+	// the watchdog is disabled by default
+	assert.Nil(t, newWatchdog(Config{}))
+}
+
+func newWatchdogClient(t *testing.T, s *fakeserver.Server, config Config) *Client {
+	var retries uint64 = 0
+	config.APIKey = "good"
+	config.Feed = Feed(s.URL())
+	config.Market = Stocks
+	config.MaxRetries = &retries
+	c, err := New(config)
+	assert.Nil(t, err)
+	return c
+}
+
+func TestWatchdogReconnect(t *testing.T) {
+	s := fakeserver.New(fakeserver.Config{})
+	defer s.Close()
+
+	c := newWatchdogClient(t, s, Config{StaleThreshold: 100 * time.Millisecond})
+	assert.Nil(t, c.Connect())
+	assert.Nil(t, c.Subscribe(StocksTrades, "AAPL"))
+	defer c.Close()
+
+	// no data is ever sent, so the client is forced to reconnect
+	for {
+		ev := nextState(t, c)
+		if ev.State == Disconnected {
+			assert.True(t, errors.Is(ev.Reason, ErrStaleFeed))
+			break
+		}
+	}
+	assert.Equal(t, Reconnecting, nextState(t, c).State)
+	assert.Equal(t, Authenticated, nextState(t, c).State)
+}
+
+func TestWatchdogMarketHours(t *testing.T) {
+	s := fakeserver.New(fakeserver.Config{})
+	defer s.Close()
+
+	c := newWatchdogClient(t, s, Config{
+		StaleThreshold: 50 * time.Millisecond,
+		MarketHours:    func(time.Time) bool { return false },
+	})
+	assert.Nil(t, c.Connect())
+	assert.Nil(t, c.Subscribe(StocksTrades, "AAPL"))
+
+	time.Sleep(300 * time.Millisecond)
+	c.Close()
+	for ev := range c.StateChanges() {
+		assert.NotEqual(t, Disconnected, ev.State)
+		if ev.State == Closed {
+			break
+		}
+	}
+}
+
+func TestUSMarketHours(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err)
+
+	assert.True(t, USMarketHours(time.Date(2024, 6, 3, 9, 30, 0, 0, ny)))
+	assert.True(t, USMarketHours(time.Date(2024, 6, 3, 15, 59, 0, 0, ny)))
+	assert.False(t, USMarketHours(time.Date(2024, 6, 3, 9, 29, 0, 0, ny)))
+	assert.False(t, USMarketHours(time.Date(2024, 6, 3, 16, 0, 0, 0, ny)))
+	assert.False(t, USMarketHours(time.Date(2024, 6, 1, 12, 0, 0, 0, ny))) // saturday
+	assert.True(t, USMarketHours(time.Date(2024, 6, 3, 14, 0, 0, 0, time.UTC)))
+
+	// daylight saving time moves the open to 13:30 UTC in summer and 14:30 in winter
+	assert.True(t, USMarketHours(time.Date(2024, 6, 3, 13, 35, 0, 0, time.UTC)))
+	assert.False(t, USMarketHours(time.Date(2024, 12, 2, 13, 35, 0, 0, time.UTC)))
+	assert.True(t, USMarketHours(time.Date(2024, 12, 2, 14, 35, 0, 0, time.UTC)))
+}
+
+func TestEasternRules(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	assert.Nil(t, err)
+
+	// the fallback matches the time zone database around every transition
+	for year := 2008; year <= 2030; year++ {
+		for _, start := range []time.Time{
+			time.Date(year, time.March, 1, 0, 0, 0, 0, time.UTC),
+			time.Date(year, time.November, 1, 0, 0, 0, 0, time.UTC),
+		} {
+			for d := time.Duration(0); d < 14*24*time.Hour; d += 30 * time.Minute {
+				at := start.Add(d)
+				_, want := at.In(ny).Zone()
+				_, got := at.In(easternRules(at)).Zone()
+				assert.Equal(t, want, got, at)
+			}
+		}
+	}
+}